@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/admin"
+	"github.com/datanorthnordik/nordikdriveapi/internal/auth"
+	"github.com/datanorthnordik/nordikdriveapi/internal/chat"
+	"github.com/datanorthnordik/nordikdriveapi/internal/config"
+	"github.com/datanorthnordik/nordikdriveapi/internal/database"
+	"github.com/datanorthnordik/nordikdriveapi/internal/file"
+	"github.com/datanorthnordik/nordikdriveapi/internal/formsubmission"
+	"github.com/datanorthnordik/nordikdriveapi/internal/linkage"
+	"github.com/datanorthnordik/nordikdriveapi/internal/llm"
+	"github.com/datanorthnordik/nordikdriveapi/internal/logs"
+	"github.com/datanorthnordik/nordikdriveapi/internal/middleware"
+	"github.com/datanorthnordik/nordikdriveapi/internal/notifications"
+	"github.com/datanorthnordik/nordikdriveapi/internal/permissions"
+	"github.com/datanorthnordik/nordikdriveapi/internal/public"
+	"github.com/datanorthnordik/nordikdriveapi/internal/role"
+)
+
+// Container holds every constructed dependency behind its package's
+// Service interface. Build wires the default implementations; a test or
+// an alternate deployment can fill the fields differently and call
+// Router without touching main.
+type Container struct {
+	Config *config.Config
+	DB     *gorm.DB
+	Logs   *logs.LogService
+	LLM    llm.Provider
+
+	Auth          auth.Service
+	Files         file.Service
+	Admin         admin.Service
+	Forms         formsubmission.Service
+	Chat          chat.Service
+	Linkage       linkage.Service
+	Notifications notifications.Service
+	Public        public.Service
+	Roles         role.Service
+}
+
+// Build connects to the database, constructs every service with its
+// default implementation and starts the background loops. The caller
+// owns the container and must Close it on shutdown.
+func Build(ctx context.Context, cfg *config.Config) (*Container, error) {
+	db, err := database.Connect(cfg.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.StartupSelfCheck {
+		if err := database.SelfCheck(ctx, db, cfg.GCSBucket); err != nil {
+			return nil, err
+		}
+	}
+
+	logService := logs.NewLogService(db, cfg)
+	if cfg.LogRetentionDays > 0 {
+		go logService.RunRetentionLoop(ctx, cfg.LogRetentionDays)
+	}
+	middleware.EnableSecurityAudit(logService)
+	if err := permissions.Reload(db); err != nil {
+		return nil, err
+	}
+
+	notificationService := notifications.NewNotificationService(db, cfg, logService)
+	if cfg.DigestIntervalHours > 0 {
+		go notificationService.RunDigestLoop(ctx, cfg.DigestIntervalHours)
+	}
+	fileService := file.NewFileService(db, cfg, logService)
+	fileService.Notifier = notificationService
+	if cfg.EditRequestStaleDays > 0 {
+		go fileService.RunStaleEditRequestLoop(ctx, cfg.EditRequestStaleDays)
+	}
+	if cfg.VersionArchiveDays > 0 {
+		go fileService.RunVersionArchiveLoop(ctx, cfg.VersionArchiveDays)
+	}
+
+	llmProvider, err := llm.New(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Container{
+		Config:        cfg,
+		DB:            db,
+		Logs:          logService,
+		LLM:           llmProvider,
+		Auth:          auth.NewAuthService(db, cfg, logService),
+		Files:         fileService,
+		Admin:         admin.NewAdminService(db, cfg, logService),
+		Forms:         formsubmission.NewFormSubmissionService(db, cfg, logService),
+		Chat:          chat.NewChatService(db, cfg, llmProvider, fileService, logService),
+		Linkage:       linkage.NewLinkageService(db, cfg, logService),
+		Notifications: notificationService,
+		Public:        public.NewPublicService(db),
+		Roles:         role.NewRoleService(db, logService),
+	}, nil
+}
+
+// Router builds the gin engine and mounts every controller against the
+// container's services.
+func (c *Container) Router() *gin.Engine {
+	router := gin.Default()
+	router.Use(cors.Default())
+	middleware.SetMaintenanceMode(c.Config.MaintenanceMode)
+	router.Use(middleware.MaintenanceGuard())
+	router.Use(middleware.Compress())
+	middleware.SetHeavyConcurrency(c.Config.HeavyConcurrency)
+
+	authController := auth.NewAuthController(c.Auth)
+	authGroup := router.Group("/auth")
+	authController.RegisterRoutes(authGroup)
+	authController.RegisterProtectedRoutes(authGroup, c.Config.JWTSecret)
+
+	publicGroup := router.Group("/public/api", public.RateLimit(60, time.Minute))
+	public.NewPublicController(c.Public).RegisterRoutes(publicGroup)
+	formsController := formsubmission.NewFormSubmissionController(c.Forms)
+	formsController.RegisterPublicRoutes(publicGroup)
+
+	api := router.Group("/api",
+		middleware.RequireAuth(c.Config.JWTSecret),
+		middleware.AuditImpersonation(c.Logs),
+		middleware.TrackActivity(c.DB))
+	chatController := chat.NewChatController(c.Chat)
+	fileController := file.NewFileController(c.Files)
+	fileController.RegisterRoutes(api)
+	formsController.RegisterRoutes(api)
+	chatController.RegisterRoutes(api)
+	notifications.NewNotificationController(c.Notifications).RegisterRoutes(api)
+	roleController := role.NewRoleController(c.Roles)
+	roleController.RegisterRoutes(api)
+
+	adminGroup := api.Group("/admin", middleware.RequireRole("Admin"))
+	admin.NewAdminController(c.Admin, c.Logs).RegisterRoutes(adminGroup)
+	chatController.RegisterAdminRoutes(adminGroup)
+	authController.RegisterAdminRoutes(adminGroup)
+	linkage.NewLinkageController(c.Linkage).RegisterAdminRoutes(adminGroup)
+	fileController.RegisterAdminRoutes(adminGroup)
+	roleController.RegisterAdminRoutes(adminGroup)
+
+	router.GET("/healthz", func(c *gin.Context) {
+		c.JSON(200, gin.H{"status": "ok"})
+	})
+
+	return router
+}
+
+// Close releases resources held by the container.
+func (c *Container) Close() {
+	if c.LLM != nil {
+		if err := c.LLM.Close(); err != nil {
+			log.Printf("container: close llm provider: %v", err)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/config"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+
+	container, err := Build(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("build: %v", err)
+	}
+	defer container.Close()
+
+	if err := container.Router().Run(":" + cfg.Port); err != nil {
+		log.Fatalf("server: %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package admin
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/storage"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+	"github.com/datanorthnordik/nordikdriveapi/internal/util"
+)
+
+// exportCommunityColumns mirrors chat's community column list so both
+// features recognize the same partner spreadsheet headers.
+var exportCommunityColumns = []string{"Community", "community", "First Nation", "Band", "Reserve"}
+
+// communityMatchExpr builds the SQL expression picking the first
+// populated community column of a row, mirroring the chat context
+// builder's priority order. Callers must append one argument per
+// community column.
+func communityMatchExpr() string {
+	parts := make([]string, 0, len(exportCommunityColumns))
+	for range exportCommunityColumns {
+		parts = append(parts, "NULLIF(row_data ->> ?, '')")
+	}
+	return "COALESCE(" + strings.Join(parts, ", ") + ", '')"
+}
+
+// StreamCommunityZip exports every file's rows belonging to one
+// community as per-file CSVs, plus the approved media attached to those
+// rows, streamed as a ZIP. It backs the one-click fulfilment of data
+// sharing agreements with individual communities.
+func (s *AdminService) StreamCommunityZip(ctx context.Context, community string, adminID uint, w io.Writer) error {
+	var files []models.File
+	if err := s.DB.Where("is_delete = false").Order("filename").Find(&files).Error; err != nil {
+		return err
+	}
+
+	client, err := newGCSClientHook(ctx)
+	if err != nil {
+		return fmt.Errorf("gcs client: %w", err)
+	}
+	defer client.Close()
+
+	zw := zip.NewWriter(w)
+	defer zw.Close()
+
+	totalRows := 0
+	for _, file := range files {
+		matched, err := s.communityRows(file, community)
+		if err != nil {
+			return err
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		totalRows += len(matched)
+
+		if err := writeCommunityCSV(zw, file, matched); err != nil {
+			return err
+		}
+		if err := s.writeCommunityMedia(ctx, client, zw, file, matched); err != nil {
+			return err
+		}
+	}
+
+	s.Logs.Record(adminID, "EXPORT_COMMUNITY", fmt.Sprintf("community=%s rows=%d", community, totalRows))
+	return nil
+}
+
+// communityRows returns the current-version rows of a file that belong
+// to the community. The match runs as JSONB operators in Postgres: rows
+// with no recognizable community column belong to everyone, otherwise
+// the first populated column must contain the community name.
+func (s *AdminService) communityRows(file models.File, community string) ([]models.FileData, error) {
+	expr := communityMatchExpr()
+	args := []interface{}{file.ID, file.Version}
+	// The match expression appears twice in the clause (empty check and
+	// ILIKE), so its column arguments do too.
+	for i := 0; i < 2; i++ {
+		for _, col := range exportCommunityColumns {
+			args = append(args, col)
+		}
+	}
+	args = append(args, "%"+community+"%")
+
+	var rows []models.FileData
+	if err := s.DB.
+		Where("file_id = ? AND version = ? AND ("+expr+" = '' OR "+expr+" ILIKE ?)", args...).
+		Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func writeCommunityCSV(zw *zip.Writer, file models.File, rows []models.FileData) error {
+	var headers []string
+	if err := json.Unmarshal(file.ColumnsOrder, &headers); err != nil {
+		return fmt.Errorf("decode column order for %s: %w", file.Filename, err)
+	}
+	entryWriter, err := zw.Create("files/" + file.Filename + ".csv")
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(entryWriter)
+	if err := cw.Write(append([]string{"row_id"}, headers...)); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		var data map[string]string
+		if err := json.Unmarshal(row.RowData, &data); err != nil {
+			continue
+		}
+		record := make([]string, 0, len(headers)+1)
+		record = append(record, fmt.Sprint(row.ID))
+		for _, h := range headers {
+			record = append(record, data[h])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeCommunityMedia streams the approved photos and documents attached
+// to the matched rows into the ZIP under media/<filename>/row_<id>/.
+func (s *AdminService) writeCommunityMedia(ctx context.Context, client *storage.Client, zw *zip.Writer, file models.File, rows []models.FileData) error {
+	rowIDs := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		rowIDs = append(rowIDs, row.ID)
+	}
+
+	var photos []models.FileEditRequestPhoto
+	if err := s.DB.Where("row_id IN ? AND status = ?", rowIDs, "approved").
+		Find(&photos).Error; err != nil {
+		return err
+	}
+	var docs []models.FileEditRequestDocument
+	if err := s.DB.
+		Joins("JOIN file_edit_request ON file_edit_request.id = file_edit_request_documents.request_id").
+		Where("file_edit_request_documents.row_id IN ? AND file_edit_request.status = ?", rowIDs, "approved").
+		Find(&docs).Error; err != nil {
+		return err
+	}
+
+	type entry struct {
+		rowID uint
+		gsURL string
+	}
+	entries := make([]entry, 0, len(photos)+len(docs))
+	for _, p := range photos {
+		entries = append(entries, entry{rowID: p.RowID, gsURL: p.GCSPath})
+	}
+	for _, d := range docs {
+		entries = append(entries, entry{rowID: d.RowID, gsURL: d.GCSPath})
+	}
+	for _, e := range entries {
+		bucket, object, err := util.ParseGSURL(e.gsURL)
+		if err != nil {
+			return err
+		}
+		reader, err := client.Bucket(bucket).Object(object).NewReader(ctx)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", e.gsURL, err)
+		}
+		name := fmt.Sprintf("media/%s/row_%d/%s", file.Filename, e.rowID, path.Base(object))
+		entryWriter, err := zw.Create(name)
+		if err != nil {
+			reader.Close()
+			return err
+		}
+		if _, err := io.Copy(entryWriter, reader); err != nil {
+			reader.Close()
+			return fmt.Errorf("copy %s: %w", e.gsURL, err)
+		}
+		reader.Close()
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var ErrBadCompareKey = errors.New("key column must exist in both files")
+
+// compareListLimit caps the per-group key lists in the JSON report;
+// the counts stay exact and the XLSX export carries the full detail.
+const compareListLimit = 1000
+
+// CompareRequest names the two files to reconcile and the column whose
+// value identifies a record in both (e.g. a registry number).
+type CompareRequest struct {
+	FileA     uint   `json:"file_a" binding:"required"`
+	FileB     uint   `json:"file_b" binding:"required"`
+	KeyColumn string `json:"key_column" binding:"required"`
+}
+
+// CompareDiff is one column whose value differs between the two files
+// for the same key.
+type CompareDiff struct {
+	Column string `json:"column"`
+	ValueA string `json:"value_a"`
+	ValueB string `json:"value_b"`
+}
+
+// CompareRow groups a matched record's differing columns.
+type CompareRow struct {
+	Key   string        `json:"key"`
+	Diffs []CompareDiff `json:"diffs"`
+}
+
+// CompareResult is the reconciliation report between two files.
+type CompareResult struct {
+	FileA         string       `json:"file_a"`
+	FileB         string       `json:"file_b"`
+	KeyColumn     string       `json:"key_column"`
+	OnlyInACount  int          `json:"only_in_a_count"`
+	OnlyInBCount  int          `json:"only_in_b_count"`
+	DifferCount   int          `json:"differ_count"`
+	MatchCount    int          `json:"match_count"`
+	DuplicateKeys int          `json:"duplicate_keys"`
+	OnlyInA       []string     `json:"only_in_a"`
+	OnlyInB       []string     `json:"only_in_b"`
+	Differing     []CompareRow `json:"differing"`
+}
+
+// CompareFiles reconciles two files' current versions on a key column:
+// records present only in one file, and matched records whose other
+// columns differ. Rows whose key repeats within a file are compared on
+// the last occurrence and counted as duplicates.
+func (s *AdminService) CompareFiles(ctx context.Context, req CompareRequest) (*CompareResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+
+	fileA, rowsA, err := s.compareSide(ctx, req.FileA, req.KeyColumn)
+	if err != nil {
+		return nil, err
+	}
+	fileB, rowsB, err := s.compareSide(ctx, req.FileB, req.KeyColumn)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CompareResult{
+		FileA:     fileA.file.Filename,
+		FileB:     fileB.file.Filename,
+		KeyColumn: req.KeyColumn,
+	}
+	result.DuplicateKeys = fileA.duplicates + fileB.duplicates
+
+	for key, rowA := range rowsA {
+		rowB, ok := rowsB[key]
+		if !ok {
+			result.OnlyInACount++
+			if len(result.OnlyInA) < compareListLimit {
+				result.OnlyInA = append(result.OnlyInA, key)
+			}
+			continue
+		}
+		diffs := diffRows(rowA, rowB, req.KeyColumn)
+		if len(diffs) == 0 {
+			result.MatchCount++
+			continue
+		}
+		result.DifferCount++
+		if len(result.Differing) < compareListLimit {
+			result.Differing = append(result.Differing, CompareRow{Key: key, Diffs: diffs})
+		}
+	}
+	for key := range rowsB {
+		if _, ok := rowsA[key]; !ok {
+			result.OnlyInBCount++
+			if len(result.OnlyInB) < compareListLimit {
+				result.OnlyInB = append(result.OnlyInB, key)
+			}
+		}
+	}
+	return result, nil
+}
+
+// compareSide loads one file's current rows keyed by the key column.
+type comparedFile struct {
+	file       models.File
+	duplicates int
+}
+
+func (s *AdminService) compareSide(ctx context.Context, fileID uint, keyColumn string) (*comparedFile, map[string]map[string]string, error) {
+	var file models.File
+	if err := s.DB.WithContext(ctx).First(&file, fileID).Error; err != nil {
+		return nil, nil, fmt.Errorf("file %d not found", fileID)
+	}
+	var columns []string
+	_ = json.Unmarshal(file.ColumnsOrder, &columns)
+	known := false
+	for _, c := range columns {
+		if c == keyColumn {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, nil, fmt.Errorf("%w: %q is not a column of %s", ErrBadCompareKey, keyColumn, file.Filename)
+	}
+
+	var rows []models.FileData
+	if err := s.DB.WithContext(ctx).
+		Where("file_id = ? AND version = ?", file.ID, file.Version).
+		Order("id").Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+	side := &comparedFile{file: file}
+	keyed := make(map[string]map[string]string, len(rows))
+	for _, row := range rows {
+		var data map[string]string
+		if err := json.Unmarshal(row.RowData, &data); err != nil {
+			continue
+		}
+		key := data[keyColumn]
+		if key == "" {
+			continue
+		}
+		if _, seen := keyed[key]; seen {
+			side.duplicates++
+		}
+		keyed[key] = data
+	}
+	return side, keyed, nil
+}
+
+// diffRows lists the columns present in either row whose values differ,
+// skipping the key itself.
+func diffRows(a, b map[string]string, keyColumn string) []CompareDiff {
+	var diffs []CompareDiff
+	seen := map[string]bool{}
+	for column, valueA := range a {
+		if column == keyColumn {
+			continue
+		}
+		seen[column] = true
+		if valueB := b[column]; valueA != valueB {
+			diffs = append(diffs, CompareDiff{Column: column, ValueA: valueA, ValueB: valueB})
+		}
+	}
+	for column, valueB := range b {
+		if column == keyColumn || seen[column] {
+			continue
+		}
+		if valueB != "" {
+			diffs = append(diffs, CompareDiff{Column: column, ValueA: "", ValueB: valueB})
+		}
+	}
+	return diffs
+}
+
+// CompareFilesXLSX renders the full reconciliation as a spreadsheet,
+// one row per finding, with differing values highlighted so reviewers
+// can scan for what needs reconciling.
+func (s *AdminService) CompareFilesXLSX(ctx context.Context, req CompareRequest) ([]byte, error) {
+	result, err := s.CompareFiles(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	wb := excelize.NewFile()
+	defer wb.Close()
+	sheet := wb.GetSheetName(0)
+	highlight, err := wb.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFF2CC"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	headers := []interface{}{"Key", "Finding", "Column", result.FileA + " value", result.FileB + " value"}
+	if err := wb.SetSheetRow(sheet, "A1", &headers); err != nil {
+		return nil, err
+	}
+	rowIdx := 2
+	writeRow := func(cells []interface{}, highlightValues bool) error {
+		cell, _ := excelize.CoordinatesToCellName(1, rowIdx)
+		if err := wb.SetSheetRow(sheet, cell, &cells); err != nil {
+			return err
+		}
+		if highlightValues {
+			from, _ := excelize.CoordinatesToCellName(4, rowIdx)
+			to, _ := excelize.CoordinatesToCellName(5, rowIdx)
+			if err := wb.SetCellStyle(sheet, from, to, highlight); err != nil {
+				return err
+			}
+		}
+		rowIdx++
+		return nil
+	}
+	for _, key := range result.OnlyInA {
+		if err := writeRow([]interface{}{key, "only in " + result.FileA, "", "", ""}, false); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range result.OnlyInB {
+		if err := writeRow([]interface{}{key, "only in " + result.FileB, "", "", ""}, false); err != nil {
+			return nil, err
+		}
+	}
+	for _, row := range result.Differing {
+		for _, diff := range row.Diffs {
+			if err := writeRow([]interface{}{row.Key, "differs", diff.Column, diff.ValueA, diff.ValueB}, true); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	buf, err := wb.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,472 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/gcs"
+	"github.com/datanorthnordik/nordikdriveapi/internal/logs"
+	"github.com/datanorthnordik/nordikdriveapi/internal/media"
+	"github.com/datanorthnordik/nordikdriveapi/internal/middleware"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+// Service is the surface AdminController depends on, implemented by
+// AdminService. Kept as an interface so alternate backends can be wired
+// in by the server container.
+type Service interface {
+	SearchFileEditRequests(ctx context.Context, req AdminFileEditSearchRequest) (*SearchResponse, error)
+	ExportSearchResults(ctx context.Context, req AdminFileEditSearchRequest, format string, allPages bool) ([]byte, string, error)
+	GlobalSearch(ctx context.Context, q string) (*GlobalSearchResult, error)
+	DownloadUpdates(ctx context.Context, req AdminFileEditSearchRequest, format string) ([]byte, string, error)
+	StreamUpdatesCSV(ctx context.Context, req AdminFileEditSearchRequest, w io.Writer) (int, error)
+	StreamMediaZip(ctx context.Context, fileID uint, bestEffort bool, category string, w io.Writer) (*MediaZipSummary, error)
+	StreamCommunityZip(ctx context.Context, community string, adminID uint, w io.Writer) error
+	ExportFileBundle(ctx context.Context, fileID uint, adminID uint, w io.Writer) error
+	ImportFileBundle(ctx context.Context, archive []byte, adminID uint) (*models.File, error)
+	CompareFiles(ctx context.Context, req CompareRequest) (*CompareResult, error)
+	CompareFilesXLSX(ctx context.Context, req CompareRequest) ([]byte, error)
+	AccessReport(ctx context.Context) (*AccessReport, error)
+	AccessReportXLSX(ctx context.Context) ([]byte, error)
+	GetAllUsers() ([]models.Auth, error)
+	ExportUsers(format string) ([]byte, string, error)
+	UpdateUser(userID uint, req UpdateUserRequest, adminID uint) error
+	UpdateUserRole(userID uint, roleName string, adminID uint) error
+	RecountFile(fileID, adminID uint) (*RecountResult, error)
+	RecountAllFiles(adminID uint) ([]RecountResult, error)
+}
+
+var _ Service = (*AdminService)(nil)
+
+// AdminController exposes the /api/admin endpoints. Routes are mounted
+// behind RequireAuth + RequireRole("Admin").
+type AdminController struct {
+	Service Service
+	Logs    *logs.LogService
+}
+
+func NewAdminController(service Service, logSvc *logs.LogService) *AdminController {
+	return &AdminController{Service: service, Logs: logSvc}
+}
+
+func (ctl *AdminController) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/search", ctl.GlobalSearch)
+	rg.POST("/search/changes", ctl.SearchChanges)
+	rg.POST("/search/changes/export", middleware.Backpressure(), ctl.ExportSearchResults)
+	rg.POST("/download/updates", middleware.Backpressure(), ctl.DownloadUpdates)
+	rg.GET("/files/:id/media.zip", middleware.Backpressure(), ctl.DownloadMediaZip)
+	rg.GET("/export/community/:name", middleware.Backpressure(), ctl.ExportCommunity)
+	rg.GET("/files/:id/export-bundle", middleware.Backpressure(), ctl.ExportFileBundle)
+	rg.POST("/files/import-bundle", middleware.Backpressure(), ctl.ImportFileBundle)
+	rg.POST("/compare-files", middleware.Backpressure(), ctl.CompareFiles)
+	rg.POST("/files/:id/recount", ctl.RecountFile)
+	rg.POST("/files/recount", ctl.RecountAllFiles)
+	rg.GET("/access-report", ctl.AccessReport)
+	rg.GET("/users", ctl.GetAllUsers)
+	rg.GET("/users/export", ctl.ExportUsers)
+	rg.PUT("/users/:id", ctl.UpdateUser)
+	rg.PUT("/users/:id/role", ctl.UpdateUserRole)
+	rg.GET("/logs", ctl.SearchLogs)
+	rg.GET("/security-events", ctl.SecurityEvents)
+	rg.GET("/maintenance", ctl.GetMaintenance)
+	rg.PUT("/maintenance", ctl.SetMaintenance)
+	rg.GET("/logs/archives", ctl.ListLogArchives)
+	rg.POST("/logs/archives/restore", ctl.RestoreLogArchives)
+	rg.GET("/metrics/gcs", ctl.GCSMetrics)
+}
+
+// GCSMetrics reports per-operation call counts, error rates, and average
+// latency for storage traffic issued through the shared GCS client,
+// since process start.
+func (ctl *AdminController) GCSMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"operations": gcs.Snapshot()})
+}
+
+func (ctl *AdminController) SearchChanges(c *gin.Context) {
+	var req AdminFileEditSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := ctl.Service.SearchFileEditRequests(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ExportSearchResults downloads change search results with the same
+// clauses and sort as the search itself. ?format=csv|xlsx picks the
+// format, ?scope=all exports every matching row instead of the
+// requested page.
+func (ctl *AdminController) ExportSearchResults(c *gin.Context) {
+	var req AdminFileEditSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return
+	}
+	data, contentType, err := ctl.Service.ExportSearchResults(c.Request.Context(), req, format, c.Query("scope") == "all")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=\"changes."+format+"\"")
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// GlobalSearch is the admin UI's single search box: one query fanned
+// out to files, users and edit requests.
+func (ctl *AdminController) GlobalSearch(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing search term"})
+		return
+	}
+	result, err := ctl.Service.GlobalSearch(c.Request.Context(), q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "search failed"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (ctl *AdminController) DownloadUpdates(c *gin.Context) {
+	var req AdminFileEditSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	format := c.DefaultQuery("format", "csv")
+	if format == "csv" && c.Query("stream") == "true" {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", "attachment; filename=\"updates.csv\"")
+		c.Status(http.StatusOK)
+		if _, err := ctl.Service.StreamUpdatesCSV(c.Request.Context(), req, c.Writer); err != nil {
+			media.AbortStream(c, err)
+		}
+		return
+	}
+	data, contentType, err := ctl.Service.DownloadUpdates(c.Request.Context(), req, format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	filename := "updates." + format
+	c.Header("Content-Disposition", media.ContentDisposition("attachment", filename))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// CompareFiles reconciles two files on a key column, as JSON by default
+// or as a highlighted spreadsheet with ?format=xlsx.
+func (ctl *AdminController) CompareFiles(c *gin.Context) {
+	var req CompareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("format") == "xlsx" {
+		data, err := ctl.Service.CompareFilesXLSX(c.Request.Context(), req)
+		if err != nil {
+			c.JSON(compareStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename=\"compare.xlsx\"")
+		c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", data)
+		return
+	}
+	result, err := ctl.Service.CompareFiles(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(compareStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func compareStatus(err error) int {
+	if errors.Is(err, ErrBadCompareKey) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// AccessReport returns the private-file access matrix, as JSON by
+// default or as a spreadsheet with ?format=xlsx.
+func (ctl *AdminController) AccessReport(c *gin.Context) {
+	if c.Query("format") == "xlsx" {
+		data, err := ctl.Service.AccessReportXLSX(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename=\"access-report.xlsx\"")
+		c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", data)
+		return
+	}
+	report, err := ctl.Service.AccessReport(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func (ctl *AdminController) DownloadMediaZip(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	fileID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	bestEffort := c.Query("best_effort") == "true"
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", "attachment; filename=\"media.zip\"")
+	c.Status(http.StatusOK)
+	summary, err := ctl.Service.StreamMediaZip(c.Request.Context(), uint(fileID), bestEffort, c.Query("category"), c.Writer)
+	if err != nil {
+		media.AbortStream(c, err)
+		return
+	}
+	if len(summary.Skipped) > 0 {
+		ctl.Logs.Record(claims.UserID, "MEDIA_ZIP_PARTIAL",
+			fmt.Sprintf("file=%d files=%d skipped=%d", fileID, summary.Files, len(summary.Skipped)))
+	}
+}
+
+func (ctl *AdminController) ExportCommunity(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	community := c.Param("name")
+	if community == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing community name"})
+		return
+	}
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", "attachment; filename=\"community.zip\"")
+	c.Status(http.StatusOK)
+	if err := ctl.Service.StreamCommunityZip(c.Request.Context(), community, claims.UserID, c.Writer); err != nil {
+		media.AbortStream(c, err)
+	}
+}
+
+// ExportFileBundle downloads the portable archive of one file for
+// promotion to another deployment.
+func (ctl *AdminController) ExportFileBundle(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	fileID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", "attachment; filename=\"bundle.zip\"")
+	c.Status(http.StatusOK)
+	if err := ctl.Service.ExportFileBundle(c.Request.Context(), uint(fileID), claims.UserID, c.Writer); err != nil {
+		media.AbortStream(c, err)
+	}
+}
+
+// ImportFileBundle recreates an exported file from an uploaded bundle.
+func (ctl *AdminController) ImportFileBundle(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing file upload"})
+		return
+	}
+	upload, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unreadable file upload"})
+		return
+	}
+	defer upload.Close()
+	archive, err := io.ReadAll(upload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unreadable file upload"})
+		return
+	}
+	file, err := ctl.Service.ImportFileBundle(c.Request.Context(), archive, claims.UserID)
+	if err != nil {
+		if errors.Is(err, ErrBundleFilenameTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import bundle"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"file": file})
+}
+
+func (ctl *AdminController) GetAllUsers(c *gin.Context) {
+	users, err := ctl.Service.GetAllUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list users"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+// ExportUsers downloads the user report as CSV or XLSX.
+func (ctl *AdminController) ExportUsers(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	data, contentType, err := ctl.Service.ExportUsers(format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export users"})
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=\"users."+format+"\"")
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// UpdateUser edits a user's role and communities. The user sees the
+// changes once their token is refreshed.
+func (ctl *AdminController) UpdateUser(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	userID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var req UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := ctl.Service.UpdateUser(uint(userID), req, claims.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "user updated"})
+}
+
+func (ctl *AdminController) UpdateUserRole(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	userID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := ctl.Service.UpdateUserRole(uint(userID), req.Role, claims.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update role"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "role updated"})
+}
+
+// RecountFile recomputes one file's row count from file_data and fixes
+// the stored metadata if it drifted.
+func (ctl *AdminController) RecountFile(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	fileID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	result, err := ctl.Service.RecountFile(uint(fileID), claims.UserID)
+	if err != nil {
+		if errors.Is(err, ErrFileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to recount file"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": result})
+}
+
+// RecountAllFiles recounts every file, returning only the ones whose
+// metadata had drifted.
+func (ctl *AdminController) RecountAllFiles(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	drifted, err := ctl.Service.RecountAllFiles(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to recount files"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"drifted": drifted})
+}
+
+// ListLogArchives returns the log batches exported to GCS by the
+// retention job.
+func (ctl *AdminController) ListLogArchives(c *gin.Context) {
+	archives, err := ctl.Logs.ListArchives()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list archives"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"archives": archives})
+}
+
+// RestoreLogArchives re-imports archived log entries for a date range.
+func (ctl *AdminController) RestoreLogArchives(c *gin.Context) {
+	var req struct {
+		From string `json:"from" binding:"required"`
+		To   string `json:"to" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	from, err1 := time.Parse("2006-01-02", req.From)
+	to, err2 := time.Parse("2006-01-02", req.To)
+	if err1 != nil || err2 != nil || to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from/to must be YYYY-MM-DD with from <= to"})
+		return
+	}
+	restored, err := ctl.Logs.RestoreArchives(c.Request.Context(), from, to.Add(24*time.Hour-time.Second))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "restored": restored})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"restored": restored})
+}
+
+func (ctl *AdminController) SearchLogs(c *gin.Context) {
+	userID, _ := strconv.ParseUint(c.Query("user_id"), 10, 32)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	entries, total, err := ctl.Logs.Search(uint(userID), c.Query("action"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search logs"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"logs": entries, "total": total})
+}
+
+// GetMaintenance reports whether the read-only maintenance switch is on.
+func (ctl *AdminController) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"enabled": middleware.InMaintenanceMode()})
+}
+
+// SetMaintenance flips the read-only maintenance switch at runtime.
+func (ctl *AdminController) SetMaintenance(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	middleware.SetMaintenanceMode(*req.Enabled)
+	ctl.Logs.Record(claims.UserID, "SET_MAINTENANCE", fmt.Sprintf("enabled=%t", *req.Enabled))
+	c.JSON(http.StatusOK, gin.H{"enabled": *req.Enabled})
+}
+
+// SecurityEvents lists denied requests recorded by the auth middleware so
+// repeated unauthorized access attempts are visible.
+func (ctl *AdminController) SecurityEvents(c *gin.Context) {
+	userID, _ := strconv.ParseUint(c.Query("user_id"), 10, 32)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	entries, total, err := ctl.Logs.SecurityEvents(uint(userID), c.Query("reason"), c.Query("route"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search security events"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"events": entries, "total": total})
+}
@@ -0,0 +1,407 @@
+package admin
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+	"github.com/datanorthnordik/nordikdriveapi/internal/util"
+)
+
+// updateExportHeaders is the column layout shared by the CSV and XLSX
+// updates exports.
+var updateExportHeaders = []string{
+	"Request ID", "File", "Row", "Status", "Requested By",
+	"Field", "Old Value", "New Value", "Created At",
+}
+
+const downloadPageSize = 200
+
+// DownloadUpdates exports the change search results matching the request
+// as CSV or XLSX bytes, paging through the search to collect every row.
+func (s *AdminService) DownloadUpdates(ctx context.Context, req AdminFileEditSearchRequest, format string) ([]byte, string, error) {
+	req.Page = 1
+	req.PageSize = downloadPageSize
+	ctx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+
+	var all []ChangeResult
+	for {
+		resp, err := s.searchChanges(ctx, req)
+		if err != nil {
+			return nil, "", err
+		}
+		all = append(all, resp.Results...)
+		if len(resp.Results) < req.PageSize {
+			break
+		}
+		req.Page++
+	}
+
+	rows := make([][]interface{}, len(all))
+	for i, result := range all {
+		rows[i] = changeResultCells(result)
+	}
+	switch format {
+	case "xlsx":
+		data, err := buildXLSX(updateExportHeaders, rows)
+		return data, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", err
+	default:
+		data, err := buildCSV(updateExportHeaders, rows)
+		return data, "text/csv", err
+	}
+}
+
+// ExportSearchResults renders change search results as CSV or XLSX with
+// the exact columns, clauses and sort of the search itself, so what the
+// admin exports is what the screen showed. With allPages set every
+// matching row is collected; otherwise only the requested page is
+// exported.
+func (s *AdminService) ExportSearchResults(ctx context.Context, req AdminFileEditSearchRequest, format string, allPages bool) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+	// Aggregations are chart data; never part of a row export.
+	req.Aggregation = ""
+	req.IncludeAggs = false
+
+	var all []ChangeResult
+	if allPages {
+		req.Page = 1
+		req.PageSize = downloadPageSize
+		for {
+			resp, err := s.searchChanges(ctx, req)
+			if err != nil {
+				return nil, "", err
+			}
+			all = append(all, resp.Results...)
+			if len(resp.Results) < req.PageSize {
+				break
+			}
+			if s.Config.ExportMaxRows > 0 && len(all) >= s.Config.ExportMaxRows {
+				all = all[:s.Config.ExportMaxRows]
+				break
+			}
+			req.Page++
+		}
+	} else {
+		if req.PageSize <= 0 || req.PageSize > 200 {
+			req.PageSize = 50
+		}
+		if req.Page < 1 {
+			req.Page = 1
+		}
+		resp, err := s.searchChanges(ctx, req)
+		if err != nil {
+			return nil, "", err
+		}
+		all = resp.Results
+	}
+
+	rows := make([][]interface{}, len(all))
+	for i, result := range all {
+		rows[i] = changeResultCells(result)
+	}
+	switch format {
+	case "xlsx":
+		data, err := buildXLSX(updateExportHeaders, rows)
+		return data, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", err
+	default:
+		data, err := buildCSV(updateExportHeaders, rows)
+		return data, "text/csv", err
+	}
+}
+
+// StreamUpdatesCSV writes the matching changes as CSV straight to the
+// response writer. It pages with keyset pagination on the detail row ID
+// rather than OFFSET, so deep pages stay cheap, and stops at the
+// configured row cap to keep a runaway filter from producing a
+// multi-gigabyte download.
+func (s *AdminService) StreamUpdatesCSV(ctx context.Context, req AdminFileEditSearchRequest, w io.Writer) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+	cw := csv.NewWriter(w)
+	if err := cw.Write(updateExportHeaders); err != nil {
+		return 0, err
+	}
+
+	written := 0
+	lastID := uint(0)
+	for {
+		base := s.DB.WithContext(ctx).Table("file_edit_request_details AS d").
+			Joins("JOIN file_edit_request AS r ON r.id = d.request_id").
+			Joins("JOIN file AS f ON f.id = r.file_id").
+			Where("d.id > ?", lastID)
+		for _, clause := range req.Clauses {
+			var err error
+			base, err = applyStringOp(base, clause)
+			if err != nil {
+				return written, err
+			}
+		}
+		base = applyFreeText(base, req.Q)
+
+		var page []struct {
+			ChangeResult
+			DetailID uint
+		}
+		err := base.
+			Select(`d.id AS detail_id, r.id AS request_id, r.file_id, f.filename AS file_name, r.row_id,
+				r.status, r.first_name, r.last_name, r.requested_by, r.created_at,
+				d.field_name, d.old_value, d.new_value`).
+			Order("d.id ASC").
+			Limit(downloadPageSize).
+			Scan(&page).Error
+		if err != nil {
+			return written, fmt.Errorf("stream changes: %w", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+
+		for _, row := range page {
+			cells := changeResultCells(row.ChangeResult)
+			record := make([]string, len(cells))
+			for i, cell := range cells {
+				record[i] = fmt.Sprint(cell)
+			}
+			if err := cw.Write(record); err != nil {
+				return written, err
+			}
+			written++
+			if s.Config.ExportMaxRows > 0 && written >= s.Config.ExportMaxRows {
+				cw.Flush()
+				return written, cw.Error()
+			}
+		}
+		lastID = page[len(page)-1].DetailID
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return written, err
+		}
+	}
+	cw.Flush()
+	return written, cw.Error()
+}
+
+func changeResultCells(r ChangeResult) []interface{} {
+	return []interface{}{
+		r.RequestID, r.FileName, r.RowID, r.Status,
+		r.FirstName + " " + r.LastName,
+		r.FieldName, r.OldValue, r.NewValue,
+		r.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// buildXLSX renders rows into a spreadsheet using the excelize stream
+// writer so large exports don't hold every cell in memory twice.
+func buildXLSX(headers []string, rows [][]interface{}) ([]byte, error) {
+	wb := excelize.NewFile()
+	defer wb.Close()
+
+	sheet := wb.GetSheetName(0)
+	sw, err := wb.NewStreamWriter(sheet)
+	if err != nil {
+		return nil, fmt.Errorf("stream writer: %w", err)
+	}
+
+	header := make([]interface{}, len(headers))
+	for i, h := range headers {
+		header[i] = h
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return nil, err
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err := sw.SetRow(cell, row); err != nil {
+			return nil, err
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := wb.Write(&buf); err != nil {
+		return nil, fmt.Errorf("write xlsx: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func buildCSV(headers []string, rows [][]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(headers); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, c := range row {
+			record[i] = fmt.Sprint(c)
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// mediaZipEntry is one object slated for the media ZIP, with the rows
+// the manifest needs to describe it.
+type mediaZipEntry struct {
+	requestID  uint
+	rowID      uint
+	uploadedBy uint
+	gsURL      string
+}
+
+// MediaZipSummary reports what a media ZIP export actually delivered.
+type MediaZipSummary struct {
+	Files   int      `json:"files"`
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// StreamMediaZip streams a ZIP of approved edit-request photos and
+// documents for a file directly to the response writer, organized as
+// row_<id>/<filename>. A manifest.csv entry lists every file with its
+// sha256 and size, computed while streaming, so recipients of large
+// handovers can verify completeness. With bestEffort set, unreadable
+// objects are skipped and recorded in an errors.txt entry instead of
+// aborting the whole export.
+// A non-empty category narrows the export to documents filed under
+// that label; photos carry no category and are excluded.
+func (s *AdminService) StreamMediaZip(ctx context.Context, fileID uint, bestEffort bool, category string, w io.Writer) (*MediaZipSummary, error) {
+	entries, err := s.mediaZipEntries(fileID, category)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newGCSClientHook(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gcs client: %w", err)
+	}
+	defer client.Close()
+
+	zw := zip.NewWriter(w)
+	defer zw.Close()
+
+	summary := &MediaZipSummary{}
+	manifest := make([][]string, 0, len(entries))
+	for _, e := range entries {
+		skip := func(cause error) error {
+			if bestEffort {
+				summary.Skipped = append(summary.Skipped, fmt.Sprintf("%s: %v", e.gsURL, cause))
+				return nil
+			}
+			return cause
+		}
+		bucket, object, err := util.ParseGSURL(e.gsURL)
+		if err != nil {
+			if err := skip(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		reader, err := client.Bucket(bucket).Object(object).NewReader(ctx)
+		if err != nil {
+			if err := skip(fmt.Errorf("open %s: %w", e.gsURL, err)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		name := fmt.Sprintf("row_%d/%s", e.rowID, path.Base(object))
+		entryWriter, err := zw.Create(name)
+		if err != nil {
+			reader.Close()
+			return nil, err
+		}
+		hasher := sha256.New()
+		size, err := io.Copy(io.MultiWriter(entryWriter, hasher), reader)
+		reader.Close()
+		if err != nil {
+			// The entry is already partially written; a copy failure in
+			// best-effort mode still leaves a corrupt member, so only
+			// open errors are truly skippable. Report and bail.
+			return nil, fmt.Errorf("copy %s: %w", e.gsURL, err)
+		}
+		summary.Files++
+		manifest = append(manifest, []string{
+			fmt.Sprint(e.requestID), fmt.Sprint(e.rowID), fmt.Sprint(e.uploadedBy),
+			e.gsURL, hex.EncodeToString(hasher.Sum(nil)), fmt.Sprint(size),
+		})
+	}
+
+	if err := writeMediaManifest(zw, manifest); err != nil {
+		return nil, err
+	}
+	if len(summary.Skipped) > 0 {
+		errWriter, err := zw.Create("errors.txt")
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range summary.Skipped {
+			if _, err := fmt.Fprintln(errWriter, line); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return summary, nil
+}
+
+// mediaZipEntries collects the approved photos and documents of a file.
+func (s *AdminService) mediaZipEntries(fileID uint, category string) ([]mediaZipEntry, error) {
+	var photos []models.FileEditRequestPhoto
+	if category == "" {
+		if err := s.DB.Where("file_id = ? AND status = ?", fileID, "approved").
+			Find(&photos).Error; err != nil {
+			return nil, err
+		}
+	}
+	docQuery := s.DB.
+		Joins("JOIN file_edit_request ON file_edit_request.id = file_edit_request_documents.request_id").
+		Where("file_edit_request_documents.file_id = ? AND file_edit_request.status = ?", fileID, "approved")
+	if category != "" {
+		docQuery = docQuery.Where("file_edit_request_documents.document_category = ?", category)
+	}
+	var docs []models.FileEditRequestDocument
+	if err := docQuery.Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	entries := make([]mediaZipEntry, 0, len(photos)+len(docs))
+	for _, p := range photos {
+		entries = append(entries, mediaZipEntry{requestID: p.RequestID, rowID: p.RowID, uploadedBy: p.UploadedBy, gsURL: p.GCSPath})
+	}
+	for _, d := range docs {
+		entries = append(entries, mediaZipEntry{requestID: d.RequestID, rowID: d.RowID, uploadedBy: d.UploadedBy, gsURL: d.GCSPath})
+	}
+	return entries, nil
+}
+
+// writeMediaManifest appends manifest.csv listing every streamed file.
+func writeMediaManifest(zw *zip.Writer, rows [][]string) error {
+	manifestWriter, err := zw.Create("manifest.csv")
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(manifestWriter)
+	if err := cw.Write([]string{"request_id", "row_id", "uploaded_by", "gcs_path", "sha256", "size"}); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,437 @@
+package admin
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/gcs"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+	"github.com/datanorthnordik/nordikdriveapi/internal/util"
+)
+
+var ErrBundleFilenameTaken = errors.New("a file with this name already exists")
+
+// bundleFormatVersion lets a future import reject archives produced by
+// an incompatible exporter.
+const bundleFormatVersion = 1
+
+// bundleImportBatch is the insert batch size for imported data rows.
+const bundleImportBatch = 500
+
+// fileBundle is the portable description of one file written as
+// bundle.json. Data rows travel separately as data.ndjson and media
+// binaries under media/, so the manifest stays small.
+type fileBundle struct {
+	FormatVersion int                  `json:"format_version"`
+	ExportedAt    time.Time            `json:"exported_at"`
+	File          models.File          `json:"file"`
+	Versions      []models.FileVersion `json:"versions"`
+	Access        []models.FileAccess  `json:"access"`
+	Comments      []models.FileComment `json:"comments,omitempty"`
+	Media         []bundleMedia        `json:"media"`
+}
+
+// bundleMedia describes one media binary in the archive independently
+// of GCS paths, so the importing deployment can store it in its own
+// bucket.
+type bundleMedia struct {
+	Kind        string `json:"kind"` // "photo" or "document"
+	RowID       uint   `json:"row_id"`
+	Category    string `json:"category,omitempty"`
+	Caption     string `json:"caption,omitempty"`
+	SortOrder   int    `json:"sort_order,omitempty"`
+	UploadedBy  uint   `json:"uploaded_by"`
+	ArchivePath string `json:"archive_path"`
+}
+
+// bundleRow is one data.ndjson line. The original row ID is kept so
+// media can be re-attached to the right row after import.
+type bundleRow struct {
+	ID      uint            `json:"id"`
+	Version int             `json:"version"`
+	RowData json.RawMessage `json:"row_data"`
+}
+
+// ExportFileBundle streams a portable archive of one file — manifest,
+// all hot data rows, and approved media — for promotion between
+// environments. Archived versions whose rows live only in GCS are not
+// included; restore them first if they must travel.
+func (s *AdminService) ExportFileBundle(ctx context.Context, fileID uint, adminID uint, w io.Writer) error {
+	var file models.File
+	if err := s.DB.First(&file, fileID).Error; err != nil {
+		return err
+	}
+	var versions []models.FileVersion
+	if err := s.DB.Where("file_id = ?", fileID).Order("version").Find(&versions).Error; err != nil {
+		return err
+	}
+	var access []models.FileAccess
+	if err := s.DB.Where("file_id = ?", fileID).Find(&access).Error; err != nil {
+		return err
+	}
+	var comments []models.FileComment
+	if err := s.DB.Where("file_id = ?", fileID).Order("id").Find(&comments).Error; err != nil {
+		return err
+	}
+	var photos []models.FileEditRequestPhoto
+	if err := s.DB.Where("file_id = ? AND status = ?", fileID, "approved").
+		Find(&photos).Error; err != nil {
+		return err
+	}
+	var docs []models.FileEditRequestDocument
+	if err := s.DB.
+		Joins("JOIN file_edit_request ON file_edit_request.id = file_edit_request_documents.request_id").
+		Where("file_edit_request_documents.file_id = ? AND file_edit_request.status = ?", fileID, "approved").
+		Find(&docs).Error; err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	defer zw.Close()
+
+	bundle := fileBundle{
+		FormatVersion: bundleFormatVersion,
+		ExportedAt:    time.Now().UTC(),
+		File:          file,
+		Versions:      versions,
+		Access:        access,
+		Comments:      comments,
+	}
+	for i, p := range photos {
+		bundle.Media = append(bundle.Media, bundleMedia{
+			Kind:        "photo",
+			RowID:       p.RowID,
+			Caption:     p.Caption,
+			SortOrder:   p.SortOrder,
+			UploadedBy:  p.UploadedBy,
+			ArchivePath: fmt.Sprintf("media/photo_%d_%s", i, path.Base(p.GCSPath)),
+		})
+	}
+	for i, d := range docs {
+		bundle.Media = append(bundle.Media, bundleMedia{
+			Kind:        "document",
+			RowID:       d.RowID,
+			Category:    d.DocumentCategory,
+			UploadedBy:  d.UploadedBy,
+			ArchivePath: fmt.Sprintf("media/document_%d_%s", i, path.Base(d.GCSPath)),
+		})
+	}
+	manifestWriter, err := zw.Create("bundle.json")
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(manifestWriter).Encode(bundle); err != nil {
+		return err
+	}
+
+	if err := s.writeBundleRows(zw, fileID); err != nil {
+		return err
+	}
+
+	client, err := newGCSClientHook(ctx)
+	if err != nil {
+		return fmt.Errorf("gcs client: %w", err)
+	}
+	defer client.Close()
+	sources := make([]string, 0, len(bundle.Media))
+	for _, p := range photos {
+		sources = append(sources, p.GCSPath)
+	}
+	for _, d := range docs {
+		sources = append(sources, d.GCSPath)
+	}
+	for i, gsURL := range sources {
+		bucket, object, err := util.ParseGSURL(gsURL)
+		if err != nil {
+			return err
+		}
+		reader, err := client.Bucket(bucket).Object(object).NewReader(ctx)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", gsURL, err)
+		}
+		entryWriter, err := zw.Create(bundle.Media[i].ArchivePath)
+		if err != nil {
+			reader.Close()
+			return err
+		}
+		if _, err := io.Copy(entryWriter, reader); err != nil {
+			reader.Close()
+			return fmt.Errorf("copy %s: %w", gsURL, err)
+		}
+		reader.Close()
+	}
+
+	s.Logs.Record(adminID, "EXPORT_FILE_BUNDLE",
+		fmt.Sprintf("file=%d versions=%d media=%d", fileID, len(versions), len(bundle.Media)))
+	return nil
+}
+
+// writeBundleRows streams every hot data row of the file as NDJSON,
+// paging by row id so a large registry never sits in memory.
+func (s *AdminService) writeBundleRows(zw *zip.Writer, fileID uint) error {
+	entryWriter, err := zw.Create("data.ndjson")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(entryWriter)
+	lastID := uint(0)
+	for {
+		var rows []models.FileData
+		if err := s.DB.Where("file_id = ? AND id > ?", fileID, lastID).
+			Order("id").Limit(bundleImportBatch).Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		for _, row := range rows {
+			if err := enc.Encode(bundleRow{ID: row.ID, Version: row.Version, RowData: json.RawMessage(row.RowData)}); err != nil {
+				return err
+			}
+			lastID = row.ID
+		}
+	}
+}
+
+// ImportFileBundle recreates an exported file in this deployment: the
+// file record, versions, data rows, access grants, and media uploaded
+// into this environment's bucket. Row IDs are reassigned and media
+// re-attached through them; version archive pointers are cleared since
+// they reference the source environment's bucket. User IDs in access
+// and media are copied as-is and assume the environments share a user
+// base.
+func (s *AdminService) ImportFileBundle(ctx context.Context, archive []byte, adminID uint) (*models.File, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, fmt.Errorf("read bundle: %w", err)
+	}
+	entries := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		entries[f.Name] = f
+	}
+
+	var bundle fileBundle
+	if err := readBundleJSON(entries["bundle.json"], &bundle); err != nil {
+		return nil, err
+	}
+	if bundle.FormatVersion != bundleFormatVersion {
+		return nil, fmt.Errorf("unsupported bundle format %d", bundle.FormatVersion)
+	}
+
+	var existing models.File
+	if err := s.DB.Where("filename = ?", bundle.File.Filename).First(&existing).Error; err == nil {
+		return nil, ErrBundleFilenameTaken
+	}
+
+	file := bundle.File
+	file.ID = 0
+	rowmap := make(map[uint]uint)
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&file).Error; err != nil {
+			return err
+		}
+		for _, v := range bundle.Versions {
+			version := models.FileVersion{
+				FileID:    file.ID,
+				Version:   v.Version,
+				CreatedBy: v.CreatedBy,
+			}
+			if err := tx.Create(&version).Error; err != nil {
+				return err
+			}
+		}
+		for _, a := range bundle.Access {
+			grant := models.FileAccess{FileID: file.ID, UserID: a.UserID, Permission: a.Permission, ExpiresAt: a.ExpiresAt}
+			if err := tx.Create(&grant).Error; err != nil {
+				return err
+			}
+		}
+		// Comments keep their thread structure through an old-to-new ID
+		// map; the exporter orders parents before replies.
+		commentMap := make(map[uint]uint, len(bundle.Comments))
+		for _, c := range bundle.Comments {
+			comment := models.FileComment{
+				FileID:    file.ID,
+				Body:      c.Body,
+				CreatedBy: c.CreatedBy,
+			}
+			if c.ParentID != nil {
+				if newParent, ok := commentMap[*c.ParentID]; ok {
+					comment.ParentID = &newParent
+				}
+			}
+			if err := tx.Create(&comment).Error; err != nil {
+				return err
+			}
+			commentMap[c.ID] = comment.ID
+		}
+		if err := s.importBundleRows(tx, entries["data.ndjson"], file.ID, rowmap); err != nil {
+			return err
+		}
+		// Media is part of the same transaction: if an upload or its
+		// row fails, the file tree rolls back with it, so a retry is
+		// not blocked by the filename check on a half-imported file.
+		return s.importBundleMedia(ctx, tx, entries, bundle.Media, &file, rowmap)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("import bundle: %w", err)
+	}
+
+	s.Logs.Record(adminID, "IMPORT_FILE_BUNDLE",
+		fmt.Sprintf("file=%d filename=%s rows=%d media=%d", file.ID, file.Filename, len(rowmap), len(bundle.Media)))
+	return &file, nil
+}
+
+// readBundleJSON decodes one JSON entry of the archive.
+func readBundleJSON(entry *zip.File, dst interface{}) error {
+	if entry == nil {
+		return errors.New("bundle is missing bundle.json")
+	}
+	reader, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return json.NewDecoder(reader).Decode(dst)
+}
+
+// importBundleRows inserts the NDJSON data rows under the new file ID,
+// recording old-to-new row ID pairs so media can follow.
+func (s *AdminService) importBundleRows(tx *gorm.DB, entry *zip.File, fileID uint, rowmap map[uint]uint) error {
+	if entry == nil {
+		return nil
+	}
+	reader, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	dec := json.NewDecoder(reader)
+	batch := make([]models.FileData, 0, bundleImportBatch)
+	oldIDs := make([]uint, 0, bundleImportBatch)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := tx.Create(&batch).Error; err != nil {
+			return err
+		}
+		for i, row := range batch {
+			rowmap[oldIDs[i]] = row.ID
+		}
+		batch = batch[:0]
+		oldIDs = oldIDs[:0]
+		return nil
+	}
+	for {
+		var row bundleRow
+		if err := dec.Decode(&row); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("decode data row: %w", err)
+		}
+		batch = append(batch, models.FileData{FileID: fileID, Version: row.Version, RowData: []byte(row.RowData)})
+		oldIDs = append(oldIDs, row.ID)
+		if len(batch) == bundleImportBatch {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// importBundleMedia uploads the archived binaries into this
+// environment's bucket and re-attaches them to the remapped rows on tx
+// as approved media with no originating request. On failure the objects
+// uploaded so far are deleted, so the caller's transaction can roll
+// back without stranding media in the bucket.
+func (s *AdminService) importBundleMedia(ctx context.Context, tx *gorm.DB, entries map[string]*zip.File, media []bundleMedia, file *models.File, rowmap map[uint]uint) (err error) {
+	if len(media) == 0 {
+		return nil
+	}
+	client, err := newGCSClientHook(ctx)
+	if err != nil {
+		return fmt.Errorf("gcs client: %w", err)
+	}
+	defer client.Close()
+
+	var uploaded []string
+	defer func() {
+		if err == nil || len(uploaded) == 0 {
+			return
+		}
+		if cleanupErr := gcs.Default.Delete(ctx, s.Config.GCSBucket, uploaded); cleanupErr != nil {
+			err = errors.Join(err, fmt.Errorf("rollback uploads: %w", cleanupErr))
+		}
+	}()
+
+	for _, m := range media {
+		entry := entries[m.ArchivePath]
+		if entry == nil {
+			return fmt.Errorf("bundle is missing %s", m.ArchivePath)
+		}
+		rowID, ok := rowmap[m.RowID]
+		if !ok {
+			// The row was not in data.ndjson (e.g. an archived-only
+			// version); without a target row the media has no home.
+			continue
+		}
+		reader, err := entry.Open()
+		if err != nil {
+			return err
+		}
+		object := fmt.Sprintf("imported/file_%d/%s", file.ID, path.Base(m.ArchivePath))
+		writer := client.Bucket(s.Config.GCSBucket).Object(object).NewWriter(ctx)
+		if _, err := io.Copy(writer, reader); err != nil {
+			reader.Close()
+			return fmt.Errorf("upload %s: %w", m.ArchivePath, err)
+		}
+		reader.Close()
+		if err := writer.Close(); err != nil {
+			return fmt.Errorf("upload %s: %w", m.ArchivePath, err)
+		}
+		uploaded = append(uploaded, object)
+		gsURL := "gs://" + s.Config.GCSBucket + "/" + object
+
+		switch m.Kind {
+		case "photo":
+			photo := models.FileEditRequestPhoto{
+				FileID:     file.ID,
+				RowID:      rowID,
+				GCSPath:    gsURL,
+				Status:     "approved",
+				UploadedBy: m.UploadedBy,
+				Caption:    m.Caption,
+				SortOrder:  m.SortOrder,
+			}
+			if err := tx.Create(&photo).Error; err != nil {
+				return err
+			}
+		case "document":
+			doc := models.FileEditRequestDocument{
+				FileID:           file.ID,
+				RowID:            rowID,
+				GCSPath:          gsURL,
+				DocumentCategory: m.Category,
+				UploadedBy:       m.UploadedBy,
+			}
+			if err := tx.Create(&doc).Error; err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown media kind %q", m.Kind)
+		}
+	}
+	return nil
+}
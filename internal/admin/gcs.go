@@ -0,0 +1,16 @@
+package admin
+
+import (
+	"context"
+
+	"cloud.google.com/go/storage"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/gcs"
+)
+
+// newGCSClientHook is a test seam so exports can run without a real GCS
+// client. The default delegates to the shared raw-client constructor in
+// internal/gcs.
+var newGCSClientHook = func(ctx context.Context) (*storage.Client, error) {
+	return gcs.NewRawClientHook(ctx)
+}
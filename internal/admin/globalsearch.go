@@ -0,0 +1,92 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+// globalSearchLimit caps each result group; the search box wants the
+// best few hits per type, not a full listing.
+const globalSearchLimit = 20
+
+// FileHit is one file match in a global search.
+type FileHit struct {
+	ID          uint   `json:"id"`
+	Filename    string `json:"filename"`
+	Description string `json:"description"`
+	Private     bool   `json:"private"`
+}
+
+// UserHit is one user match in a global search.
+type UserHit struct {
+	ID        uint   `json:"id"`
+	FirstName string `json:"firstname"`
+	LastName  string `json:"lastname"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+}
+
+// RequestHit is one edit request match in a global search.
+type RequestHit struct {
+	ID        uint   `json:"id"`
+	FileID    uint   `json:"file_id"`
+	RowID     uint   `json:"row_id"`
+	FirstName string `json:"firstname"`
+	LastName  string `json:"lastname"`
+	Status    string `json:"status"`
+}
+
+// GlobalSearchResult groups the hits by type so the admin UI can render
+// one box with sectioned results that deep-link by ID.
+type GlobalSearchResult struct {
+	Files    []FileHit    `json:"files"`
+	Users    []UserHit    `json:"users"`
+	Requests []RequestHit `json:"requests"`
+}
+
+// GlobalSearch fans one query out to files, users and edit requests in
+// parallel; each group is an independent query, so they parallelize
+// cleanly on the pool, like aggregateAll.
+func (s *AdminService) GlobalSearch(ctx context.Context, q string) (*GlobalSearchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, searchTimeout)
+	defer cancel()
+	term := "%" + strings.TrimSpace(q) + "%"
+	result := &GlobalSearchResult{}
+	errs := make([]error, 3)
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		errs[0] = s.DB.WithContext(ctx).Model(&models.File{}).
+			Where("is_delete = false").
+			Where("filename ILIKE ? OR description ILIKE ? OR source_org ILIKE ? OR citation ILIKE ?",
+				term, term, term, term).
+			Order("created_at DESC").Limit(globalSearchLimit).
+			Scan(&result.Files).Error
+	}()
+	go func() {
+		defer wg.Done()
+		errs[1] = s.DB.WithContext(ctx).Model(&models.Auth{}).
+			Where("first_name ILIKE ? OR last_name ILIKE ? OR email ILIKE ?", term, term, term).
+			Order("id").Limit(globalSearchLimit).
+			Scan(&result.Users).Error
+	}()
+	go func() {
+		defer wg.Done()
+		errs[2] = s.DB.WithContext(ctx).Model(&models.FileEditRequest{}).
+			Where("first_name ILIKE ? OR last_name ILIKE ?", term, term).
+			Order("created_at DESC").Limit(globalSearchLimit).
+			Scan(&result.Requests).Error
+	}()
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,97 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var ErrFileNotFound = errors.New("file not found")
+
+// RecountResult reports one file whose stored metadata was checked
+// against file_data. StoredBytes is the byte size of the current
+// version's rows as stored, for comparison with Size (the original
+// upload size, which cannot be recomputed once the upload is gone).
+type RecountResult struct {
+	FileID      uint   `json:"file_id"`
+	Filename    string `json:"filename"`
+	OldRows     int    `json:"old_rows"`
+	NewRows     int    `json:"new_rows"`
+	StoredBytes int64  `json:"stored_bytes"`
+	Updated     bool   `json:"updated"`
+}
+
+// RecountFile recomputes a file's row count from file_data for its
+// current version and corrects File.Rows when it has drifted — after
+// manual database fixes or partially failed imports the stored count
+// can be wrong.
+func (s *AdminService) RecountFile(fileID, adminID uint) (*RecountResult, error) {
+	var file models.File
+	if err := s.DB.First(&file, fileID).Error; err != nil {
+		return nil, ErrFileNotFound
+	}
+	result, err := s.recount(&file)
+	if err != nil {
+		return nil, err
+	}
+	if result.Updated {
+		s.Logs.Record(adminID, "RECOUNT_FILE", fmt.Sprintf("file=%d rows %d -> %d", file.ID, result.OldRows, result.NewRows))
+	}
+	return result, nil
+}
+
+// RecountAllFiles runs RecountFile over every file and returns only the
+// ones whose metadata had drifted.
+func (s *AdminService) RecountAllFiles(adminID uint) ([]RecountResult, error) {
+	var files []models.File
+	if err := s.DB.Select("id", "filename", "rows", "version").Order("id").Find(&files).Error; err != nil {
+		return nil, err
+	}
+	drifted := make([]RecountResult, 0)
+	for i := range files {
+		result, err := s.recount(&files[i])
+		if err != nil {
+			return nil, err
+		}
+		if result.Updated {
+			drifted = append(drifted, *result)
+		}
+	}
+	if len(drifted) > 0 {
+		s.Logs.Record(adminID, "RECOUNT_ALL_FILES", fmt.Sprintf("drifted=%d of %d", len(drifted), len(files)))
+	}
+	return drifted, nil
+}
+
+func (s *AdminService) recount(file *models.File) (*RecountResult, error) {
+	var rows int64
+	if err := s.DB.Model(&models.FileData{}).
+		Where("file_id = ? AND version = ?", file.ID, file.Version).
+		Count(&rows).Error; err != nil {
+		return nil, err
+	}
+	var storedBytes int64
+	if err := s.DB.Model(&models.FileData{}).
+		Select("COALESCE(SUM(octet_length(row_data::text)), 0)").
+		Where("file_id = ? AND version = ?", file.ID, file.Version).
+		Scan(&storedBytes).Error; err != nil {
+		return nil, err
+	}
+	result := &RecountResult{
+		FileID:      file.ID,
+		Filename:    file.Filename,
+		OldRows:     file.Rows,
+		NewRows:     int(rows),
+		StoredBytes: storedBytes,
+	}
+	if result.NewRows == result.OldRows {
+		return result, nil
+	}
+	if err := s.DB.Model(&models.File{}).Where("id = ?", file.ID).
+		Update("rows", result.NewRows).Error; err != nil {
+		return nil, err
+	}
+	result.Updated = true
+	return result, nil
+}
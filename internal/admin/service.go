@@ -0,0 +1,375 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/config"
+	"github.com/datanorthnordik/nordikdriveapi/internal/logs"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+	"github.com/datanorthnordik/nordikdriveapi/internal/search"
+)
+
+// AdminService backs the admin dashboard: change search, exports and user
+// management.
+type AdminService struct {
+	DB     *gorm.DB
+	Config *config.Config
+	Logs   *logs.LogService
+}
+
+func NewAdminService(db *gorm.DB, cfg *config.Config, logSvc *logs.LogService) *AdminService {
+	return &AdminService{DB: db, Config: cfg, Logs: logSvc}
+}
+
+// SearchClause is one structured filter in an admin change search.
+type SearchClause struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// AdminFileEditSearchRequest is the body of the admin change search
+// endpoint. Q is an optional free-text term matched against field name,
+// old value and new value in addition to the structured clauses.
+type AdminFileEditSearchRequest struct {
+	Q           string         `json:"q"`
+	Clauses     []SearchClause `json:"clauses"`
+	Aggregation string         `json:"aggregation"`
+	// IncludeAggs computes every aggregation breakdown in one request so
+	// the dashboard doesn't need a round-trip per chart.
+	IncludeAggs bool `json:"include_aggs"`
+	// Sort picks the result order; see changeSearch for the allow-list.
+	// SortDir is "asc" or "desc" (default desc).
+	Sort     string `json:"sort"`
+	SortDir  string `json:"sort_dir"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+}
+
+// ChangeResult is one row of the admin change search: a request joined
+// with its file and one detail.
+type ChangeResult struct {
+	RequestID   uint      `json:"request_id"`
+	FileID      uint      `json:"file_id"`
+	FileName    string    `json:"file_name"`
+	RowID       uint      `json:"row_id"`
+	Status      string    `json:"status"`
+	FirstName   string    `json:"firstname"`
+	LastName    string    `json:"lastname"`
+	FieldName   string    `json:"field_name"`
+	OldValue    string    `json:"old_value"`
+	NewValue    string    `json:"new_value"`
+	RequestedBy uint      `json:"requested_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	// Highlights names the columns the free-text term matched in, so
+	// the UI can emphasise them without re-implementing the match.
+	Highlights []string `json:"highlights,omitempty" gorm:"-"`
+}
+
+// AggregationBucket is one group in an aggregation result.
+type AggregationBucket struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+// AggregationSet bundles every breakdown the dashboard charts use.
+type AggregationSet struct {
+	ByFile      []AggregationBucket `json:"by_file"`
+	ByField     []AggregationBucket `json:"by_field"`
+	ByStatus    []AggregationBucket `json:"by_status"`
+	ByCommunity []AggregationBucket `json:"by_community"`
+}
+
+// SearchResponse is the change search result with pagination metadata.
+type SearchResponse struct {
+	Results      []ChangeResult      `json:"results"`
+	Total        int64               `json:"total"`
+	Page         int                 `json:"page"`
+	PageSize     int                 `json:"page_size"`
+	Aggregations []AggregationBucket `json:"aggregations,omitempty"`
+	AllAggs      *AggregationSet     `json:"all_aggregations,omitempty"`
+}
+
+// searchTimeout bounds one interactive change search; exportTimeout
+// bounds a full export pass. Both cancel the underlying queries when a
+// client disconnects.
+const (
+	searchTimeout = 30 * time.Second
+	exportTimeout = 5 * time.Minute
+)
+
+// SearchFileEditRequests runs a structured search over edit request
+// details for the admin change screen. The request context cancels the
+// queries if the client goes away.
+func (s *AdminService) SearchFileEditRequests(ctx context.Context, req AdminFileEditSearchRequest) (*SearchResponse, error) {
+	if req.PageSize <= 0 || req.PageSize > 200 {
+		req.PageSize = 50
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	ctx, cancel := context.WithTimeout(ctx, searchTimeout)
+	defer cancel()
+	return s.searchChanges(ctx, req)
+}
+
+func (s *AdminService) searchChanges(ctx context.Context, req AdminFileEditSearchRequest) (*SearchResponse, error) {
+	base := s.DB.WithContext(ctx).Table("file_edit_request_details AS d").
+		Joins("JOIN file_edit_request AS r ON r.id = d.request_id").
+		Joins("JOIN file AS f ON f.id = r.file_id")
+
+	for _, clause := range req.Clauses {
+		var err error
+		base, err = applyStringOp(base, clause)
+		if err != nil {
+			return nil, err
+		}
+	}
+	base = applyFreeText(base, req.Q)
+
+	var total int64
+	if err := base.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("count changes: %w", err)
+	}
+
+	order, err := orderClause(req.Sort, req.SortDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []ChangeResult
+	err = base.
+		Select(`r.id AS request_id, r.file_id, f.filename AS file_name, r.row_id,
+			r.status, r.first_name, r.last_name, r.requested_by, r.created_at,
+			d.field_name, d.old_value, d.new_value`).
+		Order(order).
+		Limit(req.PageSize).Offset((req.Page - 1) * req.PageSize).
+		Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("search changes: %w", err)
+	}
+
+	if term := strings.TrimSpace(req.Q); term != "" {
+		lower := strings.ToLower(term)
+		for i := range results {
+			results[i].Highlights = matchedColumns(results[i], lower)
+		}
+	}
+
+	resp := &SearchResponse{Results: results, Total: total, Page: req.Page, PageSize: req.PageSize}
+	if req.Aggregation != "" {
+		aggs, err := s.aggregate(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Aggregations = aggs
+	}
+	if req.IncludeAggs {
+		all, err := s.aggregateAll(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		resp.AllAggs = all
+	}
+	return resp, nil
+}
+
+// aggregateAll computes every breakdown concurrently; each aggregation
+// is an independent query, so they parallelize cleanly on the pool.
+func (s *AdminService) aggregateAll(ctx context.Context, req AdminFileEditSearchRequest) (*AggregationSet, error) {
+	names := []string{"by_file", "by_field", "by_status", "by_community"}
+	results := make([][]AggregationBucket, len(names))
+	errs := make([]error, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			r := req
+			r.Aggregation = name
+			results[i], errs[i] = s.aggregate(ctx, r)
+		}(i, name)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return &AggregationSet{
+		ByFile:      results[0],
+		ByField:     results[1],
+		ByStatus:    results[2],
+		ByCommunity: results[3],
+	}, nil
+}
+
+// changeSearch declares the filterable fields and sort expressions of
+// the admin change search. Every clause and sort is validated against
+// this registry, so column names never come from user input.
+// change_count sorts by how many field changes a request proposes.
+var changeSearch = search.NewRegistry(map[string]search.Field{
+	"file_name":          {Column: "f.filename"},
+	"status":             {Column: "r.status"},
+	"field_name":         {Column: "d.field_name"},
+	"old_value":          {Column: "d.old_value"},
+	"new_value":          {Column: "d.new_value"},
+	"firstname":          {Column: "r.first_name"},
+	"lastname":           {Column: "r.last_name"},
+	"uploader_community": {Column: "r.uploader_community"},
+	"requested_by":       {Column: "r.requested_by", Type: search.TypeNumber},
+}, map[string]string{
+	"created_at":   "r.created_at",
+	"status":       "r.status",
+	"requested_by": "r.requested_by",
+	"file_name":    "f.filename",
+	"change_count": "(SELECT COUNT(*) FROM file_edit_request_details dc WHERE dc.request_id = r.id)",
+})
+
+// applyStringOp adds one clause's WHERE condition via the registry.
+func applyStringOp(q *gorm.DB, clause SearchClause) (*gorm.DB, error) {
+	return changeSearch.Apply(q, clause.Field, clause.Op, clause.Value)
+}
+
+// orderClause validates the requested sort against the registry and
+// returns the ORDER BY expression, defaulting to newest first.
+func orderClause(sort, dir string) (string, error) {
+	return changeSearch.OrderClause(sort, dir, "created_at")
+}
+
+// applyFreeText adds the free-text ILIKE condition across the value
+// columns. The trigram indexes created at migration time keep these
+// leading-wildcard matches off sequential scans.
+func applyFreeText(q *gorm.DB, term string) *gorm.DB {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return q
+	}
+	pattern := "%" + term + "%"
+	return q.Where("d.old_value ILIKE ? OR d.new_value ILIKE ? OR d.field_name ILIKE ?",
+		pattern, pattern, pattern)
+}
+
+// matchedColumns reports which searchable columns of a result contain
+// the lowercased free-text term.
+func matchedColumns(r ChangeResult, lowerTerm string) []string {
+	var matched []string
+	if strings.Contains(strings.ToLower(r.OldValue), lowerTerm) {
+		matched = append(matched, "old_value")
+	}
+	if strings.Contains(strings.ToLower(r.NewValue), lowerTerm) {
+		matched = append(matched, "new_value")
+	}
+	if strings.Contains(strings.ToLower(r.FieldName), lowerTerm) {
+		matched = append(matched, "field_name")
+	}
+	return matched
+}
+
+func (s *AdminService) aggregate(ctx context.Context, req AdminFileEditSearchRequest) ([]AggregationBucket, error) {
+	var groupCol string
+	switch req.Aggregation {
+	case "by_file":
+		groupCol = "f.filename"
+	case "by_field":
+		groupCol = "d.field_name"
+	case "by_status":
+		groupCol = "r.status"
+	case "by_community":
+		groupCol = "r.uploader_community"
+	default:
+		return nil, fmt.Errorf("unknown aggregation %q", req.Aggregation)
+	}
+
+	q := s.DB.WithContext(ctx).Table("file_edit_request_details AS d").
+		Joins("JOIN file_edit_request AS r ON r.id = d.request_id").
+		Joins("JOIN file AS f ON f.id = r.file_id")
+	for _, clause := range req.Clauses {
+		var err error
+		q, err = applyStringOp(q, clause)
+		if err != nil {
+			return nil, err
+		}
+	}
+	q = applyFreeText(q, req.Q)
+	var buckets []AggregationBucket
+	err := q.Select(groupCol + " AS key, COUNT(*) AS count").
+		Group(groupCol).Order("count DESC").Scan(&buckets).Error
+	if err != nil {
+		return nil, fmt.Errorf("aggregate %s: %w", req.Aggregation, err)
+	}
+	return buckets, nil
+}
+
+// GetAllUsers lists every account for the admin user screen.
+func (s *AdminService) GetAllUsers() ([]models.Auth, error) {
+	var users []models.Auth
+	if err := s.DB.Order("created_at DESC").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// UpdateUserRequest carries admin edits to a user's role and
+// communities. Nil fields are left unchanged.
+type UpdateUserRequest struct {
+	Role           *string   `json:"role"`
+	Communities    *[]string `json:"communities"`
+	CanUpload      *bool     `json:"can_upload"`
+	EthicsReviewer *bool     `json:"ethics_reviewer"`
+}
+
+// UpdateUser applies role and community changes to a user. Tokens the
+// user already holds keep the old claims; the next refresh re-reads the
+// user row and picks the changes up.
+func (s *AdminService) UpdateUser(userID uint, req UpdateUserRequest, adminID uint) error {
+	updates := map[string]any{}
+	if req.Role != nil {
+		updates["role"] = *req.Role
+	}
+	if req.Communities != nil {
+		communities, err := json.Marshal(*req.Communities)
+		if err != nil {
+			return err
+		}
+		updates["communities"] = datatypes.JSON(communities)
+	}
+	if req.CanUpload != nil {
+		updates["can_upload"] = *req.CanUpload
+	}
+	if req.EthicsReviewer != nil {
+		updates["ethics_reviewer"] = *req.EthicsReviewer
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	res := s.DB.Model(&models.Auth{}).Where("id = ?", userID).Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	s.Logs.Record(adminID, "UPDATE_USER", fmt.Sprintf("user=%d role=%v communities=%v can_upload=%v",
+		userID, req.Role != nil, req.Communities != nil, req.CanUpload != nil))
+	return nil
+}
+
+// UpdateUserRole changes a user's role.
+func (s *AdminService) UpdateUserRole(userID uint, roleName string, adminID uint) error {
+	if err := s.DB.Model(&models.Auth{}).Where("id = ?", userID).
+		Update("role", roleName).Error; err != nil {
+		return err
+	}
+	s.Logs.Record(adminID, "UPDATE_USER_ROLE", fmt.Sprintf("user=%d role=%s", userID, roleName))
+	return nil
+}
@@ -0,0 +1,344 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/i18n"
+	"github.com/datanorthnordik/nordikdriveapi/internal/middleware"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+// Service is the surface AuthController depends on, implemented by
+// AuthService. Kept as an interface so alternate backends can be wired
+// in by the server container.
+type Service interface {
+	Signup(req SignupRequest) (*models.Auth, error)
+	Login(email, password string, client ClientInfo) (*TokenPair, *models.Auth, error)
+	Refresh(refreshToken string, client ClientInfo) (*TokenPair, error)
+	LoginHistory(userID uint) ([]models.LoginEvent, error)
+	SendOTP(email string) error
+	VerifyOTP(email, code string) error
+	VerifyEmail(email, code string) error
+	ResendVerification(email string) error
+	ResetPassword(email, code, newPassword string) error
+	SendEmailChangeOTP(userID uint, newEmail string) error
+	UpdateProfile(userID uint, req UpdateProfileRequest) (*models.Auth, error)
+	ChangePassword(userID uint, currentPassword, newPassword string) error
+	Impersonate(admin *middleware.Claims, targetID uint) (string, error)
+}
+
+var _ Service = (*AuthService)(nil)
+
+// AuthController exposes the /auth endpoints.
+type AuthController struct {
+	Service Service
+}
+
+func NewAuthController(service Service) *AuthController {
+	return &AuthController{Service: service}
+}
+
+// RegisterRoutes mounts the public auth endpoints on the given group.
+func (ctl *AuthController) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/signup", ctl.Signup)
+	rg.POST("/login", ctl.Login)
+	rg.POST("/refresh", ctl.Refresh)
+	rg.POST("/send-otp", ctl.SendOTP)
+	rg.POST("/verify-otp", ctl.VerifyOTP)
+	rg.POST("/verify-email", ctl.VerifyEmail)
+	rg.POST("/resend-verification", ctl.ResendVerification)
+	rg.POST("/reset-password", ctl.ResetPassword)
+}
+
+// RegisterProtectedRoutes mounts auth endpoints that require a valid
+// access token. The /auth group itself is public, so these add
+// RequireAuth inline.
+func (ctl *AuthController) RegisterProtectedRoutes(rg *gin.RouterGroup, jwtSecret string) {
+	rg.GET("/login-history", middleware.RequireAuth(jwtSecret), ctl.LoginHistory)
+	rg.POST("/profile/send-otp", middleware.RequireAuth(jwtSecret), ctl.SendProfileOTP)
+	rg.PUT("/profile", middleware.RequireAuth(jwtSecret), ctl.UpdateProfile)
+	rg.POST("/change-password", middleware.RequireAuth(jwtSecret), ctl.ChangePassword)
+}
+
+// RegisterAdminRoutes mounts auth endpoints that belong under
+// /api/admin.
+func (ctl *AuthController) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	rg.POST("/impersonate/:userId", ctl.Impersonate)
+}
+
+// Impersonate issues a short-lived token acting as the target user.
+func (ctl *AuthController) Impersonate(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	targetID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil || targetID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "invalid user id")})
+		return
+	}
+	if uint(targetID) == claims.UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "you are already yourself")})
+		return
+	}
+	token, err := ctl.Service.Impersonate(claims, uint(targetID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "user not found")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"access_token": token, "expires_in_minutes": 15})
+}
+
+func (ctl *AuthController) Signup(c *gin.Context) {
+	var req SignupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	user, err := ctl.Service.Signup(req)
+	if err != nil {
+		if errors.Is(err, ErrEmailTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": i18n.TErr(c, err)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to create account")})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"user": user})
+}
+
+func (ctl *AuthController) Login(c *gin.Context) {
+	var req struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	pair, user, err := ctl.Service.Login(req.Email, req.Password, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.TErr(c, err)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "login failed")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tokens": pair, "user": user})
+}
+
+// clientInfo captures what the login audit records about the caller.
+// Country is whatever coarse geo header the fronting proxy adds —
+// Cloudflare and App Engine variants are checked — and stays empty when
+// the API is reached directly.
+func clientInfo(c *gin.Context) ClientInfo {
+	country := c.GetHeader("CF-IPCountry")
+	if country == "" {
+		country = c.GetHeader("X-Appengine-Country")
+	}
+	return ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Country:   country,
+	}
+}
+
+// LoginHistory returns the caller's recent login and refresh events.
+func (ctl *AuthController) LoginHistory(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	events, err := ctl.Service.LoginHistory(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to list login history")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+func (ctl *AuthController) Refresh(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	pair, err := ctl.Service.Refresh(req.RefreshToken, clientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, "invalid refresh token")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tokens": pair})
+}
+
+func (ctl *AuthController) SendOTP(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	if err := ctl.Service.SendOTP(req.Email); err != nil {
+		if errors.Is(err, ErrOTPThrottled) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": i18n.TErr(c, err)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to send code")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "if the email exists, a code has been sent"})
+}
+
+func (ctl *AuthController) VerifyOTP(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+		Code  string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	if err := ctl.Service.VerifyOTP(req.Email, req.Code); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "code verified"})
+}
+
+// VerifyEmail confirms the code mailed at signup and marks the account
+// verified.
+func (ctl *AuthController) VerifyEmail(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+		Code  string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	if err := ctl.Service.VerifyEmail(req.Email, req.Code); err != nil {
+		if errors.Is(err, ErrInvalidOTP) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.TErr(c, err)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to verify email")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "email verified"})
+}
+
+// ResendVerification mails a fresh signup verification code, throttled
+// the same way as password-reset codes.
+func (ctl *AuthController) ResendVerification(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	if err := ctl.Service.ResendVerification(req.Email); err != nil {
+		if errors.Is(err, ErrOTPThrottled) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": i18n.TErr(c, err)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to send code")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "if the email exists, a code has been sent"})
+}
+
+// SendProfileOTP sends a verification code to the address the user
+// wants to change their account email to.
+func (ctl *AuthController) SendProfileOTP(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	if err := ctl.Service.SendEmailChangeOTP(claims.UserID, req.Email); err != nil {
+		switch {
+		case errors.Is(err, ErrEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": i18n.TErr(c, err)})
+		case errors.Is(err, ErrOTPThrottled):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": i18n.TErr(c, err)})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to send code")})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "a code has been sent to the new address"})
+}
+
+// UpdateProfile changes the caller's name and email. An email change
+// requires the code sent by SendProfileOTP to the new address.
+func (ctl *AuthController) UpdateProfile(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	user, err := ctl.Service.UpdateProfile(claims.UserID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": i18n.TErr(c, err)})
+		case errors.Is(err, ErrInvalidOTP):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.TErr(c, err)})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to update profile")})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// ChangePassword sets a new password for the caller after verifying
+// the current one. Other sessions are revoked at their next refresh.
+func (ctl *AuthController) ChangePassword(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required,min=8"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	if err := ctl.Service.ChangePassword(claims.UserID, req.CurrentPassword, req.NewPassword); err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c, "current password is incorrect")})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to change password")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password updated"})
+}
+
+func (ctl *AuthController) ResetPassword(c *gin.Context) {
+	var req struct {
+		Email       string `json:"email" binding:"required,email"`
+		Code        string `json:"code" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required,min=8"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	if err := ctl.Service.ResetPassword(req.Email, req.Code, req.NewPassword); err != nil {
+		if errors.Is(err, ErrInvalidOTP) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.TErr(c, err)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to reset password")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password updated"})
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/mail"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+// loginHistoryLimit caps the user-facing history; older events stay in
+// the table for the audit trail but are not paged through the API.
+const loginHistoryLimit = 50
+
+// A success preceded by this many failures inside the window triggers
+// an admin alert — the classic "guessed it on the Nth try" shape.
+const (
+	failureAlertThreshold = 5
+	failureAlertWindow    = 15 * time.Minute
+)
+
+// ClientInfo is what the controller captures about the connecting
+// client. Country is coarse geo supplied by the fronting proxy (see
+// clientCountry in the controller); it is empty when no proxy adds it.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+	Country   string
+}
+
+// recordLogin appends one login event. The audit trail is reporting
+// data: a write failure is logged and never blocks the login itself.
+func (s *AuthService) recordLogin(userID uint, email, kind string, client ClientInfo, success bool) {
+	event := models.LoginEvent{
+		UserID:    userID,
+		Email:     email,
+		Kind:      kind,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Country:   client.Country,
+		Success:   success,
+	}
+	if err := s.DB.Create(&event).Error; err != nil {
+		log.Printf("auth: record login event for %s: %v", email, err)
+	}
+}
+
+// checkLoginAnomalies runs after a successful password login and emails
+// the alert address when the login looks like a possible compromise:
+// a country this account has never logged in from before, or a success
+// right after a burst of failures. Alerts are best effort and are
+// skipped entirely when ALERT_EMAIL is unset.
+func (s *AuthService) checkLoginAnomalies(user *models.Auth, client ClientInfo) {
+	if s.Config.AlertEmail == "" {
+		return
+	}
+	if client.Country != "" {
+		var seen int64
+		err := s.DB.Model(&models.LoginEvent{}).
+			Where("user_id = ? AND success = true AND country = ?", user.ID, client.Country).
+			Count(&seen).Error
+		if err != nil {
+			log.Printf("auth: country check for user %d: %v", user.ID, err)
+		} else if seen == 0 {
+			var prior int64
+			s.DB.Model(&models.LoginEvent{}).
+				Where("user_id = ? AND success = true AND country <> ''", user.ID).
+				Count(&prior)
+			// The first geo-tagged login ever is baseline, not an anomaly.
+			if prior > 0 {
+				s.sendLoginAlert(user, fmt.Sprintf(
+					"logged in from %s, a country not seen on this account before (IP %s)",
+					client.Country, client.IP))
+			}
+		}
+	}
+	var failures int64
+	err := s.DB.Model(&models.LoginEvent{}).
+		Where("email = ? AND kind = 'login' AND success = false AND created_at > ?",
+			user.Email, time.Now().Add(-failureAlertWindow)).
+		Count(&failures).Error
+	if err != nil {
+		log.Printf("auth: failure check for %s: %v", user.Email, err)
+	} else if failures >= failureAlertThreshold {
+		s.sendLoginAlert(user, fmt.Sprintf(
+			"logged in after %d failed attempts in the last %d minutes (IP %s)",
+			failures, int(failureAlertWindow.Minutes()), client.IP))
+	}
+}
+
+func (s *AuthService) sendLoginAlert(user *models.Auth, detail string) {
+	subject := fmt.Sprintf("NordikDrive login alert: %s", user.Email)
+	body := fmt.Sprintf("%s %s (%s) %s.", user.FirstName, user.LastName, user.Email, detail)
+	if err := mail.Send(s.Config, s.Config.AlertEmail, mail.Notification(subject, body)); err != nil {
+		log.Printf("auth: send login alert for %s: %v", user.Email, err)
+	}
+	s.Logs.Record(user.ID, "LOGIN_ALERT", detail)
+}
+
+// LoginHistory returns the user's most recent login and refresh events,
+// newest first, so they can spot sessions they do not recognize.
+func (s *AuthService) LoginHistory(userID uint) ([]models.LoginEvent, error) {
+	var events []models.LoginEvent
+	err := s.DB.Where("user_id = ?", userID).
+		Order("created_at DESC").Limit(loginHistoryLimit).
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
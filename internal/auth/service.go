@@ -0,0 +1,462 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/config"
+	"github.com/datanorthnordik/nordikdriveapi/internal/logs"
+	"github.com/datanorthnordik/nordikdriveapi/internal/mail"
+	"github.com/datanorthnordik/nordikdriveapi/internal/middleware"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrEmailTaken         = errors.New("an account with this email already exists")
+	ErrInvalidOTP         = errors.New("invalid or expired code")
+	ErrOTPThrottled       = errors.New("too many codes requested; try again later")
+)
+
+// OTP abuse limits: at most otpSendLimitPerHour codes per email per hour,
+// and at most otpMaxAttempts wrong guesses before a code stops working.
+const (
+	otpSendLimitPerHour = 5
+	otpMaxAttempts      = 5
+)
+
+// AuthService handles signup, login, token refresh and the OTP-based
+// password reset flow.
+type AuthService struct {
+	DB     *gorm.DB
+	Config *config.Config
+	Logs   *logs.LogService
+}
+
+func NewAuthService(db *gorm.DB, cfg *config.Config, logSvc *logs.LogService) *AuthService {
+	return &AuthService{DB: db, Config: cfg, Logs: logSvc}
+}
+
+// SignupRequest is the payload for account creation.
+type SignupRequest struct {
+	FirstName   string   `json:"firstname" binding:"required"`
+	LastName    string   `json:"lastname" binding:"required"`
+	Email       string   `json:"email" binding:"required,email"`
+	Password    string   `json:"password" binding:"required,min=8"`
+	Communities []string `json:"communities"`
+}
+
+// TokenPair is what login and refresh return to the client.
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Signup creates a new account with a bcrypt-hashed password.
+func (s *AuthService) Signup(req SignupRequest) (*models.Auth, error) {
+	var existing models.Auth
+	err := s.DB.Where("email = ?", req.Email).First(&existing).Error
+	if err == nil {
+		return nil, ErrEmailTaken
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("hash password: %w", err)
+	}
+	communities, _ := json.Marshal(req.Communities)
+	user := models.Auth{
+		FirstName:   req.FirstName,
+		LastName:    req.LastName,
+		Email:       req.Email,
+		Password:    string(hash),
+		Role:        "User",
+		Communities: datatypes.JSON(communities),
+	}
+	if err := s.DB.Create(&user).Error; err != nil {
+		return nil, err
+	}
+	// The verification code is best-effort: a mail outage must not block
+	// account creation, and /auth/resend-verification can retry.
+	if err := s.issueOTP(user.Email); err != nil {
+		log.Printf("auth: send verification code to %s: %v", user.Email, err)
+	}
+	s.Logs.Record(user.ID, "SIGNUP", user.Email)
+	return &user, nil
+}
+
+// VerifyEmail confirms the code mailed at signup and marks the account
+// verified, unlocking uploads and edit requests.
+func (s *AuthService) VerifyEmail(email, code string) error {
+	if err := s.VerifyOTP(email, code); err != nil {
+		return err
+	}
+	if err := s.DB.Model(&models.Auth{}).Where("email = ?", email).
+		Update("email_verified", true).Error; err != nil {
+		return err
+	}
+	s.DB.Where("email = ?", email).Delete(&models.OTP{})
+	var user models.Auth
+	if err := s.DB.Where("email = ?", email).First(&user).Error; err == nil {
+		s.Logs.Record(user.ID, "VERIFY_EMAIL", email)
+	}
+	return nil
+}
+
+// ResendVerification mails a fresh verification code. Like SendOTP it
+// succeeds silently for unknown or already-verified addresses so it
+// cannot be used to probe for accounts.
+func (s *AuthService) ResendVerification(email string) error {
+	var user models.Auth
+	if err := s.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.EmailVerified {
+		return nil
+	}
+	if err := s.issueOTP(email); err != nil {
+		return err
+	}
+	s.Logs.Record(user.ID, "SEND_VERIFICATION", email)
+	return nil
+}
+
+// Login verifies credentials and mints an access/refresh token pair.
+func (s *AuthService) Login(email, password string, client ClientInfo) (*TokenPair, *models.Auth, error) {
+	var user models.Auth
+	if err := s.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.recordLogin(0, email, "login", client, false)
+			return nil, nil, ErrInvalidCredentials
+		}
+		return nil, nil, err
+	}
+	if !verifyPassword(&user, password) {
+		s.recordLogin(user.ID, email, "login", client, false)
+		return nil, nil, ErrInvalidCredentials
+	}
+	pair, err := s.mintTokens(&user)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Anomaly checks read the trail as it stood before this success, so
+	// they run before the event is appended.
+	s.checkLoginAnomalies(&user, client)
+	s.recordLogin(user.ID, email, "login", client, true)
+	now := time.Now()
+	user.LastLoginAt = &now
+	if err := s.DB.Model(&user).Update("last_login_at", now).Error; err != nil {
+		// The stamp is reporting data only; login still succeeds.
+		log.Printf("auth: stamp last login for user %d: %v", user.ID, err)
+	}
+	s.Logs.Record(user.ID, "LOGIN", user.Email)
+	return pair, &user, nil
+}
+
+// Refresh exchanges a valid refresh token for a fresh token pair, picking
+// up any role/community changes made since the last mint. Tokens issued
+// before the user's last password change are rejected, which is how a
+// password change revokes other sessions.
+func (s *AuthService) Refresh(refreshToken string, client ClientInfo) (*TokenPair, error) {
+	claims, err := middleware.ParseToken(refreshToken, s.Config.JWTSecret)
+	if err != nil || claims.TokenType != "refresh" {
+		return nil, ErrInvalidCredentials
+	}
+	var user models.Auth
+	if err := s.DB.First(&user, claims.UserID).Error; err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if user.PasswordChangedAt != nil &&
+		(claims.IssuedAt == nil || claims.IssuedAt.Time.Before(*user.PasswordChangedAt)) {
+		return nil, ErrInvalidCredentials
+	}
+	pair, err := s.mintTokens(&user)
+	if err != nil {
+		return nil, err
+	}
+	// Refreshes go in the trail too — a stolen refresh token used from
+	// elsewhere shows up in the user's history — but they do not alert;
+	// the anomaly rules are about password logins.
+	s.recordLogin(user.ID, user.Email, "refresh", client, true)
+	return pair, nil
+}
+
+func (s *AuthService) mintTokens(user *models.Auth) (*TokenPair, error) {
+	access, err := s.mintToken(user, "access", time.Duration(s.Config.AccessTokenMinutes)*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := s.mintToken(user, "refresh", time.Duration(s.Config.RefreshTokenHours)*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+func (s *AuthService) mintToken(user *models.Auth, tokenType string, ttl time.Duration) (string, error) {
+	var communities []string
+	_ = json.Unmarshal(user.Communities, &communities)
+	claims := middleware.Claims{
+		UserID:      user.ID,
+		Email:       user.Email,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		Role:        user.Role,
+		Communities: communities,
+		TokenType:   tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.Config.JWTSecret))
+}
+
+// impersonationTTL keeps support sessions short regardless of the normal
+// access token lifetime.
+const impersonationTTL = 15 * time.Minute
+
+// Impersonate mints a short-lived access token that acts as the target
+// user while recording the admin behind it, so support staff can see
+// exactly what a user sees.
+func (s *AuthService) Impersonate(admin *middleware.Claims, targetID uint) (string, error) {
+	var target models.Auth
+	if err := s.DB.First(&target, targetID).Error; err != nil {
+		return "", fmt.Errorf("load user %d: %w", targetID, err)
+	}
+	var communities []string
+	_ = json.Unmarshal(target.Communities, &communities)
+
+	claims := middleware.Claims{
+		UserID:         target.ID,
+		Email:          target.Email,
+		FirstName:      target.FirstName,
+		LastName:       target.LastName,
+		Role:           target.Role,
+		Communities:    communities,
+		TokenType:      "access",
+		ImpersonatorID: admin.UserID,
+		Banner: fmt.Sprintf("Viewing as %s %s (%s) — support session by %s",
+			target.FirstName, target.LastName, target.Email, admin.Email),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(impersonationTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.Config.JWTSecret))
+	if err != nil {
+		return "", err
+	}
+	s.Logs.Record(admin.UserID, "IMPERSONATE_START", fmt.Sprintf("user=%d email=%s", target.ID, target.Email))
+	return token, nil
+}
+
+// SendOTP issues a six-digit reset code to the given email. It succeeds
+// silently when the email is unknown so the endpoint can't be used to
+// probe for accounts.
+func (s *AuthService) SendOTP(email string) error {
+	var user models.Auth
+	if err := s.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if err := s.issueOTP(email); err != nil {
+		return err
+	}
+	s.Logs.Record(user.ID, "SEND_OTP", email)
+	return nil
+}
+
+// issueOTP creates and mails a fresh code for the email, expiring any
+// code issued before it so only the latest one can verify. Expired rows
+// are kept for an hour to enforce the resend throttle.
+func (s *AuthService) issueOTP(email string) error {
+	var recent int64
+	if err := s.DB.Model(&models.OTP{}).
+		Where("email = ? AND created_at > ?", email, time.Now().Add(-time.Hour)).
+		Count(&recent).Error; err != nil {
+		return err
+	}
+	if recent >= otpSendLimitPerHour {
+		return ErrOTPThrottled
+	}
+	if err := s.DB.Model(&models.OTP{}).Where("email = ?", email).
+		Update("expires_at", time.Now()).Error; err != nil {
+		return err
+	}
+	code, err := generateOTPCode()
+	if err != nil {
+		return err
+	}
+	const otpMinutes = 10
+	otp := models.OTP{
+		Email:     email,
+		Code:      code,
+		ExpiresAt: time.Now().Add(otpMinutes * time.Minute),
+	}
+	if err := s.DB.Create(&otp).Error; err != nil {
+		return err
+	}
+	if err := mail.Send(s.Config, email, mail.OTP(code, otpMinutes)); err != nil {
+		return fmt.Errorf("send otp email: %w", err)
+	}
+	return nil
+}
+
+// SendEmailChangeOTP issues a verification code to an address the user
+// wants to switch to. Unlike SendOTP it targets an address without an
+// account, so it rejects taken emails instead of staying silent — the
+// caller is authenticated, and signup already discloses the same fact.
+func (s *AuthService) SendEmailChangeOTP(userID uint, newEmail string) error {
+	var existing models.Auth
+	if err := s.DB.Where("email = ?", newEmail).First(&existing).Error; err == nil {
+		return ErrEmailTaken
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err := s.issueOTP(newEmail); err != nil {
+		return err
+	}
+	s.Logs.Record(userID, "SEND_OTP", newEmail)
+	return nil
+}
+
+// UpdateProfileRequest is the payload for self-service profile edits.
+// Code is only required when Email differs from the current address.
+type UpdateProfileRequest struct {
+	FirstName string `json:"firstname" binding:"required"`
+	LastName  string `json:"lastname" binding:"required"`
+	Email     string `json:"email" binding:"required,email"`
+	Code      string `json:"code"`
+}
+
+// UpdateProfile changes the user's name and, after OTP re-verification
+// of the new address, their email. Claims inside already-issued tokens
+// keep the old values until the next refresh, which re-reads the user
+// row.
+func (s *AuthService) UpdateProfile(userID uint, req UpdateProfileRequest) (*models.Auth, error) {
+	var user models.Auth
+	if err := s.DB.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	if req.Email != user.Email {
+		var existing models.Auth
+		if err := s.DB.Where("email = ?", req.Email).First(&existing).Error; err == nil {
+			return nil, ErrEmailTaken
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		// The code was sent to the new address via /auth/send-otp, proving
+		// the user controls it before we switch the account over.
+		if err := s.VerifyOTP(req.Email, req.Code); err != nil {
+			return nil, err
+		}
+		s.DB.Where("email = ?", req.Email).Delete(&models.OTP{})
+	}
+	user.FirstName = req.FirstName
+	user.LastName = req.LastName
+	user.Email = req.Email
+	if err := s.DB.Save(&user).Error; err != nil {
+		return nil, err
+	}
+	s.Logs.Record(userID, "UPDATE_PROFILE", user.Email)
+	return &user, nil
+}
+
+// VerifyOTP checks that the given code matches the latest unexpired OTP
+// for the email. Each wrong guess counts against the code; past the
+// attempt limit it no longer verifies even when guessed right.
+func (s *AuthService) VerifyOTP(email, code string) error {
+	var otp models.OTP
+	err := s.DB.Where("email = ? AND expires_at > ?", email, time.Now()).
+		Order("created_at DESC").First(&otp).Error
+	if err != nil {
+		return ErrInvalidOTP
+	}
+	if otp.Attempts >= otpMaxAttempts {
+		return ErrInvalidOTP
+	}
+	if otp.Code != code {
+		s.DB.Model(&otp).Update("attempts", gorm.Expr("attempts + 1"))
+		return ErrInvalidOTP
+	}
+	return nil
+}
+
+// ResetPassword sets a new password after a successful OTP verification.
+func (s *AuthService) ResetPassword(email, code, newPassword string) error {
+	if err := s.VerifyOTP(email, code); err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+	if err := s.DB.Model(&models.Auth{}).Where("email = ?", email).
+		Updates(map[string]any{"password": string(hash), "password_changed_at": time.Now()}).Error; err != nil {
+		return err
+	}
+	s.DB.Where("email = ?", email).Delete(&models.OTP{})
+	var user models.Auth
+	if err := s.DB.Where("email = ?", email).First(&user).Error; err == nil {
+		s.Logs.Record(user.ID, "RESET_PASSWORD", email)
+	}
+	return nil
+}
+
+// ChangePassword sets a new password for a logged-in user after
+// verifying the current one. Stamping password_changed_at revokes every
+// refresh token issued before now, so other sessions cannot outlive the
+// old password.
+func (s *AuthService) ChangePassword(userID uint, currentPassword, newPassword string) error {
+	var user models.Auth
+	if err := s.DB.First(&user, userID).Error; err != nil {
+		return err
+	}
+	if !verifyPassword(&user, currentPassword) {
+		return ErrInvalidCredentials
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+	if err := s.DB.Model(&models.Auth{}).Where("id = ?", userID).
+		Updates(map[string]any{"password": string(hash), "password_changed_at": time.Now()}).Error; err != nil {
+		return err
+	}
+	s.Logs.Record(userID, "CHANGE_PASSWORD", user.Email)
+	return nil
+}
+
+// verifyPassword checks a plaintext password against the user's bcrypt
+// hash.
+func verifyPassword(user *models.Auth, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) == nil
+}
+
+func generateOTPCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
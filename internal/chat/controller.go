@@ -0,0 +1,171 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/file"
+	"github.com/datanorthnordik/nordikdriveapi/internal/middleware"
+)
+
+// Service is the surface ChatController depends on, implemented by
+// ChatService. Kept as an interface so alternate backends (e.g. a mock
+// LLM) can be wired in by the server container.
+type Service interface {
+	Chat(ctx context.Context, req ChatRequest, userID uint, role string, communities []string) (*ChatResponse, error)
+	Synthesize(ctx context.Context, req TTSRequest) ([]byte, error)
+	UsageReport(from, to time.Time) ([]UsageSummary, error)
+	ExportTranscriptsCSV(from, to time.Time, filterUser uint, requesterID uint) ([]byte, error)
+	CurrentModel() string
+	SetModel(name string, adminID uint)
+}
+
+var _ Service = (*ChatService)(nil)
+
+// ChatController exposes the /api/chat endpoints.
+type ChatController struct {
+	Service Service
+}
+
+func NewChatController(service Service) *ChatController {
+	return &ChatController{Service: service}
+}
+
+func (ctl *ChatController) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/chat", middleware.Backpressure(), ctl.Chat)
+	rg.POST("/chat/tts", middleware.Backpressure(), ctl.TTS)
+}
+
+// RegisterAdminRoutes mounts chat endpoints that belong under
+// /api/admin.
+func (ctl *ChatController) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	rg.GET("/chat/usage", ctl.Usage)
+	rg.GET("/chat/transcripts", ctl.Transcripts)
+	rg.GET("/chat/model", ctl.GetModel)
+	rg.PUT("/chat/model", ctl.SetModel)
+}
+
+// GetModel reports the model currently answering chat calls.
+func (ctl *ChatController) GetModel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"model": ctl.Service.CurrentModel()})
+}
+
+// SetModel switches the default chat model at runtime, for when Google
+// deprecates a version between deploys. An empty model reverts to the
+// configured default.
+func (ctl *ChatController) SetModel(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req struct {
+		Model string `json:"model"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctl.Service.SetModel(req.Model, claims.UserID)
+	c.JSON(http.StatusOK, gin.H{"model": ctl.Service.CurrentModel()})
+}
+
+// Transcripts downloads the anonymized chat Q&A export for research
+// ethics review. Admin role alone is not enough: the requester must
+// also hold the ethics_reviewer capability.
+func (ctl *ChatController) Transcripts(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var from, to time.Time
+	if v := c.Query("from"); v != "" {
+		if t, err := time.Parse("2006-01-02", v); err == nil {
+			from = t
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if t, err := time.Parse("2006-01-02", v); err == nil {
+			to = t.Add(24*time.Hour - time.Second)
+		}
+	}
+	var filterUser uint
+	if v := c.Query("user"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+			return
+		}
+		filterUser = uint(id)
+	}
+	data, err := ctl.Service.ExportTranscriptsCSV(from, to, filterUser, claims.UserID)
+	if err != nil {
+		if errors.Is(err, ErrNotEthicsReviewer) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build transcript export"})
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=\"transcripts.csv\"")
+	c.Data(http.StatusOK, "text/csv", data)
+}
+
+// Usage reports per-user token spend between optional from/to dates.
+func (ctl *ChatController) Usage(c *gin.Context) {
+	var from, to time.Time
+	if v := c.Query("from"); v != "" {
+		if t, err := time.Parse("2006-01-02", v); err == nil {
+			from = t
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if t, err := time.Parse("2006-01-02", v); err == nil {
+			to = t.Add(24*time.Hour - time.Second)
+		}
+	}
+	summaries, err := ctl.Service.UsageReport(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build usage report"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"usage": summaries})
+}
+
+func (ctl *ChatController) Chat(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req ChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := ctl.Service.Chat(c.Request.Context(), req, claims.UserID, claims.Role, claims.Communities)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, file.ErrFileNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, file.ErrAccessDenied):
+			status = http.StatusForbidden
+		case errors.Is(err, ErrQuotaExceeded):
+			status = http.StatusTooManyRequests
+		case errors.Is(err, ErrInvalidGenParams):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (ctl *ChatController) TTS(c *gin.Context) {
+	var req TTSRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	audio, err := ctl.Service.Synthesize(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "audio/mpeg", audio)
+}
@@ -0,0 +1,66 @@
+package chat
+
+import (
+	"strings"
+)
+
+// redactedPlaceholder replaces sensitive cell values in prompts and any
+// value that nonetheless shows up in an answer.
+const redactedPlaceholder = "[redacted]"
+
+// minRedactableLen keeps the output scan from mangling answers over
+// trivially short values ("12" appears in dates, ages and row numbers).
+// Short values are still scrubbed from the prompt itself.
+const minRedactableLen = 4
+
+// redactor scrubs configured sensitive columns (exact birth dates, ID
+// numbers, ...) from the row context before it is sent to Vertex, and
+// remembers the removed values so answers can be scanned for leaks —
+// the model may have seen a value in a previous turn's cached prompt.
+type redactor struct {
+	columns map[string]bool
+	values  map[string]bool
+}
+
+// newRedactor builds a redactor over the configured sensitive columns;
+// it returns nil when none are configured, and a nil redactor is a
+// no-op everywhere.
+func (s *ChatService) newRedactor() *redactor {
+	if len(s.Config.ChatRedactedColumns) == 0 {
+		return nil
+	}
+	columns := make(map[string]bool, len(s.Config.ChatRedactedColumns))
+	for _, name := range s.Config.ChatRedactedColumns {
+		columns[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	return &redactor{columns: columns, values: map[string]bool{}}
+}
+
+// scrub replaces the sensitive columns' values in one row with the
+// placeholder, remembering the originals for the output scan.
+func (r *redactor) scrub(rowData map[string]string) {
+	if r == nil {
+		return
+	}
+	for k, v := range rowData {
+		if v == "" || !r.columns[strings.ToLower(k)] {
+			continue
+		}
+		r.values[v] = true
+		rowData[k] = redactedPlaceholder
+	}
+}
+
+// scan replaces any scrubbed value that leaked into the answer.
+func (r *redactor) scan(answer string) string {
+	if r == nil {
+		return answer
+	}
+	for v := range r.values {
+		if len(v) < minRedactableLen {
+			continue
+		}
+		answer = strings.ReplaceAll(answer, v, redactedPlaceholder)
+	}
+	return answer
+}
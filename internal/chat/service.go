@@ -0,0 +1,318 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/config"
+	"github.com/datanorthnordik/nordikdriveapi/internal/file"
+	"github.com/datanorthnordik/nordikdriveapi/internal/llm"
+	"github.com/datanorthnordik/nordikdriveapi/internal/logs"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var (
+	ErrNoAnswer         = errors.New("the model returned no answer")
+	ErrInvalidGenParams = errors.New("invalid generation parameters")
+)
+
+// Rows included in the prompt context are capped so a large registry
+// doesn't blow the model's input window.
+const maxContextRows = 200
+
+// ChatService answers natural-language questions about a file's rows
+// using the configured llm.Provider (Gemini on Vertex in production).
+type ChatService struct {
+	DB       *gorm.DB
+	Config   *config.Config
+	Files    *file.FileService
+	Logs     *logs.LogService
+	Provider llm.Provider
+
+	// modelOverride, when set by an admin at runtime, replaces the
+	// configured default model until the next restart.
+	modelMu       sync.RWMutex
+	modelOverride string
+}
+
+func NewChatService(db *gorm.DB, cfg *config.Config, provider llm.Provider, files *file.FileService, logSvc *logs.LogService) *ChatService {
+	return &ChatService{DB: db, Config: cfg, Provider: provider, Files: files, Logs: logSvc}
+}
+
+// ChatRequest is the body of POST /api/chat. Filename is kept for older
+// clients; Filenames asks over several files at once and AllFiles asks
+// over every file the user can access.
+type ChatRequest struct {
+	Filename  string   `json:"filename"`
+	Filenames []string `json:"filenames"`
+	AllFiles  bool     `json:"all_files"`
+	Question  string   `json:"question" binding:"required"`
+	// Temperature and MaxOutputTokens override the configured defaults
+	// for this call, within validated bounds.
+	Temperature     *float32 `json:"temperature"`
+	MaxOutputTokens int32    `json:"max_output_tokens"`
+}
+
+// ChatResponse carries the model's answer back to the client along with
+// the files whose rows were in the prompt context, the FileData row IDs
+// that were in context, and the subset the model actually cited so the
+// UI can deep-link to source rows.
+type ChatResponse struct {
+	Answer      string   `json:"answer"`
+	Sources     []string `json:"sources"`
+	ContextRows []uint   `json:"context_rows"`
+	CitedRows   []uint   `json:"cited_rows"`
+}
+
+// CurrentModel returns the model in effect: the runtime override when
+// an admin has set one, the configured default otherwise.
+func (s *ChatService) CurrentModel() string {
+	s.modelMu.RLock()
+	defer s.modelMu.RUnlock()
+	if s.modelOverride != "" {
+		return s.modelOverride
+	}
+	return s.Config.GeminiModel
+}
+
+// SetModel switches the default model at runtime, like the maintenance
+// toggle: in-memory only, reverting to the configured default on
+// restart. An empty name clears the override.
+func (s *ChatService) SetModel(name string, adminID uint) {
+	s.modelMu.Lock()
+	s.modelOverride = strings.TrimSpace(name)
+	s.modelMu.Unlock()
+	s.Logs.Record(adminID, "SET_CHAT_MODEL", s.CurrentModel())
+}
+
+// genOptions merges the configured generation defaults with a request's
+// validated overrides.
+func (s *ChatService) genOptions(req ChatRequest) (*llm.GenOptions, error) {
+	opts := &llm.GenOptions{
+		MaxOutputTokens: int32(s.Config.GeminiMaxOutputTokens),
+		SafetyThreshold: s.Config.GeminiSafetyThreshold,
+	}
+	if s.Config.GeminiTemperature > 0 {
+		t := float32(s.Config.GeminiTemperature)
+		opts.Temperature = &t
+	}
+	if req.Temperature != nil {
+		if *req.Temperature < 0 || *req.Temperature > 2 {
+			return nil, fmt.Errorf("%w: temperature must be between 0 and 2", ErrInvalidGenParams)
+		}
+		opts.Temperature = req.Temperature
+	}
+	if req.MaxOutputTokens != 0 {
+		if req.MaxOutputTokens < 1 || req.MaxOutputTokens > 8192 {
+			return nil, fmt.Errorf("%w: max_output_tokens must be between 1 and 8192", ErrInvalidGenParams)
+		}
+		opts.MaxOutputTokens = req.MaxOutputTokens
+	}
+	return opts, nil
+}
+
+// Chat builds a row context from the requested files (filtered to the
+// user's communities for non-admins), labels every row with its source
+// file, and asks Gemini the user's question.
+func (s *ChatService) Chat(ctx context.Context, req ChatRequest, userID uint, role string, communities []string) (*ChatResponse, error) {
+	if err := s.checkQuota(userID); err != nil {
+		return nil, err
+	}
+	files, err := s.resolveFiles(req, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	// Spread the context budget across the requested files so one large
+	// registry doesn't crowd the others out.
+	rowsPerFile := maxContextRows / len(files)
+	if rowsPerFile < 1 {
+		rowsPerFile = 1
+	}
+
+	red := s.newRedactor()
+	var b strings.Builder
+	sources := make([]string, 0, len(files))
+	var contextRows []uint
+	for _, f := range files {
+		var rows []models.FileData
+		if err := s.DB.Where("file_id = ? AND version = ?", f.ID, f.Version).
+			Order("id").Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		section, included := s.buildContext(rows, role, communities, rowsPerFile, red)
+		if section == "" {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("=== Data from %q ===\n%s", f.Filename, section))
+		sources = append(sources, f.Filename)
+		contextRows = append(contextRows, included...)
+	}
+
+	prompt := fmt.Sprintf(
+		"You are a careful research assistant for community death and burial registries.\n"+
+			"Answer the question using only the data below. Each row is labelled with the\n"+
+			"file it came from; when you state a fact, name the source file it is drawn\n"+
+			"from and cite the supporting rows as [row N]. If the data does not contain\n"+
+			"the answer, say so plainly.\n\n%s\nQuestion: %s",
+		b.String(), req.Question)
+
+	opts, err := s.genOptions(req)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.Provider.GenerateContent(ctx, s.CurrentModel(), prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.recordUsage(userID, sources, result.Usage)
+	if result.Text == "" {
+		return nil, ErrNoAnswer
+	}
+	answer := red.scan(result.Text)
+	s.recordTranscript(userID, sources, req.Question, answer)
+	s.Logs.Record(userID, "CHAT", strings.Join(sources, ","))
+	return &ChatResponse{
+		Answer:      answer,
+		Sources:     sources,
+		ContextRows: contextRows,
+		CitedRows:   citedRows(answer, contextRows),
+	}, nil
+}
+
+var rowCitationPattern = regexp.MustCompile(`row\s+(\d+)`)
+
+// citedRows extracts the row IDs the model mentioned in its answer,
+// keeping only IDs that were actually in the prompt context so the model
+// can't fabricate citations.
+func citedRows(answer string, contextRows []uint) []uint {
+	inContext := make(map[uint]bool, len(contextRows))
+	for _, id := range contextRows {
+		inContext[id] = true
+	}
+	seen := map[uint]bool{}
+	var cited []uint
+	for _, match := range rowCitationPattern.FindAllStringSubmatch(strings.ToLower(answer), -1) {
+		n, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil {
+			continue
+		}
+		id := uint(n)
+		if inContext[id] && !seen[id] {
+			cited = append(cited, id)
+			seen[id] = true
+		}
+	}
+	return cited
+}
+
+// resolveFiles turns the request's file selection into accessible File
+// rows, rejecting any named file the user cannot read.
+func (s *ChatService) resolveFiles(req ChatRequest, userID uint, role string) ([]models.File, error) {
+	if req.AllFiles {
+		var all []models.File
+		if err := s.DB.Where("is_delete = false").Order("filename").Find(&all).Error; err != nil {
+			return nil, err
+		}
+		accessible := make([]models.File, 0, len(all))
+		for _, f := range all {
+			if s.Files.CanAccessFile(&f, userID, role) {
+				accessible = append(accessible, f)
+			}
+		}
+		if len(accessible) == 0 {
+			return nil, file.ErrFileNotFound
+		}
+		return accessible, nil
+	}
+
+	names := req.Filenames
+	if len(names) == 0 && req.Filename != "" {
+		names = []string{req.Filename}
+	}
+	if len(names) == 0 {
+		return nil, errors.New("provide filename, filenames, or all_files")
+	}
+	files := make([]models.File, 0, len(names))
+	for _, name := range names {
+		var f models.File
+		if err := s.DB.Where("filename = ? AND is_delete = false", name).First(&f).Error; err != nil {
+			return nil, file.ErrFileNotFound
+		}
+		if !s.Files.CanAccessFile(&f, userID, role) {
+			return nil, file.ErrAccessDenied
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// buildContext renders up to maxRows rows as labelled lines, filtering
+// to the user's communities unless they are an admin, and returns the
+// IDs of the rows it included.
+func (s *ChatService) buildContext(rows []models.FileData, role string, communities []string, maxRows int, red *redactor) (string, []uint) {
+	var b strings.Builder
+	var included []uint
+	for _, row := range rows {
+		if len(included) >= maxRows {
+			break
+		}
+		rowData := map[string]string{}
+		if err := json.Unmarshal(row.RowData, &rowData); err != nil {
+			continue
+		}
+		if role != "Admin" && !matchesCommunities(rowData, communities) {
+			continue
+		}
+		red.scrub(rowData)
+		b.WriteString(fmt.Sprintf("row %d: ", row.ID))
+		first := true
+		for k, v := range rowData {
+			if v == "" {
+				continue
+			}
+			if !first {
+				b.WriteString("; ")
+			}
+			b.WriteString(k + "=" + v)
+			first = false
+		}
+		b.WriteString("\n")
+		included = append(included, row.ID)
+	}
+	return b.String(), included
+}
+
+// communityColumns are the header names that may carry a community value
+// in partner spreadsheets.
+var communityColumns = []string{"Community", "community", "First Nation", "Band", "Reserve"}
+
+// matchesCommunities reports whether a row belongs to any of the user's
+// communities. Rows with no recognizable community column are visible to
+// everyone.
+func matchesCommunities(rowData map[string]string, communities []string) bool {
+	var value string
+	for _, col := range communityColumns {
+		if v, ok := rowData[col]; ok && v != "" {
+			value = v
+			break
+		}
+	}
+	if value == "" {
+		return true
+	}
+	for _, community := range communities {
+		if strings.Contains(strings.ToLower(value), strings.ToLower(community)) {
+			return true
+		}
+	}
+	return false
+}
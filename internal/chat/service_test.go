@@ -0,0 +1,152 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/datatypes"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/config"
+	"github.com/datanorthnordik/nordikdriveapi/internal/llm"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+// newTestService wires a ChatService to the hermetic local provider; no
+// database or GCS is touched by the paths under test.
+func newTestService(cfg *config.Config, provider llm.Provider) *ChatService {
+	return &ChatService{Config: cfg, Provider: provider}
+}
+
+func TestGenOptionsValidation(t *testing.T) {
+	s := newTestService(&config.Config{GeminiMaxOutputTokens: 1024, GeminiTemperature: 0.5}, llm.NewLocalProvider())
+
+	opts, err := s.genOptions(ChatRequest{})
+	if err != nil {
+		t.Fatalf("defaults: %v", err)
+	}
+	if opts.MaxOutputTokens != 1024 {
+		t.Errorf("MaxOutputTokens = %d, want 1024", opts.MaxOutputTokens)
+	}
+	if opts.Temperature == nil || *opts.Temperature != 0.5 {
+		t.Errorf("Temperature = %v, want 0.5", opts.Temperature)
+	}
+
+	override := float32(1.2)
+	opts, err = s.genOptions(ChatRequest{Temperature: &override, MaxOutputTokens: 64})
+	if err != nil {
+		t.Fatalf("overrides: %v", err)
+	}
+	if *opts.Temperature != 1.2 || opts.MaxOutputTokens != 64 {
+		t.Errorf("overrides not applied: %+v", opts)
+	}
+
+	tooHot := float32(2.5)
+	if _, err := s.genOptions(ChatRequest{Temperature: &tooHot}); !errors.Is(err, ErrInvalidGenParams) {
+		t.Errorf("temperature 2.5: got %v, want ErrInvalidGenParams", err)
+	}
+	if _, err := s.genOptions(ChatRequest{MaxOutputTokens: 9000}); !errors.Is(err, ErrInvalidGenParams) {
+		t.Errorf("max_output_tokens 9000: got %v, want ErrInvalidGenParams", err)
+	}
+}
+
+func TestCurrentModelFallsBackToConfig(t *testing.T) {
+	s := newTestService(&config.Config{GeminiModel: "gemini-test"}, llm.NewLocalProvider())
+	if got := s.CurrentModel(); got != "gemini-test" {
+		t.Errorf("CurrentModel() = %q, want %q", got, "gemini-test")
+	}
+}
+
+func TestCitedRowsOnlyAcceptsContextRows(t *testing.T) {
+	answer := "See [row 3] and row 7; row 99 is made up, and row 3 repeats."
+	cited := citedRows(answer, []uint{3, 7})
+	if len(cited) != 2 || cited[0] != 3 || cited[1] != 7 {
+		t.Errorf("citedRows = %v, want [3 7]", cited)
+	}
+}
+
+func TestBuildContextFiltersAndRedacts(t *testing.T) {
+	s := newTestService(&config.Config{ChatRedactedColumns: []string{"Birth Date"}}, llm.NewLocalProvider())
+	rows := []models.FileData{
+		{ID: 1, RowData: mustRow(t, map[string]string{"Name": "A", "Community": "Moose Factory", "Birth Date": "1901-02-03"})},
+		{ID: 2, RowData: mustRow(t, map[string]string{"Name": "B", "Community": "Elsewhere"})},
+		{ID: 3, RowData: mustRow(t, map[string]string{"Name": "C"})},
+	}
+
+	red := s.newRedactor()
+	section, included := s.buildContext(rows, "User", []string{"moose factory"}, 10, red)
+
+	// Row 2 belongs to another community; row 3 has no community column
+	// and stays visible to everyone.
+	if len(included) != 2 || included[0] != 1 || included[1] != 3 {
+		t.Fatalf("included = %v, want [1 3]", included)
+	}
+	if strings.Contains(section, "1901-02-03") {
+		t.Errorf("sensitive value leaked into context: %q", section)
+	}
+	if !strings.Contains(section, redactedPlaceholder) {
+		t.Errorf("placeholder missing from context: %q", section)
+	}
+	if got := red.scan("born 1901-02-03"); strings.Contains(got, "1901-02-03") {
+		t.Errorf("scan left scrubbed value in answer: %q", got)
+	}
+}
+
+func TestSynthesizeUsesLocalProvider(t *testing.T) {
+	oldRead, oldWrite := readTTSCacheHook, writeTTSCacheHook
+	defer func() { readTTSCacheHook, writeTTSCacheHook = oldRead, oldWrite }()
+
+	var wroteKey string
+	readTTSCacheHook = func(ctx context.Context, bucket, object string) ([]byte, error) {
+		return nil, errors.New("cache miss")
+	}
+	writeTTSCacheHook = func(ctx context.Context, bucket, object string, audio []byte) error {
+		wroteKey = object
+		return nil
+	}
+
+	s := newTestService(&config.Config{GCSBucket: "test-bucket"}, llm.NewLocalProvider())
+	if _, err := s.Synthesize(context.Background(), TTSRequest{Text: "hello"}); err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	if !strings.HasPrefix(wroteKey, ttsCachePrefix) {
+		t.Errorf("cache key = %q, want %s prefix", wroteKey, ttsCachePrefix)
+	}
+
+	if _, err := s.Synthesize(context.Background(), TTSRequest{Text: "hello", Voice: "not-a-voice"}); err == nil {
+		t.Error("unknown voice accepted")
+	}
+	if _, err := s.Synthesize(context.Background(), TTSRequest{Text: "hello", Speed: 3}); err == nil {
+		t.Error("out-of-range speed accepted")
+	}
+}
+
+func TestSynthesizeServesCachedAudio(t *testing.T) {
+	oldRead := readTTSCacheHook
+	defer func() { readTTSCacheHook = oldRead }()
+
+	cached := []byte("mp3 bytes")
+	readTTSCacheHook = func(ctx context.Context, bucket, object string) ([]byte, error) {
+		return cached, nil
+	}
+
+	s := newTestService(&config.Config{GCSBucket: "test-bucket"}, llm.NewLocalProvider())
+	audio, err := s.Synthesize(context.Background(), TTSRequest{Text: "hello"})
+	if err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	if string(audio) != string(cached) {
+		t.Errorf("audio = %q, want cached bytes", audio)
+	}
+}
+
+func mustRow(t *testing.T, row map[string]string) datatypes.JSON {
+	t.Helper()
+	data, err := json.Marshal(row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,101 @@
+package chat
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var ErrNotEthicsReviewer = errors.New("transcript export requires the ethics reviewer capability")
+
+// recordTranscript keeps the Q&A pair for research-governance review.
+// Failures are logged and swallowed, like usage bookkeeping: governance
+// records must not eat a successful answer.
+func (s *ChatService) recordTranscript(userID uint, sources []string, question, answer string) {
+	transcript := models.ChatTranscript{
+		UserID:   userID,
+		File:     strings.Join(sources, ","),
+		Question: question,
+		Answer:   answer,
+	}
+	if err := s.DB.Create(&transcript).Error; err != nil {
+		log.Printf("chat: failed to record transcript for user %d: %v", userID, err)
+	}
+}
+
+// ensureEthicsReviewer checks the requester's ethics_reviewer flag. The
+// admin group already gates the route by role; this is the second key.
+func (s *ChatService) ensureEthicsReviewer(userID uint) error {
+	var user models.Auth
+	if err := s.DB.Select("ethics_reviewer").First(&user, userID).Error; err != nil {
+		return err
+	}
+	if !user.EthicsReviewer {
+		return ErrNotEthicsReviewer
+	}
+	return nil
+}
+
+// participantPseudonym replaces a user ID with a stable opaque label so
+// reviewers can follow one participant across rows without learning who
+// they are. Keyed on the JWT secret so the mapping can't be rebuilt
+// from the export alone.
+func (s *ChatService) participantPseudonym(userID uint) string {
+	mac := hmac.New(sha256.New, []byte(s.Config.JWTSecret))
+	fmt.Fprintf(mac, "transcript:%d", userID)
+	return "participant-" + hex.EncodeToString(mac.Sum(nil))[:12]
+}
+
+// ExportTranscriptsCSV builds the anonymized transcript CSV for ethics
+// review: timestamp, pseudonymous participant, files referenced, and
+// the Q&A pair. filterUser narrows to one user when non-zero; the
+// export still shows only their pseudonym.
+func (s *ChatService) ExportTranscriptsCSV(from, to time.Time, filterUser uint, requesterID uint) ([]byte, error) {
+	if err := s.ensureEthicsReviewer(requesterID); err != nil {
+		return nil, err
+	}
+
+	q := s.DB.Model(&models.ChatTranscript{}).Order("created_at")
+	if !from.IsZero() {
+		q = q.Where("created_at >= ?", from)
+	}
+	if !to.IsZero() {
+		q = q.Where("created_at <= ?", to)
+	}
+	if filterUser != 0 {
+		q = q.Where("user_id = ?", filterUser)
+	}
+	var transcripts []models.ChatTranscript
+	if err := q.Find(&transcripts).Error; err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	_ = cw.Write([]string{"Timestamp", "Participant", "Files", "Question", "Answer"})
+	for _, t := range transcripts {
+		_ = cw.Write([]string{
+			t.CreatedAt.Format(time.RFC3339),
+			s.participantPseudonym(t.UserID),
+			t.File,
+			t.Question,
+			t.Answer,
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return nil, err
+	}
+	s.Logs.Record(requesterID, "EXPORT_TRANSCRIPTS",
+		fmt.Sprintf("rows=%d filtered=%v", len(transcripts), filterUser != 0))
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,89 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/gcs"
+	"github.com/datanorthnordik/nordikdriveapi/internal/llm"
+)
+
+// ttsCachePrefix is where synthesized audio is cached in GCS so repeated
+// playback of the same answer doesn't re-bill Vertex.
+const ttsCachePrefix = "tts-cache/"
+
+// allowedVoices maps each selectable voice to its language code.
+var allowedVoices = map[string]string{
+	"en-US-Neural2-F": "en-US",
+	"en-US-Neural2-D": "en-US",
+	"fr-CA-Neural2-A": "fr-CA",
+	"fr-CA-Neural2-B": "fr-CA",
+}
+
+// TTSRequest selects what to synthesize and how it should sound.
+type TTSRequest struct {
+	Text  string  `json:"text" binding:"required"`
+	Voice string  `json:"voice"`
+	Speed float64 `json:"speed"`
+}
+
+// Hooks so the cache can be exercised without a real GCS client.
+var (
+	readTTSCacheHook  = readTTSCache
+	writeTTSCacheHook = writeTTSCache
+)
+
+// Synthesize validates the voice selection, serves cached audio for
+// identical (text, voice, speed) tuples, and synthesizes + caches on a
+// miss.
+func (s *ChatService) Synthesize(ctx context.Context, req TTSRequest) ([]byte, error) {
+	if req.Voice == "" {
+		req.Voice = "en-US-Neural2-F"
+	}
+	language, ok := allowedVoices[req.Voice]
+	if !ok {
+		return nil, fmt.Errorf("voice %q is not available", req.Voice)
+	}
+	if req.Speed == 0 {
+		req.Speed = 1.0
+	}
+	if req.Speed < 0.5 || req.Speed > 2.0 {
+		return nil, errors.New("speed must be between 0.5 and 2.0")
+	}
+
+	key := fmt.Sprintf("%s%x.mp3", ttsCachePrefix,
+		sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%.2f", req.Text, req.Voice, req.Speed))))
+	if audio, err := readTTSCacheHook(ctx, s.Config.GCSBucket, key); err == nil {
+		return audio, nil
+	}
+
+	audio, err := s.Provider.TTS(ctx, req.Text, llm.TTSOptions{
+		Voice:        req.Voice,
+		LanguageCode: language,
+		SpeakingRate: req.Speed,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// A failed cache write only costs the next playback a re-synthesis.
+	_ = writeTTSCacheHook(ctx, s.Config.GCSBucket, key, audio)
+	return audio, nil
+}
+
+// TTS synthesizes text with the default voice; kept for callers that
+// predate voice selection.
+func (s *ChatService) TTS(ctx context.Context, text string) ([]byte, error) {
+	return s.Synthesize(ctx, TTSRequest{Text: text})
+}
+
+func readTTSCache(ctx context.Context, bucket, object string) ([]byte, error) {
+	return gcs.Default.Download(ctx, bucket, object)
+}
+
+func writeTTSCache(ctx context.Context, bucket, object string, audio []byte) error {
+	_, err := gcs.Default.Upload(ctx, bucket, object, bytes.NewReader(audio), "audio/mpeg")
+	return err
+}
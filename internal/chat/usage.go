@@ -0,0 +1,87 @@
+package chat
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/llm"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var ErrQuotaExceeded = errors.New("monthly chat token quota exceeded")
+
+// recordUsage persists the token counts Gemini reported for one call.
+// Failures are logged and swallowed: billing bookkeeping must not eat a
+// successful answer.
+func (s *ChatService) recordUsage(userID uint, sources []string, reported *llm.Usage) {
+	if reported == nil {
+		return
+	}
+	usage := models.ChatUsage{
+		UserID:         userID,
+		File:           strings.Join(sources, ","),
+		PromptTokens:   reported.PromptTokens,
+		ResponseTokens: reported.ResponseTokens,
+	}
+	if err := s.DB.Create(&usage).Error; err != nil {
+		log.Printf("chat: failed to record usage for user %d: %v", userID, err)
+	}
+}
+
+// checkQuota returns ErrQuotaExceeded when the user has spent their
+// configured monthly token budget. Admins are not exempt: runaway spend
+// is runaway spend.
+func (s *ChatService) checkQuota(userID uint) error {
+	quota := s.Config.ChatMonthlyTokenQuota
+	if quota <= 0 {
+		return nil
+	}
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	var spent int64
+	err := s.DB.Model(&models.ChatUsage{}).
+		Where("user_id = ? AND created_at >= ?", userID, monthStart).
+		Select("COALESCE(SUM(prompt_tokens + response_tokens), 0)").
+		Scan(&spent).Error
+	if err != nil {
+		return err
+	}
+	if spent >= int64(quota) {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// UsageSummary is one user's token spend over a period.
+type UsageSummary struct {
+	UserID         uint   `json:"user_id"`
+	Email          string `json:"email"`
+	Calls          int64  `json:"calls"`
+	PromptTokens   int64  `json:"prompt_tokens"`
+	ResponseTokens int64  `json:"response_tokens"`
+}
+
+// UsageReport aggregates chat usage per user between from and to.
+func (s *ChatService) UsageReport(from, to time.Time) ([]UsageSummary, error) {
+	q := s.DB.Table("chat_usage").
+		Joins("LEFT JOIN users ON users.id = chat_usage.user_id").
+		Select(`chat_usage.user_id, users.email, COUNT(*) AS calls,
+			COALESCE(SUM(chat_usage.prompt_tokens), 0) AS prompt_tokens,
+			COALESCE(SUM(chat_usage.response_tokens), 0) AS response_tokens`).
+		Group("chat_usage.user_id, users.email").
+		Order("prompt_tokens + response_tokens DESC")
+	if !from.IsZero() {
+		q = q.Where("chat_usage.created_at >= ?", from)
+	}
+	if !to.IsZero() {
+		q = q.Where("chat_usage.created_at <= ?", to)
+	}
+	var summaries []UsageSummary
+	if err := q.Scan(&summaries).Error; err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
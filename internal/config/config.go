@@ -0,0 +1,197 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Config holds every runtime setting the API reads from the environment.
+// All values are loaded once at startup; services receive the struct by
+// pointer and must not mutate it.
+type Config struct {
+	Port        string
+	DatabaseURL string
+
+	JWTSecret          string
+	AccessTokenMinutes int
+	RefreshTokenHours  int
+
+	GCSBucket   string
+	GCPProject  string
+	GCPLocation string
+
+	// LLMProvider selects the chat backend: "vertex" (default) or
+	// "local" for a hermetic mock.
+	LLMProvider string
+	// GeminiModel is the boot-time default model; admins can switch it
+	// at runtime via /api/admin/chat/model when Google deprecates a
+	// version.
+	GeminiModel string
+	// GeminiTemperature and GeminiMaxOutputTokens tune generation; the
+	// zero MaxOutputTokens leaves the platform default. Requests may
+	// override both within validated bounds.
+	GeminiTemperature     float64
+	GeminiMaxOutputTokens int
+	// GeminiSafetyThreshold picks the harm-blocking level: "none",
+	// "only_high", "medium_and_above" or "low_and_above". Empty leaves
+	// the platform default.
+	GeminiSafetyThreshold string
+	// ChatMonthlyTokenQuota caps each user's combined prompt+response
+	// tokens per calendar month; 0 means unlimited.
+	ChatMonthlyTokenQuota int
+	// ChatRedactedColumns names dataset columns whose values are
+	// scrubbed from chat prompts and scanned for in answers (exact
+	// birth dates, ID numbers, ...). Matching is case-insensitive.
+	ChatRedactedColumns []string
+
+	// MaxEditUploadBytes caps the decoded size of one base64 photo or
+	// document in an edit request; MaxEditUploadTotalBytes caps the
+	// decoded size of all uploads in one request combined.
+	MaxEditUploadBytes      int
+	MaxEditUploadTotalBytes int
+
+	// ExportMaxRows caps how many rows one streamed updates export may
+	// emit; 0 means unlimited.
+	ExportMaxRows int
+
+	// HeavyConcurrency caps how many expensive requests (upload parsing,
+	// ZIP exports, chat completions) run at once; requests beyond the cap
+	// get 429 with a Retry-After hint. 0 disables the limiter.
+	HeavyConcurrency int
+
+	// MaintenanceMode starts the API read-only; admins can flip it at
+	// runtime via /api/admin/maintenance.
+	MaintenanceMode bool
+
+	// StartupSelfCheck verifies required tables and bucket access at
+	// boot and refuses to start when anything is missing.
+	StartupSelfCheck bool
+
+	// EditRequestStaleDays, when positive, marks pending edit requests
+	// untouched for this many days as stale.
+	EditRequestStaleDays int
+
+	// VersionArchiveDays, when positive, moves the rows of superseded
+	// file versions older than this many days to compressed NDJSON in
+	// GCS; reads of archived versions rehydrate on demand.
+	VersionArchiveDays int
+
+	// LogRetentionDays, when positive, archives log rows older than this
+	// many days to LogArchiveBucket and deletes them from Postgres.
+	LogRetentionDays int
+	LogArchiveBucket string
+
+	SMTPHost string
+	SMTPPort int
+	SMTPUser string
+	SMTPPass string
+	MailFrom string
+	// MailDryRunDir, when set, writes rendered emails to this directory
+	// instead of sending them, for reviewing templates locally.
+	MailDryRunDir string
+	// AlertEmail receives account-compromise alerts (new-country logins,
+	// successes after a burst of failures). Empty disables alerting.
+	AlertEmail string
+
+	// PublicBaseURL is the externally reachable origin of the API, used
+	// to build links in outgoing emails (e.g. anonymous submission
+	// verification).
+	PublicBaseURL string
+
+	// DigestIntervalHours, when positive, emails admins a periodic digest
+	// of pending review work every this many hours (24 for daily, 168 for
+	// weekly). 0 disables the digest.
+	DigestIntervalHours int
+
+	// StoreOriginals archives the raw bytes of uploaded spreadsheets to
+	// GCS under originals/<file_id>/<version>/ so the pre-normalization
+	// source can be audited or re-downloaded.
+	StoreOriginals bool
+}
+
+// Load reads the configuration from the environment, applying defaults for
+// optional values and failing fast when a required value is missing.
+func Load() (*Config, error) {
+	cfg := &Config{
+		Port:                    getenv("PORT", "8080"),
+		DatabaseURL:             os.Getenv("DATABASE_URL"),
+		JWTSecret:               os.Getenv("JWT_SECRET"),
+		AccessTokenMinutes:      getenvInt("ACCESS_TOKEN_MINUTES", 15),
+		RefreshTokenHours:       getenvInt("REFRESH_TOKEN_HOURS", 24*7),
+		GCSBucket:               os.Getenv("GCS_BUCKET"),
+		GCPProject:              os.Getenv("GCP_PROJECT"),
+		GCPLocation:             getenv("GCP_LOCATION", "northamerica-northeast1"),
+		LLMProvider:             getenv("LLM_PROVIDER", "vertex"),
+		GeminiModel:             getenv("GEMINI_MODEL", "gemini-1.5-flash"),
+		GeminiTemperature:       getenvFloat("GEMINI_TEMPERATURE", 0.2),
+		GeminiMaxOutputTokens:   getenvInt("GEMINI_MAX_OUTPUT_TOKENS", 0),
+		GeminiSafetyThreshold:   getenv("GEMINI_SAFETY_THRESHOLD", ""),
+		ChatMonthlyTokenQuota:   getenvInt("CHAT_MONTHLY_TOKEN_QUOTA", 0),
+		ChatRedactedColumns:     getenvList("CHAT_REDACTED_COLUMNS"),
+		MaxEditUploadBytes:      getenvInt("MAX_EDIT_UPLOAD_BYTES", 10<<20),
+		MaxEditUploadTotalBytes: getenvInt("MAX_EDIT_UPLOAD_TOTAL_BYTES", 50<<20),
+		ExportMaxRows:           getenvInt("EXPORT_MAX_ROWS", 500000),
+		HeavyConcurrency:        getenvInt("HEAVY_CONCURRENCY", 4),
+		EditRequestStaleDays:    getenvInt("EDIT_REQUEST_STALE_DAYS", 90),
+		MaintenanceMode:         os.Getenv("MAINTENANCE_MODE") == "true",
+		StartupSelfCheck:        os.Getenv("STARTUP_SELF_CHECK") == "true",
+		VersionArchiveDays:      getenvInt("VERSION_ARCHIVE_DAYS", 0),
+		LogRetentionDays:        getenvInt("LOG_RETENTION_DAYS", 0),
+		LogArchiveBucket:        getenv("LOG_ARCHIVE_BUCKET", os.Getenv("GCS_BUCKET")),
+		SMTPHost:                os.Getenv("SMTP_HOST"),
+		SMTPPort:                getenvInt("SMTP_PORT", 587),
+		SMTPUser:                os.Getenv("SMTP_USER"),
+		SMTPPass:                os.Getenv("SMTP_PASS"),
+		MailFrom:                getenv("MAIL_FROM", "noreply@nordikdrive.ca"),
+		MailDryRunDir:           os.Getenv("MAIL_DRY_RUN_DIR"),
+		AlertEmail:              os.Getenv("ALERT_EMAIL"),
+		PublicBaseURL:           os.Getenv("PUBLIC_BASE_URL"),
+		DigestIntervalHours:     getenvInt("DIGEST_INTERVAL_HOURS", 0),
+		StoreOriginals:          os.Getenv("STORE_ORIGINAL_UPLOADS") == "true",
+	}
+	if cfg.DatabaseURL == "" {
+		return nil, fmt.Errorf("DATABASE_URL is required")
+	}
+	if cfg.JWTSecret == "" {
+		return nil, fmt.Errorf("JWT_SECRET is required")
+	}
+	return cfg, nil
+}
+
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func getenvInt(key string, fallback int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+// getenvList reads a comma-separated list, dropping empty entries.
+func getenvList(key string) []string {
+	var values []string
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+func getenvFloat(key string, fallback float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return fallback
+}
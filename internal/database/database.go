@@ -0,0 +1,120 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+// Connect opens the Postgres connection and runs the auto-migrations for
+// every model the API owns.
+func Connect(dsn string) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("connect postgres: %w", err)
+	}
+
+	if err := db.AutoMigrate(
+		&models.Auth{},
+		&models.Role{},
+		&models.File{},
+		&models.FileVersion{},
+		&models.FileData{},
+		&models.FileSnapshot{},
+		&models.FileComment{},
+		&models.CellAnnotation{},
+		&models.FileAccess{},
+		&models.FileEditRequest{},
+		&models.FileEditRequestDetail{},
+		&models.FileEditRequestPhoto{},
+		&models.FileEditRequestDocument{},
+		&models.FormDefinition{},
+		&models.FormSubmission{},
+		&models.FormSubmissionDetail{},
+		&models.FormSubmissionUpload{},
+		&models.RowClaim{},
+		&models.ComputedColumn{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.LinkRule{},
+		&models.RecordLink{},
+		&models.ChatUsage{},
+		&models.ChatTranscript{},
+		&models.DocumentCategory{},
+		&models.LoginEvent{},
+		&models.SystemLog{},
+		&models.LogArchive{},
+		&models.OTP{},
+	); err != nil {
+		return nil, fmt.Errorf("auto-migrate: %w", err)
+	}
+	if err := migrateFileDataJSONB(db); err != nil {
+		return nil, err
+	}
+	if err := createTrigramIndexes(db); err != nil {
+		return nil, err
+	}
+	// Grants created before permission levels existed default to viewer.
+	if err := db.Exec("UPDATE file_access SET permission = 'viewer' WHERE permission IS NULL OR permission = ''").Error; err != nil {
+		return nil, fmt.Errorf("backfill file_access permissions: %w", err)
+	}
+	return db, nil
+}
+
+// migrateFileDataJSONB converts file_data.row_data from json to jsonb so
+// row filters (community export, aggregation, search) run as indexed
+// JSONB operators instead of table scans, then creates a GIN index over
+// the whole document plus expression indexes on the community columns
+// partner spreadsheets are most often filtered by.
+func migrateFileDataJSONB(db *gorm.DB) error {
+	var dataType string
+	if err := db.Raw("SELECT data_type FROM information_schema.columns WHERE table_name = 'file_data' AND column_name = 'row_data'").
+		Scan(&dataType).Error; err != nil {
+		return fmt.Errorf("inspect file_data.row_data: %w", err)
+	}
+	if dataType != "" && dataType != "jsonb" {
+		if err := db.Exec("ALTER TABLE file_data ALTER COLUMN row_data TYPE jsonb USING row_data::jsonb").Error; err != nil {
+			return fmt.Errorf("migrate row_data to jsonb: %w", err)
+		}
+	}
+	statements := []string{
+		"CREATE INDEX IF NOT EXISTS idx_file_data_row_data_gin ON file_data USING gin (row_data jsonb_path_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_file_data_community ON file_data ((row_data ->> 'Community'))",
+		"CREATE INDEX IF NOT EXISTS idx_file_data_first_nation ON file_data ((row_data ->> 'First Nation'))",
+		"CREATE INDEX IF NOT EXISTS idx_file_data_band ON file_data ((row_data ->> 'Band'))",
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("create file_data index: %w", err)
+		}
+	}
+	return nil
+}
+
+// createTrigramIndexes backs the admin free-text change search. pg_trgm
+// may be unavailable on locked-down instances, in which case the search
+// still works, just without index support.
+func createTrigramIndexes(db *gorm.DB) error {
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		log.Printf("database: pg_trgm unavailable, free-text search will be unindexed: %v", err)
+		return nil
+	}
+	statements := []string{
+		"CREATE INDEX IF NOT EXISTS idx_edit_details_old_value_trgm ON file_edit_request_details USING gin (old_value gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_edit_details_new_value_trgm ON file_edit_request_details USING gin (new_value gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_edit_details_field_name_trgm ON file_edit_request_details USING gin (field_name gin_trgm_ops)",
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("create trigram index: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/gcs"
+)
+
+// requiredTables is every table the services query. FileVersion and Role
+// keep gorm's default pluralized names; the rest override TableName.
+var requiredTables = []string{
+	"users",
+	"roles",
+	"file",
+	"file_versions",
+	"file_data",
+	"file_access",
+	"file_edit_request",
+	"file_edit_request_details",
+	"file_edit_request_photos",
+	"file_edit_request_documents",
+	"form_submission",
+	"form_definitions",
+	"form_submission_details",
+	"form_submission_uploads",
+	"row_claims",
+	"computed_columns",
+	"notifications",
+	"notification_preferences",
+	"link_rules",
+	"record_links",
+	"chat_usage",
+	"logs",
+	"log_archives",
+	"otps",
+}
+
+// SelfCheck verifies at boot that every table the services query exists
+// and that the GCS bucket is reachable, logging a precise list of what
+// is missing instead of letting requests fail later with opaque runtime
+// errors. An empty bucket name skips the GCS check.
+func SelfCheck(ctx context.Context, db *gorm.DB, bucket string) error {
+	var missing []string
+	for _, table := range requiredTables {
+		var regclass *string
+		if err := db.Raw("SELECT to_regclass(?)", table).Scan(&regclass).Error; err != nil {
+			return fmt.Errorf("self-check: inspect table %s: %w", table, err)
+		}
+		if regclass == nil {
+			missing = append(missing, "table "+table)
+		}
+	}
+
+	if bucket != "" {
+		client, err := gcs.NewRawClientHook(ctx)
+		if err != nil {
+			missing = append(missing, fmt.Sprintf("gcs client (%v)", err))
+		} else {
+			if _, err := client.Bucket(bucket).Attrs(ctx); err != nil {
+				missing = append(missing, fmt.Sprintf("gcs bucket %s (%v)", bucket, err))
+			}
+			client.Close()
+		}
+	}
+
+	if len(missing) > 0 {
+		for _, m := range missing {
+			log.Printf("self-check: missing %s", m)
+		}
+		return fmt.Errorf("self-check: %d dependencies missing: %s", len(missing), strings.Join(missing, "; "))
+	}
+	log.Printf("self-check: %d tables and bucket access verified", len(requiredTables))
+	return nil
+}
@@ -0,0 +1,147 @@
+package file
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var ErrInvalidAggregate = errors.New("invalid aggregate query")
+
+const (
+	aggregateMaxGroupBy     = 3
+	aggregateDefaultBuckets = 100
+	aggregateMaxBuckets     = 1000
+)
+
+// AggregateRequest describes a grouped count over a file's current
+// version. Filters are equality matches on column values.
+type AggregateRequest struct {
+	GroupBy []string `json:"group_by" binding:"required"`
+	// Func is "count" (default) or "distinct_count"; the latter counts
+	// distinct values of the Distinct column per bucket.
+	Func     string            `json:"func"`
+	Distinct string            `json:"distinct"`
+	Filters  map[string]string `json:"filters"`
+	Limit    int               `json:"limit"`
+}
+
+// AggregateBucket is one result group; Keys line up with the requested
+// group-by columns.
+type AggregateBucket struct {
+	Keys  []string `json:"keys"`
+	Value int64    `json:"value"`
+}
+
+// Aggregate runs a grouped count over the file's current version using
+// Postgres JSONB operators so rows never leave the database, e.g. deaths
+// per community per decade for dashboards.
+func (s *FileService) Aggregate(fileID uint, req AggregateRequest, userID uint, role string) ([]AggregateBucket, error) {
+	var file models.File
+	if err := s.DB.First(&file, fileID).Error; err != nil {
+		return nil, ErrFileNotFound
+	}
+	if !s.CanAccessFile(&file, userID, role) {
+		return nil, ErrAccessDenied
+	}
+
+	var columns []string
+	if err := json.Unmarshal(file.ColumnsOrder, &columns); err != nil {
+		return nil, fmt.Errorf("decode columns order: %w", err)
+	}
+	known := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		known[c] = true
+	}
+
+	if len(req.GroupBy) == 0 || len(req.GroupBy) > aggregateMaxGroupBy {
+		return nil, fmt.Errorf("%w: between 1 and %d group-by columns required", ErrInvalidAggregate, aggregateMaxGroupBy)
+	}
+	for _, col := range req.GroupBy {
+		if !known[col] {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidAggregate, col)
+		}
+	}
+	for col := range req.Filters {
+		if !known[col] {
+			return nil, fmt.Errorf("%w: unknown filter column %q", ErrInvalidAggregate, col)
+		}
+	}
+
+	aggFunc := req.Func
+	if aggFunc == "" {
+		aggFunc = "count"
+	}
+	var valueExpr string
+	var valueArgs []interface{}
+	switch aggFunc {
+	case "count":
+		valueExpr = "COUNT(*)"
+	case "distinct_count":
+		if !known[req.Distinct] {
+			return nil, fmt.Errorf("%w: distinct_count requires a known distinct column", ErrInvalidAggregate)
+		}
+		valueExpr = "COUNT(DISTINCT row_data ->> ?)"
+		valueArgs = []interface{}{req.Distinct}
+	default:
+		return nil, fmt.Errorf("%w: unsupported func %q", ErrInvalidAggregate, aggFunc)
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = aggregateDefaultBuckets
+	}
+	if limit > aggregateMaxBuckets {
+		limit = aggregateMaxBuckets
+	}
+
+	selects := make([]string, 0, len(req.GroupBy)+1)
+	groups := make([]string, 0, len(req.GroupBy))
+	var args []interface{}
+	for i, col := range req.GroupBy {
+		selects = append(selects, fmt.Sprintf("row_data ->> ? AS k%d", i))
+		groups = append(groups, fmt.Sprintf("k%d", i))
+		args = append(args, col)
+	}
+	selects = append(selects, valueExpr+" AS value")
+	args = append(args, valueArgs...)
+
+	query := "SELECT " + strings.Join(selects, ", ") + " FROM file_data WHERE file_id = ? AND version = ?"
+	args = append(args, file.ID, file.Version)
+	for col, val := range req.Filters {
+		query += " AND row_data ->> ? = ?"
+		args = append(args, col, val)
+	}
+	query += " GROUP BY " + strings.Join(groups, ", ") + " ORDER BY value DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.DB.Raw(query, args...).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("aggregate: %w", err)
+	}
+	defer rows.Close()
+
+	buckets := make([]AggregateBucket, 0, limit)
+	for rows.Next() {
+		keys := make([]sql.NullString, len(req.GroupBy))
+		dest := make([]interface{}, 0, len(keys)+1)
+		for i := range keys {
+			dest = append(dest, &keys[i])
+		}
+		var value int64
+		dest = append(dest, &value)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		bucket := AggregateBucket{Keys: make([]string, len(keys)), Value: value}
+		for i, k := range keys {
+			bucket.Keys[i] = k.String
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets, rows.Err()
+}
@@ -0,0 +1,224 @@
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var ErrInvalidAnnotation = errors.New("invalid annotation")
+
+// Confidence levels an annotation may carry; empty means unrated.
+var annotationConfidences = map[string]bool{"": true, "low": true, "medium": true, "high": true}
+
+// storeImportAnnotations persists the sources detected during parsing
+// (Excel fill colors, see cellSourceLabel) against the freshly inserted
+// rows. rowIDs is the output of insertRows in sheet order, so the
+// sheet's row indexes map straight onto database IDs. Called inside the
+// insert transaction so an import is either fully annotated or not
+// saved at all.
+func storeImportAnnotations(tx *gorm.DB, fileID uint, rowIDs []uint, sheet *parsedSheet, userID uint) error {
+	if len(sheet.Annotations) == 0 {
+		return nil
+	}
+	batch := make([]models.CellAnnotation, 0, len(sheet.Annotations))
+	for rowIdx, cols := range sheet.Annotations {
+		if rowIdx < 0 || rowIdx >= len(rowIDs) {
+			continue
+		}
+		for column, source := range cols {
+			batch = append(batch, models.CellAnnotation{
+				FileID:    fileID,
+				RowID:     rowIDs[rowIdx],
+				Column:    column,
+				Source:    source,
+				CreatedBy: userID,
+			})
+		}
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(batch, insertBatchSize).Error
+}
+
+// StripSourceLabels removes legacy in-value source labels ("(NCTR
+// SOURCE)" etc.) from every cell of the file's current version,
+// recording each stripped label as an annotation so no provenance is
+// lost. One-time cleanup for files imported before strict mode existed;
+// admin only. Returns the number of cells changed.
+func (s *FileService) StripSourceLabels(fileID, userID uint) (int, error) {
+	var file models.File
+	if err := s.DB.First(&file, fileID).Error; err != nil {
+		return 0, ErrFileNotFound
+	}
+	stripped := 0
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var lastID uint
+		for {
+			var rows []models.FileData
+			if err := tx.Where("file_id = ? AND version = ? AND id > ?", fileID, file.Version, lastID).
+				Order("id").Limit(insertBatchSize).Find(&rows).Error; err != nil {
+				return err
+			}
+			if len(rows) == 0 {
+				return nil
+			}
+			for _, r := range rows {
+				lastID = r.ID
+				var row map[string]string
+				if err := json.Unmarshal(r.RowData, &row); err != nil {
+					return fmt.Errorf("decode row %d: %w", r.ID, err)
+				}
+				changed := false
+				for column, value := range row {
+					for _, label := range sourceLabels {
+						if !strings.HasSuffix(value, " "+label) {
+							continue
+						}
+						row[column] = strings.TrimSuffix(value, " "+label)
+						if err := upsertAnnotation(tx, fileID, r.ID, column, strings.Trim(label, "()"), userID); err != nil {
+							return err
+						}
+						changed = true
+						stripped++
+						break
+					}
+				}
+				if !changed {
+					continue
+				}
+				raw, err := json.Marshal(row)
+				if err != nil {
+					return err
+				}
+				if err := tx.Model(&models.FileData{}).Where("id = ?", r.ID).
+					Update("row_data", datatypes.JSON(raw)).Error; err != nil {
+					return err
+				}
+			}
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.Logs.Record(userID, "STRIP_SOURCE_LABELS", fmt.Sprintf("file=%d cells=%d", fileID, stripped))
+	return stripped, nil
+}
+
+// upsertAnnotation records a source for a cell unless one already
+// exists; an existing annotation (possibly reviewer-edited) wins.
+func upsertAnnotation(tx *gorm.DB, fileID, rowID uint, column, source string, userID uint) error {
+	var existing models.CellAnnotation
+	err := tx.Where("file_id = ? AND row_id = ? AND \"column\" = ?", fileID, rowID, column).
+		First(&existing).Error
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return tx.Create(&models.CellAnnotation{
+		FileID:    fileID,
+		RowID:     rowID,
+		Column:    column,
+		Source:    source,
+		CreatedBy: userID,
+	}).Error
+}
+
+// ListAnnotations returns every annotation on the file's current
+// version, enforcing private-file access.
+func (s *FileService) ListAnnotations(fileID, userID uint, role string) ([]models.CellAnnotation, error) {
+	var file models.File
+	if err := s.DB.First(&file, fileID).Error; err != nil {
+		return nil, ErrFileNotFound
+	}
+	if !s.CanAccessFile(&file, userID, role) {
+		return nil, ErrAccessDenied
+	}
+	var annotations []models.CellAnnotation
+	err := s.DB.Joins("JOIN file_data ON file_data.id = cell_annotations.row_id").
+		Where("cell_annotations.file_id = ? AND file_data.version = ?", fileID, file.Version).
+		Order("cell_annotations.row_id, cell_annotations.\"column\"").
+		Find(&annotations).Error
+	if err != nil {
+		return nil, err
+	}
+	return annotations, nil
+}
+
+// SetAnnotation creates, updates, or clears the annotation on one cell.
+// Contributor permission is required, matching comments and edit
+// requests. Passing an empty source and confidence deletes the
+// annotation.
+func (s *FileService) SetAnnotation(fileID, rowID uint, column, source, confidence string, userID uint, role string) (*models.CellAnnotation, error) {
+	var file models.File
+	if err := s.DB.First(&file, fileID).Error; err != nil {
+		return nil, ErrFileNotFound
+	}
+	if !s.HasFilePermission(&file, userID, role, PermissionContributor) {
+		return nil, ErrAccessDenied
+	}
+	if !annotationConfidences[confidence] {
+		return nil, fmt.Errorf("%w: confidence must be low, medium or high", ErrInvalidAnnotation)
+	}
+	var columns []string
+	_ = json.Unmarshal(file.ColumnsOrder, &columns)
+	known := false
+	for _, c := range columns {
+		if c == column {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidAnnotation, column)
+	}
+	var row models.FileData
+	if err := s.DB.Where("id = ? AND file_id = ?", rowID, fileID).First(&row).Error; err != nil {
+		return nil, ErrRowNotFound
+	}
+
+	if source == "" && confidence == "" {
+		if err := s.DB.Where("file_id = ? AND row_id = ? AND \"column\" = ?", fileID, rowID, column).
+			Delete(&models.CellAnnotation{}).Error; err != nil {
+			return nil, err
+		}
+		s.Logs.Record(userID, "SET_CELL_ANNOTATION", fmt.Sprintf("file=%d row=%d column=%s cleared", fileID, rowID, column))
+		return nil, nil
+	}
+
+	var annotation models.CellAnnotation
+	err := s.DB.Where("file_id = ? AND row_id = ? AND \"column\" = ?", fileID, rowID, column).
+		First(&annotation).Error
+	switch {
+	case err == nil:
+		updates := map[string]interface{}{"source": source, "confidence": confidence, "created_by": userID}
+		if err := s.DB.Model(&annotation).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		annotation = models.CellAnnotation{
+			FileID:     fileID,
+			RowID:      rowID,
+			Column:     column,
+			Source:     source,
+			Confidence: confidence,
+			CreatedBy:  userID,
+		}
+		if err := s.DB.Create(&annotation).Error; err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+	s.Logs.Record(userID, "SET_CELL_ANNOTATION", fmt.Sprintf("file=%d row=%d column=%s source=%s", fileID, rowID, column, source))
+	return &annotation, nil
+}
@@ -0,0 +1,208 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/gcs"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+	"github.com/datanorthnordik/nordikdriveapi/internal/util"
+)
+
+const versionArchivePrefix = "version-archives/"
+
+// Hooks so archival can run without a real GCS client in tests.
+var (
+	uploadVersionArchiveHook = uploadVersionArchive
+	readVersionArchiveHook   = readVersionArchive
+)
+
+// ArchiveOldVersions exports the rows of superseded file versions older
+// than olderThanDays to compressed NDJSON in GCS and deletes them from
+// file_data, recording the archive location on the FileVersion so the
+// rows can be rehydrated on demand. Returns how many versions were
+// archived.
+func (s *FileService) ArchiveOldVersions(ctx context.Context, olderThanDays int) (int, error) {
+	if olderThanDays <= 0 {
+		return 0, fmt.Errorf("archive days must be positive")
+	}
+	cutoff := time.Now().AddDate(0, 0, -olderThanDays)
+
+	var candidates []models.FileVersion
+	if err := s.DB.
+		Joins("JOIN file ON file.id = file_versions.file_id").
+		Where("file_versions.version < file.version").
+		Where("file_versions.created_at < ?", cutoff).
+		Where("file_versions.archived_at IS NULL").
+		Find(&candidates).Error; err != nil {
+		return 0, err
+	}
+
+	archived := 0
+	for _, fv := range candidates {
+		if err := s.archiveVersion(ctx, fv); err != nil {
+			return archived, fmt.Errorf("archive file %d v%d: %w", fv.FileID, fv.Version, err)
+		}
+		archived++
+	}
+	return archived, nil
+}
+
+// archiveVersion moves one version's rows to GCS and deletes them.
+func (s *FileService) archiveVersion(ctx context.Context, fv models.FileVersion) error {
+	var rows []models.FileData
+	if err := s.DB.Where("file_id = ? AND version = ?", fv.FileID, fv.Version).
+		Order("id").Find(&rows).Error; err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(gz)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return fmt.Errorf("encode row %d: %w", row.ID, err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	object := fmt.Sprintf("%sfile_%d_v%d_%s.ndjson.gz",
+		versionArchivePrefix, fv.FileID, fv.Version, time.Now().Format("20060102T150405"))
+	if err := uploadVersionArchiveHook(ctx, s.Config.GCSBucket, object, buf.Bytes()); err != nil {
+		return fmt.Errorf("upload archive: %w", err)
+	}
+
+	now := time.Now()
+	if err := s.DB.Model(&models.FileVersion{}).Where("id = ?", fv.ID).
+		Updates(map[string]interface{}{
+			"archive_url": "gs://" + s.Config.GCSBucket + "/" + object,
+			"archived_at": now,
+		}).Error; err != nil {
+		return err
+	}
+	if err := s.DB.Where("file_id = ? AND version = ?", fv.FileID, fv.Version).
+		Delete(&models.FileData{}).Error; err != nil {
+		return fmt.Errorf("delete archived rows: %w", err)
+	}
+	s.Logs.Record(0, "ARCHIVE_FILE_VERSION",
+		fmt.Sprintf("file=%d version=%d rows=%d", fv.FileID, fv.Version, len(rows)))
+	return nil
+}
+
+// rehydrateVersion loads an archived version's rows back into file_data
+// so an old-version read works transparently. The archived object stays
+// in GCS; clearing archived_at makes the version eligible for the next
+// archive pass again. The bool reports whether an archived version
+// existed at all.
+func (s *FileService) rehydrateVersion(ctx context.Context, fileID uint, version int) ([]models.FileData, bool, error) {
+	var fv models.FileVersion
+	err := s.DB.Where("file_id = ? AND version = ? AND archived_at IS NOT NULL", fileID, version).
+		First(&fv).Error
+	if err != nil {
+		return nil, false, nil
+	}
+
+	data, err := readVersionArchiveHook(ctx, fv.ArchiveURL)
+	if err != nil {
+		return nil, true, fmt.Errorf("read %s: %w", fv.ArchiveURL, err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, true, fmt.Errorf("decompress %s: %w", fv.ArchiveURL, err)
+	}
+	defer gz.Close()
+
+	var rows []models.FileData
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		var row models.FileData
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, true, err
+	}
+
+	// Rows keep their original IDs so edit requests and claims that
+	// reference them stay valid.
+	if err := s.DB.CreateInBatches(rows, insertBatchSize).Error; err != nil {
+		return nil, true, fmt.Errorf("rehydrate rows: %w", err)
+	}
+	if err := s.DB.Model(&models.FileVersion{}).Where("id = ?", fv.ID).
+		Update("archived_at", nil).Error; err != nil {
+		return nil, true, err
+	}
+	log.Printf("file: rehydrated %d rows for file %d v%d", len(rows), fileID, version)
+	return rows, true, nil
+}
+
+// ErrVersionNotArchived is returned when a restore targets a version
+// that has no archived rows to pull back.
+var ErrVersionNotArchived = errors.New("version is not archived")
+
+// RestoreVersion pulls an archived version's rows back into file_data
+// ahead of need, so reads and reverts against very old versions work
+// without paying the rehydration cost on the request path.
+func (s *FileService) RestoreVersion(ctx context.Context, fileID uint, version int, adminID uint) (int, error) {
+	var file models.File
+	if err := s.DB.First(&file, fileID).Error; err != nil {
+		return 0, ErrFileNotFound
+	}
+	rows, found, err := s.rehydrateVersion(ctx, fileID, version)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, ErrVersionNotArchived
+	}
+	s.Logs.Record(adminID, "RESTORE_FILE_VERSION",
+		fmt.Sprintf("file=%d version=%d rows=%d", fileID, version, len(rows)))
+	return len(rows), nil
+}
+
+// RunVersionArchiveLoop archives superseded versions once a day until
+// ctx is cancelled. Started from the server container when configured.
+func (s *FileService) RunVersionArchiveLoop(ctx context.Context, olderThanDays int) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+	for {
+		if archived, err := s.ArchiveOldVersions(ctx, olderThanDays); err != nil {
+			log.Printf("file: version archive pass failed: %v", err)
+		} else if archived > 0 {
+			log.Printf("file: archived %d superseded versions older than %d days", archived, olderThanDays)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func uploadVersionArchive(ctx context.Context, bucket, object string, data []byte) error {
+	_, err := gcs.Default.Upload(ctx, bucket, object, bytes.NewReader(data), "application/gzip")
+	return err
+}
+
+func readVersionArchive(ctx context.Context, gsURL string) ([]byte, error) {
+	bucket, object, err := util.ParseGSURL(gsURL)
+	if err != nil {
+		return nil, err
+	}
+	return gcs.Default.Download(ctx, bucket, object)
+}
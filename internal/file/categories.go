@@ -0,0 +1,93 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var (
+	ErrUnknownCategory  = errors.New("unknown document category")
+	ErrCategoryExists   = errors.New("document category already exists")
+	ErrCategoryNotFound = errors.New("document category not found")
+)
+
+// ListDocumentCategories returns the admin-managed category labels,
+// alphabetically.
+func (s *FileService) ListDocumentCategories() ([]models.DocumentCategory, error) {
+	var categories []models.DocumentCategory
+	if err := s.DB.Order("name").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// AddDocumentCategory creates a category label.
+func (s *FileService) AddDocumentCategory(name string, adminID uint) (*models.DocumentCategory, error) {
+	name = strings.TrimSpace(name)
+	var existing models.DocumentCategory
+	if err := s.DB.Where("name = ?", name).First(&existing).Error; err == nil {
+		return nil, ErrCategoryExists
+	}
+	category := models.DocumentCategory{Name: name, CreatedBy: adminID}
+	if err := s.DB.Create(&category).Error; err != nil {
+		return nil, fmt.Errorf("create document category: %w", err)
+	}
+	s.Logs.Record(adminID, "ADD_DOCUMENT_CATEGORY", name)
+	return &category, nil
+}
+
+// DeleteDocumentCategory removes a category label. Existing documents
+// keep the label they were filed under.
+func (s *FileService) DeleteDocumentCategory(categoryID uint, adminID uint) error {
+	var category models.DocumentCategory
+	if err := s.DB.First(&category, categoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCategoryNotFound
+		}
+		return err
+	}
+	if err := s.DB.Delete(&category).Error; err != nil {
+		return err
+	}
+	s.Logs.Record(adminID, "DELETE_DOCUMENT_CATEGORY", category.Name)
+	return nil
+}
+
+// validateDocumentCategories rejects uploads filed under a label the
+// admins have not defined. While no categories are configured the check
+// is a no-op, so existing deployments keep their free-text behaviour;
+// an empty category stays allowed either way for uncategorized scans.
+func (s *FileService) validateDocumentCategories(documents []EditRequestUpload) error {
+	named := false
+	for _, doc := range documents {
+		if doc.DocumentCategory != "" {
+			named = true
+			break
+		}
+	}
+	if !named {
+		return nil
+	}
+	categories, err := s.ListDocumentCategories()
+	if err != nil {
+		return err
+	}
+	if len(categories) == 0 {
+		return nil
+	}
+	valid := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		valid[c.Name] = true
+	}
+	for _, doc := range documents {
+		if doc.DocumentCategory != "" && !valid[doc.DocumentCategory] {
+			return fmt.Errorf("%w: %q", ErrUnknownCategory, doc.DocumentCategory)
+		}
+	}
+	return nil
+}
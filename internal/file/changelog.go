@@ -0,0 +1,128 @@
+package file
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+// ChangelogEntry is one link in a file's hash-chained history. Hash
+// covers the entry payload plus the previous entry's hash, so altering
+// or removing any historical change breaks every later hash.
+type ChangelogEntry struct {
+	Seq       int       `json:"seq"`
+	Type      string    `json:"type"` // "version" or "edit_request"
+	Ref       uint      `json:"ref"`
+	Timestamp time.Time `json:"timestamp"`
+	Summary   string    `json:"summary"`
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `json:"hash"`
+}
+
+// ChangelogVerification reports whether a previously exported changelog
+// still matches the chain rebuilt from the database. Entries is how many
+// entries were actually verified: the exported prefix when the chain has
+// grown since the export, the full chain otherwise.
+type ChangelogVerification struct {
+	Valid       bool `json:"valid"`
+	Entries     int  `json:"entries"`
+	MismatchSeq int  `json:"mismatch_seq,omitempty"`
+}
+
+// BuildChangelog returns the ordered, hash-chained history of a file:
+// every version upload and every approved edit request.
+func (s *FileService) BuildChangelog(fileID uint, userID uint, role string) ([]ChangelogEntry, error) {
+	var file models.File
+	if err := s.DB.First(&file, "id = ?", fileID).Error; err != nil {
+		return nil, ErrFileNotFound
+	}
+	if !s.CanAccessFile(&file, userID, role) {
+		return nil, ErrAccessDenied
+	}
+
+	var versions []models.FileVersion
+	if err := s.DB.Where("file_id = ?", fileID).Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	var requests []models.FileEditRequest
+	if err := s.DB.Where("file_id = ? AND status = ?", fileID, "approved").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	withDetails, err := s.attachDetails(requests)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]ChangelogEntry, 0, len(versions)+len(withDetails))
+	for _, v := range versions {
+		entries = append(entries, ChangelogEntry{
+			Type:      "version",
+			Ref:       v.ID,
+			Timestamp: v.CreatedAt,
+			Summary:   fmt.Sprintf("version %d uploaded by user %d", v.Version, v.CreatedBy),
+		})
+	}
+	for _, r := range withDetails {
+		fields := make([]string, 0, len(r.Details))
+		for _, d := range r.Details {
+			fields = append(fields, fmt.Sprintf("%s: %q -> %q", d.FieldName, d.OldValue, d.NewValue))
+		}
+		entries = append(entries, ChangelogEntry{
+			Type:      "edit_request",
+			Ref:       r.ID,
+			Timestamp: r.UpdatedAt,
+			Summary:   fmt.Sprintf("row %d edited (%s), approved by user %d", r.RowID, strings.Join(fields, "; "), r.ApprovedBy),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Timestamp.Equal(entries[j].Timestamp) {
+			return entries[i].Ref < entries[j].Ref
+		}
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+
+	prev := ""
+	for i := range entries {
+		entries[i].Seq = i + 1
+		entries[i].PrevHash = prev
+		entries[i].Hash = entryHash(entries[i], prev)
+		prev = entries[i].Hash
+	}
+	return entries, nil
+}
+
+// VerifyChangelog rebuilds the chain from the database and compares it
+// entry by entry against an exported changelog. The first sequence
+// number that differs is reported; zero means the chains match.
+func (s *FileService) VerifyChangelog(fileID uint, exported []ChangelogEntry, userID uint, role string) (*ChangelogVerification, error) {
+	current, err := s.BuildChangelog(fileID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	result := &ChangelogVerification{Valid: true, Entries: len(current)}
+	for i, entry := range exported {
+		if i >= len(current) || current[i].Hash != entry.Hash {
+			result.Valid = false
+			result.MismatchSeq = entry.Seq
+			return result, nil
+		}
+	}
+	if len(exported) < len(current) {
+		// New entries after the export are fine; only the exported
+		// prefix was verified, so report its length rather than the
+		// full chain's.
+		result.Entries = len(exported)
+	}
+	return result, nil
+}
+
+func entryHash(entry ChangelogEntry, prev string) string {
+	payload := fmt.Sprintf("%s|%d|%s|%s|%s", entry.Type, entry.Ref, entry.Timestamp.UTC().Format(time.RFC3339Nano), entry.Summary, prev)
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
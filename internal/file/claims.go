@@ -0,0 +1,109 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var (
+	ErrRowNotFound   = errors.New("row not found")
+	ErrClaimNotFound = errors.New("claim not found")
+	ErrClaimExists   = errors.New("you have already claimed this row")
+)
+
+// CreateRowClaim stores a user's claimed relationship to a row for admin
+// verification. Verified claimants are treated as followers of the row
+// when change notifications fan out.
+func (s *FileService) CreateRowClaim(rowID uint, relationship string, userID uint) (*models.RowClaim, error) {
+	var row models.FileData
+	if err := s.DB.First(&row, rowID).Error; err != nil {
+		return nil, ErrRowNotFound
+	}
+
+	var existing models.RowClaim
+	err := s.DB.Where("row_id = ? AND user_id = ? AND status <> ?", rowID, userID, "rejected").
+		First(&existing).Error
+	if err == nil {
+		return nil, ErrClaimExists
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	claim := models.RowClaim{
+		FileID:       row.FileID,
+		RowID:        rowID,
+		UserID:       userID,
+		Relationship: relationship,
+		Status:       "pending",
+	}
+	if err := s.DB.Create(&claim).Error; err != nil {
+		return nil, err
+	}
+	s.Logs.Record(userID, "CREATE_ROW_CLAIM", fmt.Sprintf("claim=%d file=%d row=%d", claim.ID, claim.FileID, claim.RowID))
+	return &claim, nil
+}
+
+// GetRowClaims returns the claims a user has made, newest first.
+func (s *FileService) GetRowClaims(userID uint) ([]models.RowClaim, error) {
+	var claims []models.RowClaim
+	if err := s.DB.Where("user_id = ?", userID).
+		Order("created_at DESC").Find(&claims).Error; err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// ListRowClaims returns claims for admin review, optionally filtered by
+// status.
+func (s *FileService) ListRowClaims(status string) ([]models.RowClaim, error) {
+	q := s.DB.Order("created_at ASC")
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var claims []models.RowClaim
+	if err := q.Find(&claims).Error; err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// ReviewRowClaim verifies or rejects one pending claim.
+func (s *FileService) ReviewRowClaim(claimID uint, verify bool, adminID uint) (*models.RowClaim, error) {
+	var claim models.RowClaim
+	if err := s.DB.First(&claim, claimID).Error; err != nil {
+		return nil, ErrClaimNotFound
+	}
+	if claim.Status != "pending" {
+		return nil, ErrNotPending
+	}
+	claim.Status = "rejected"
+	if verify {
+		claim.Status = "verified"
+	}
+	claim.VerifiedBy = adminID
+	if err := s.DB.Save(&claim).Error; err != nil {
+		return nil, err
+	}
+	s.Logs.Record(adminID, "REVIEW_ROW_CLAIM", fmt.Sprintf("claim=%d %s", claim.ID, claim.Status))
+	return &claim, nil
+}
+
+// VerifiedClaimants returns the users with a verified claim on a row.
+func (s *FileService) VerifiedClaimants(rowID uint) ([]uint, error) {
+	var claims []models.RowClaim
+	if err := s.DB.Select("user_id").
+		Where("row_id = ? AND status = ?", rowID, "verified").
+		Find(&claims).Error; err != nil {
+		return nil, err
+	}
+	userIDs := make([]uint, 0, len(claims))
+	for _, claim := range claims {
+		userIDs = append(userIDs, claim.UserID)
+	}
+	return userIDs, nil
+}
@@ -0,0 +1,154 @@
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var ErrInvalidColumns = errors.New("invalid column update")
+
+// UpdateColumnsPayload renames headers and/or replaces the column order
+// without a re-upload. Renames map old header names to new ones; Order,
+// when set, is the full new ColumnsOrder using post-rename names.
+type UpdateColumnsPayload struct {
+	Renames map[string]string `json:"renames"`
+	Order   []string          `json:"order"`
+}
+
+// UpdateColumns applies header renames and reordering to a file,
+// rewriting the keys inside every current-version row in batches within
+// one transaction so readers never see a half-renamed dataset.
+func (s *FileService) UpdateColumns(fileID uint, payload UpdateColumnsPayload, adminID uint) (*models.File, error) {
+	var file models.File
+	if err := s.DB.First(&file, fileID).Error; err != nil {
+		return nil, ErrFileNotFound
+	}
+	var columns []string
+	if err := json.Unmarshal(file.ColumnsOrder, &columns); err != nil {
+		return nil, fmt.Errorf("decode columns order: %w", err)
+	}
+
+	renamed, err := applyRenames(columns, payload.Renames)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload.Order) > 0 {
+		if !samePermutation(renamed, payload.Order) {
+			return nil, fmt.Errorf("%w: order must contain exactly the file's columns", ErrInvalidColumns)
+		}
+		renamed = payload.Order
+	}
+
+	raw, _ := json.Marshal(renamed)
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&file).Update("columns_order", datatypes.JSON(raw)).Error; err != nil {
+			return err
+		}
+		if len(payload.Renames) == 0 {
+			// Pure reorders only touch ColumnsOrder; row keys are a map.
+			return nil
+		}
+		return renameRowKeys(tx, file.ID, file.Version, payload.Renames)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("update columns: %w", err)
+	}
+	s.Logs.Record(adminID, "UPDATE_COLUMNS", fmt.Sprintf("file=%d renames=%d", file.ID, len(payload.Renames)))
+	return &file, nil
+}
+
+// applyRenames validates and applies header renames against the current
+// column list.
+func applyRenames(columns []string, renames map[string]string) ([]string, error) {
+	existing := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		existing[c] = true
+	}
+	for old, updated := range renames {
+		if !existing[old] {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidColumns, old)
+		}
+		if updated == "" {
+			return nil, fmt.Errorf("%w: new name for %q is empty", ErrInvalidColumns, old)
+		}
+	}
+	out := make([]string, len(columns))
+	seen := make(map[string]bool, len(columns))
+	for i, c := range columns {
+		if replacement, ok := renames[c]; ok {
+			c = replacement
+		}
+		if seen[c] {
+			return nil, fmt.Errorf("%w: rename produces duplicate column %q", ErrInvalidColumns, c)
+		}
+		seen[c] = true
+		out[i] = c
+	}
+	return out, nil
+}
+
+// samePermutation reports whether b holds exactly the elements of a.
+func samePermutation(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int, len(a))
+	for _, v := range a {
+		counts[v]++
+	}
+	for _, v := range b {
+		counts[v]--
+		if counts[v] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// renameRowKeys rewrites RowData keys for the file's current version,
+// paging by primary key in insertBatchSize chunks.
+func renameRowKeys(tx *gorm.DB, fileID uint, version int, renames map[string]string) error {
+	var lastID uint
+	for {
+		var rows []models.FileData
+		if err := tx.Where("file_id = ? AND version = ? AND id > ?", fileID, version, lastID).
+			Order("id").Limit(insertBatchSize).Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		for _, r := range rows {
+			lastID = r.ID
+			var row map[string]string
+			if err := json.Unmarshal(r.RowData, &row); err != nil {
+				return fmt.Errorf("decode row %d: %w", r.ID, err)
+			}
+			changed := false
+			for old, updated := range renames {
+				if v, ok := row[old]; ok {
+					delete(row, old)
+					row[updated] = v
+					changed = true
+				}
+			}
+			if !changed {
+				continue
+			}
+			raw, err := json.Marshal(row)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&models.FileData{}).Where("id = ?", r.ID).
+				Update("row_data", datatypes.JSON(raw)).Error; err != nil {
+				return err
+			}
+		}
+	}
+}
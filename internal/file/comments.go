@@ -0,0 +1,112 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrNotCommentOwner = errors.New("only the author or an admin can delete a comment")
+	ErrEmptyComment    = errors.New("comment body cannot be empty")
+)
+
+// CommentWithAuthor is a comment joined with its author's name for the
+// listing endpoint.
+type CommentWithAuthor struct {
+	models.FileComment
+	AuthorName string `json:"author_name"`
+}
+
+// AddComment attaches a note to a file, optionally as a reply to an
+// existing comment. Contributor permission is required: comments carry
+// institutional knowledge about the data, the same trust level as
+// proposing corrections to it.
+func (s *FileService) AddComment(fileID uint, parentID *uint, body string, userID uint, role string) (*models.FileComment, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, ErrEmptyComment
+	}
+	var file models.File
+	if err := s.DB.First(&file, fileID).Error; err != nil {
+		return nil, ErrFileNotFound
+	}
+	if !s.HasFilePermission(&file, userID, role, PermissionContributor) {
+		return nil, ErrAccessDenied
+	}
+	if parentID != nil {
+		var parent models.FileComment
+		if err := s.DB.Where("id = ? AND file_id = ?", *parentID, fileID).
+			First(&parent).Error; err != nil {
+			return nil, ErrCommentNotFound
+		}
+	}
+	comment := models.FileComment{
+		FileID:    fileID,
+		ParentID:  parentID,
+		Body:      body,
+		CreatedBy: userID,
+	}
+	if err := s.DB.Create(&comment).Error; err != nil {
+		return nil, err
+	}
+	s.Logs.Record(userID, "ADD_FILE_COMMENT", fmt.Sprintf("file=%d comment=%d", fileID, comment.ID))
+	return &comment, nil
+}
+
+// ListComments returns a file's comments oldest first with author names
+// attached; the client nests replies via parent_id.
+func (s *FileService) ListComments(fileID, userID uint, role string) ([]CommentWithAuthor, error) {
+	var file models.File
+	if err := s.DB.First(&file, fileID).Error; err != nil {
+		return nil, ErrFileNotFound
+	}
+	if !s.CanAccessFile(&file, userID, role) {
+		return nil, ErrAccessDenied
+	}
+	var comments []CommentWithAuthor
+	err := s.DB.Table("file_comments").
+		Select("file_comments.*, COALESCE(users.first_name || ' ' || users.last_name, '') AS author_name").
+		Joins("LEFT JOIN users ON users.id = file_comments.created_by").
+		Where("file_comments.file_id = ?", fileID).
+		Order("file_comments.created_at ASC").
+		Scan(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// DeleteComment removes a comment and its replies. Only the author or
+// an admin may delete.
+func (s *FileService) DeleteComment(commentID, userID uint, role string) error {
+	var comment models.FileComment
+	if err := s.DB.First(&comment, commentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCommentNotFound
+		}
+		return err
+	}
+	if role != "Admin" && comment.CreatedBy != userID {
+		return ErrNotCommentOwner
+	}
+	// Replies can themselves have replies, so walk the whole subtree
+	// rather than just the direct children.
+	err := s.DB.Exec(`
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM file_comments WHERE id = ?
+			UNION ALL
+			SELECT c.id FROM file_comments c JOIN subtree s ON c.parent_id = s.id
+		)
+		DELETE FROM file_comments WHERE id IN (SELECT id FROM subtree)`, comment.ID).Error
+	if err != nil {
+		return err
+	}
+	s.Logs.Record(userID, "DELETE_FILE_COMMENT", fmt.Sprintf("file=%d comment=%d", comment.FileID, commentID))
+	return nil
+}
@@ -0,0 +1,244 @@
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"gorm.io/datatypes"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var (
+	ErrInvalidExpression = errors.New("invalid expression")
+	ErrComputedNotFound  = errors.New("computed column not found")
+	ErrColumnNameTaken   = errors.New("column name already in use")
+)
+
+// Computed column expressions are deliberately tiny: value tokens are
+// {Column Name} references, 'quoted literals' and bare numbers; the
+// operators are + - * / for arithmetic and || for concatenation, applied
+// left to right without precedence. Examples:
+//
+//	{Death Year} - {Birth Year}
+//	{First Name} || ' ' || {Last Name}
+//
+// Arithmetic over a non-numeric cell yields an empty value for that row.
+
+type exprToken struct {
+	kind  string // "col", "lit" or "op"
+	value string
+}
+
+// tokenizeExpression splits an expression into tokens and checks that
+// values and operators alternate.
+func tokenizeExpression(expr string) ([]exprToken, error) {
+	var tokens []exprToken
+	i := 0
+	for i < len(expr) {
+		switch {
+		case expr[i] == ' ' || expr[i] == '\t':
+			i++
+		case expr[i] == '{':
+			end := strings.IndexByte(expr[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unclosed column reference", ErrInvalidExpression)
+			}
+			name := strings.TrimSpace(expr[i+1 : i+end])
+			if name == "" {
+				return nil, fmt.Errorf("%w: empty column reference", ErrInvalidExpression)
+			}
+			tokens = append(tokens, exprToken{kind: "col", value: name})
+			i += end + 1
+		case expr[i] == '\'':
+			end := strings.IndexByte(expr[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unclosed literal", ErrInvalidExpression)
+			}
+			tokens = append(tokens, exprToken{kind: "lit", value: expr[i+1 : i+1+end]})
+			i += end + 2
+		case strings.HasPrefix(expr[i:], "||"):
+			tokens = append(tokens, exprToken{kind: "op", value: "||"})
+			i += 2
+		case expr[i] == '+' || expr[i] == '-' || expr[i] == '*' || expr[i] == '/':
+			tokens = append(tokens, exprToken{kind: "op", value: string(expr[i])})
+			i++
+		default:
+			end := i
+			for end < len(expr) && !strings.ContainsRune(" \t{}'+-*/|", rune(expr[end])) {
+				end++
+			}
+			if end == i {
+				return nil, fmt.Errorf("%w: unexpected character %q", ErrInvalidExpression, expr[i])
+			}
+			if _, err := strconv.ParseFloat(expr[i:end], 64); err != nil {
+				return nil, fmt.Errorf("%w: %q is not a number", ErrInvalidExpression, expr[i:end])
+			}
+			tokens = append(tokens, exprToken{kind: "lit", value: expr[i:end]})
+			i = end
+		}
+	}
+	if len(tokens) == 0 || len(tokens)%2 == 0 {
+		return nil, fmt.Errorf("%w: values and operators must alternate", ErrInvalidExpression)
+	}
+	for idx, t := range tokens {
+		if (idx%2 == 0) == (t.kind == "op") {
+			return nil, fmt.Errorf("%w: values and operators must alternate", ErrInvalidExpression)
+		}
+	}
+	return tokens, nil
+}
+
+// evalExpression evaluates tokens against one row. Arithmetic on
+// non-numeric values yields "".
+func evalExpression(tokens []exprToken, row map[string]string) string {
+	value := func(t exprToken) string {
+		if t.kind == "col" {
+			return row[t.value]
+		}
+		return t.value
+	}
+	acc := value(tokens[0])
+	for i := 1; i < len(tokens); i += 2 {
+		next := value(tokens[i+1])
+		if tokens[i].value == "||" {
+			acc += next
+			continue
+		}
+		left, err1 := strconv.ParseFloat(strings.TrimSpace(acc), 64)
+		right, err2 := strconv.ParseFloat(strings.TrimSpace(next), 64)
+		if err1 != nil || err2 != nil {
+			return ""
+		}
+		switch tokens[i].value {
+		case "+":
+			left += right
+		case "-":
+			left -= right
+		case "*":
+			left *= right
+		case "/":
+			if right == 0 {
+				return ""
+			}
+			left /= right
+		}
+		acc = strconv.FormatFloat(left, 'f', -1, 64)
+	}
+	return acc
+}
+
+// AddComputedColumn validates and stores a derived column definition for
+// a file.
+func (s *FileService) AddComputedColumn(fileID uint, name, expression string, adminID uint) (*models.ComputedColumn, error) {
+	var file models.File
+	if err := s.DB.First(&file, fileID).Error; err != nil {
+		return nil, ErrFileNotFound
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidExpression)
+	}
+	tokens, err := tokenizeExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	if err := json.Unmarshal(file.ColumnsOrder, &columns); err != nil {
+		return nil, fmt.Errorf("decode columns order: %w", err)
+	}
+	known := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		known[c] = true
+	}
+	for _, t := range tokens {
+		if t.kind == "col" && !known[t.value] {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidExpression, t.value)
+		}
+	}
+	if known[name] {
+		return nil, ErrColumnNameTaken
+	}
+	var count int64
+	if err := s.DB.Model(&models.ComputedColumn{}).
+		Where("file_id = ? AND name = ?", fileID, name).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrColumnNameTaken
+	}
+
+	column := models.ComputedColumn{FileID: fileID, Name: name, Expression: expression, CreatedBy: adminID}
+	if err := s.DB.Create(&column).Error; err != nil {
+		return nil, fmt.Errorf("create computed column: %w", err)
+	}
+	s.Logs.Record(adminID, "ADD_COMPUTED_COLUMN", fmt.Sprintf("file=%d name=%s", fileID, name))
+	return &column, nil
+}
+
+// ListComputedColumns returns a file's derived column definitions.
+func (s *FileService) ListComputedColumns(fileID uint) ([]models.ComputedColumn, error) {
+	var columns []models.ComputedColumn
+	if err := s.DB.Where("file_id = ?", fileID).Order("id").Find(&columns).Error; err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
+
+// DeleteComputedColumn removes a derived column definition.
+func (s *FileService) DeleteComputedColumn(fileID, columnID, adminID uint) error {
+	result := s.DB.Where("id = ? AND file_id = ?", columnID, fileID).Delete(&models.ComputedColumn{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrComputedNotFound
+	}
+	s.Logs.Record(adminID, "DELETE_COMPUTED_COLUMN", fmt.Sprintf("file=%d column=%d", fileID, columnID))
+	return nil
+}
+
+// applyComputedColumns evaluates a file's derived columns into each
+// row's data, returning the computed column names. Definitions that no
+// longer parse are skipped rather than breaking reads.
+func (s *FileService) applyComputedColumns(file *models.File, rows []models.FileData) []string {
+	definitions, err := s.ListComputedColumns(file.ID)
+	if err != nil {
+		log.Printf("file: load computed columns for %d: %v", file.ID, err)
+		return nil
+	}
+	if len(definitions) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(definitions))
+	parsed := make([][]exprToken, 0, len(definitions))
+	for _, def := range definitions {
+		tokens, err := tokenizeExpression(def.Expression)
+		if err != nil {
+			log.Printf("file: computed column %q on file %d no longer parses: %v", def.Name, file.ID, err)
+			continue
+		}
+		names = append(names, def.Name)
+		parsed = append(parsed, tokens)
+	}
+	for i := range rows {
+		var row map[string]string
+		if err := json.Unmarshal(rows[i].RowData, &row); err != nil {
+			continue
+		}
+		for j, tokens := range parsed {
+			row[names[j]] = evalExpression(tokens, row)
+		}
+		raw, err := json.Marshal(row)
+		if err != nil {
+			continue
+		}
+		rows[i].RowData = datatypes.JSON(raw)
+	}
+	return names
+}
@@ -0,0 +1,1014 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/gin-gonic/gin"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/i18n"
+	"github.com/datanorthnordik/nordikdriveapi/internal/media"
+	"github.com/datanorthnordik/nordikdriveapi/internal/middleware"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+	"github.com/datanorthnordik/nordikdriveapi/internal/permissions"
+)
+
+// Service is the surface FileController depends on, implemented by
+// FileService. Kept as an interface so alternate backends (e.g. other
+// object stores) can be wired in by the server container.
+type Service interface {
+	StartUpload(header *multipart.FileHeader, private, dedupe, checkDuplicates, fixDates, cleanWS, strictSources bool, userID uint, role string, communities []string) (string, error)
+	GetUploadStatus(id string, userID uint, role string) (*UploadStatus, error)
+	ReplaceFiles(ctx context.Context, fileID uint, header *multipart.FileHeader, dedupe, cleanWS, strictSources bool, userID uint, role string) (*models.File, *DedupeReport, error)
+	GetAllFiles(userID uint, role, community string) ([]FileWithUser, error)
+	GetFileData(ctx context.Context, fileID uint, version int, userID uint, role string) (*models.File, []models.FileData, error)
+	VersionMeta(fileID uint, version int) (*models.FileVersion, error)
+	SetDefaultSort(fileID uint, column, direction string, userID uint, role string) (*models.File, error)
+	CreateSnapshot(fileID uint, name string, userID uint, role string) (*models.FileSnapshot, error)
+	ListSnapshots(fileID uint, userID uint, role string) ([]models.FileSnapshot, error)
+	AddComment(fileID uint, parentID *uint, body string, userID uint, role string) (*models.FileComment, error)
+	ListComments(fileID, userID uint, role string) ([]CommentWithAuthor, error)
+	DeleteComment(commentID, userID uint, role string) error
+	ListAnnotations(fileID, userID uint, role string) ([]models.CellAnnotation, error)
+	SetAnnotation(fileID, rowID uint, column, source, confidence string, userID uint, role string) (*models.CellAnnotation, error)
+	StripSourceLabels(fileID, userID uint) (int, error)
+	OriginalDownloadURL(ctx context.Context, fileID uint, version int, userID uint, role string) (string, error)
+	GetSnapshot(ctx context.Context, token string) (*models.File, *models.FileSnapshot, []models.FileData, error)
+	Aggregate(fileID uint, req AggregateRequest, userID uint, role string) ([]AggregateBucket, error)
+	StreamBundleZip(ctx context.Context, fileID uint, version int, userID uint, role string, w io.Writer) error
+	BuildChangelog(fileID uint, userID uint, role string) ([]ChangelogEntry, error)
+	VerifyChangelog(fileID uint, exported []ChangelogEntry, userID uint, role string) (*ChangelogVerification, error)
+	ListTrash() ([]TrashedFile, error)
+	DeleteFile(fileID uint, userID uint) error
+	ResetFile(fileID uint, version int, userID uint) error
+	RestoreVersion(ctx context.Context, fileID uint, version int, adminID uint) (int, error)
+	SetPublic(fileID uint, settings PublishSettings, adminID uint) (*models.File, error)
+	UpdateMetadata(fileID uint, meta MetadataUpdate, userID uint, role string) (*models.File, error)
+	UpdateColumns(fileID uint, payload UpdateColumnsPayload, adminID uint) (*models.File, error)
+	AddComputedColumn(fileID uint, name, expression string, adminID uint) (*models.ComputedColumn, error)
+	ListComputedColumns(fileID uint) ([]models.ComputedColumn, error)
+	DeleteComputedColumn(fileID, columnID, adminID uint) error
+	TransferOwnership(fileID, newOwnerID, adminID uint) error
+	GrantAccess(fileID, userID uint, permission string, expiresAt *time.Time, grantedBy uint, grantedByRole string) error
+	RevokeAccess(fileID, userID, revokedBy uint, revokedByRole string) error
+	CreateEditRequest(ctx context.Context, payload CreateEditRequestPayload, userID uint, role, firstName, lastName string, communities []string) (*EditRequestWithDetails, error)
+	UpdateEditRequest(ctx context.Context, requestID uint, payload UpdateEditRequestPayload, userID uint) (*models.FileEditRequest, error)
+	GetEditRequests(userID uint, status string) ([]EditRequestWithDetails, error)
+	GetPendingEditRequests() ([]EditRequestWithDetails, error)
+	ApproveEditRequest(ctx context.Context, requestID uint, overrides []DetailOverride, adminID uint) error
+	UpdateEditRequestDetails(requestID uint, edits []DetailEdit, adminID uint) ([]models.FileEditRequestDetail, error)
+	RejectEditRequest(requestID uint, adminID uint) error
+	UndoEditRequest(ctx context.Context, requestID uint, adminID uint) (*UndoReport, error)
+	RepairRequestMedia(ctx context.Context, requestID uint, adminID uint) (*RepairReport, error)
+	CreateRowClaim(rowID uint, relationship string, userID uint) (*models.RowClaim, error)
+	GetRowClaims(userID uint) ([]models.RowClaim, error)
+	ListRowClaims(status string) ([]models.RowClaim, error)
+	ReviewRowClaim(claimID uint, verify bool, adminID uint) (*models.RowClaim, error)
+	GetRowHistory(rowID uint) ([]RowHistoryEntry, error)
+	GetPhotosByRow(rowID uint) ([]models.FileEditRequestPhoto, error)
+	UpdatePhotoMeta(photoID uint, meta PhotoMetaUpdate, userID uint, role string) (*models.FileEditRequestPhoto, error)
+	GetDocsByRow(rowID uint, category string) ([]models.FileEditRequestDocument, error)
+	ListDocumentCategories() ([]models.DocumentCategory, error)
+	AddDocumentCategory(name string, adminID uint) (*models.DocumentCategory, error)
+	DeleteDocumentCategory(categoryID uint, adminID uint) error
+	OpenMediaHandle(ctx context.Context, gsURL string, offset, length int64) (*storage.Reader, *storage.ObjectAttrs, error)
+}
+
+var _ Service = (*FileService)(nil)
+
+// FileController exposes the /api/file endpoints.
+type FileController struct {
+	Service Service
+}
+
+func NewFileController(service Service) *FileController {
+	return &FileController{Service: service}
+}
+
+// RegisterRoutes mounts the file endpoints. The group is expected to be
+// behind RequireAuth; admin-only routes add RequireRole inline.
+func (ctl *FileController) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/file/upload", middleware.Backpressure(), ctl.UploadFiles)
+	rg.GET("/file/upload/:id/status", ctl.GetUploadStatus)
+	rg.POST("/file/:id/replace", middleware.Backpressure(), ctl.ReplaceFile)
+	rg.GET("/files", ctl.GetAllFiles)
+	rg.GET("/file/:id/data", ctl.GetFileData)
+	rg.POST("/file/:id/aggregate", ctl.Aggregate)
+	rg.GET("/file/:id/bundle", middleware.Backpressure(), ctl.DownloadBundle)
+	rg.GET("/file/:id/changelog", ctl.GetChangelog)
+	rg.POST("/file/:id/changelog/verify", ctl.VerifyChangelog)
+	rg.GET("/file/trash", middleware.RequireRole("Admin"), ctl.ListTrash)
+	rg.DELETE("/file/:id", middleware.RequireRole("Admin"), ctl.DeleteFile)
+	rg.POST("/file/:id/reset", middleware.RequireRole("Admin"), ctl.ResetFile)
+	rg.POST("/file/:id/versions/:v/restore", middleware.RequireRole("Admin"), ctl.RestoreVersion)
+	rg.PUT("/file/:id/publish", middleware.RequirePermission(permissions.ManageAccess), ctl.PublishFile)
+	rg.PUT("/file/:id/owner", middleware.RequireRole("Admin"), ctl.TransferOwnership)
+	rg.PUT("/file/:id/metadata", ctl.UpdateMetadata)
+	rg.PUT("/file/:id/sort", ctl.SetDefaultSort)
+	rg.POST("/file/:id/snapshots", ctl.CreateSnapshot)
+	rg.GET("/file/:id/snapshots", ctl.ListSnapshots)
+	rg.POST("/file/:id/comments", ctl.AddComment)
+	rg.GET("/file/:id/comments", ctl.ListComments)
+	rg.DELETE("/file/comments/:id", ctl.DeleteComment)
+	rg.GET("/file/:id/annotations", ctl.ListAnnotations)
+	rg.PUT("/file/:id/annotations", ctl.SetAnnotation)
+	rg.POST("/file/:id/strip-source-labels", middleware.RequireRole("Admin"), ctl.StripSourceLabels)
+	rg.GET("/file/:id/original", ctl.GetOriginal)
+	rg.GET("/snapshot/:token", ctl.GetSnapshot)
+	rg.PUT("/file/:id/columns", middleware.RequireRole("Admin"), ctl.UpdateColumns)
+	rg.GET("/file/:id/computed", ctl.ListComputedColumns)
+	rg.POST("/file/:id/computed", middleware.RequireRole("Admin"), ctl.AddComputedColumn)
+	rg.DELETE("/file/:id/computed/:columnId", middleware.RequireRole("Admin"), ctl.DeleteComputedColumn)
+	rg.POST("/file/:id/access", ctl.GrantAccess)
+	rg.DELETE("/file/:id/access/:userId", ctl.RevokeAccess)
+
+	rg.POST("/file/edit/request", ctl.CreateEditRequest)
+	rg.PUT("/file/edit/request/:id", ctl.UpdateEditRequest)
+	rg.GET("/file/edit/requests", ctl.GetEditRequests)
+	rg.GET("/file/edit/requests/pending", middleware.RequirePermission(permissions.ApproveRequests), ctl.GetPendingEditRequests)
+	rg.PUT("/file/edit/request/:id/details", middleware.RequirePermission(permissions.ApproveRequests), ctl.UpdateEditRequestDetails)
+	rg.POST("/file/edit/request/:id/approve", middleware.RequirePermission(permissions.ApproveRequests), ctl.ApproveEditRequest)
+	rg.POST("/file/edit/request/:id/reject", middleware.RequirePermission(permissions.ApproveRequests), ctl.RejectEditRequest)
+	rg.POST("/file/edit/request/:id/undo", middleware.RequirePermission(permissions.ApproveRequests), ctl.UndoEditRequest)
+	rg.POST("/file/row/:id/claim", ctl.CreateRowClaim)
+	rg.GET("/file/claims", ctl.GetRowClaims)
+	rg.GET("/file/row/:id/history", ctl.GetRowHistory)
+	rg.GET("/file/row/:id/photos", ctl.GetPhotosByRow)
+	rg.PUT("/file/photos/:id/meta", ctl.UpdatePhotoMeta)
+	rg.GET("/file/row/:id/docs", ctl.GetDocsByRow)
+	rg.GET("/file/media", ctl.StreamMedia)
+	rg.GET("/file/document-categories", ctl.ListDocumentCategories)
+}
+
+// RegisterAdminRoutes mounts file endpoints that live on the admin group.
+func (ctl *FileController) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	rg.POST("/requests/:id/repair-media", ctl.RepairRequestMedia)
+	rg.POST("/document-categories", ctl.AddDocumentCategory)
+	rg.DELETE("/document-categories/:id", ctl.DeleteDocumentCategory)
+	rg.GET("/claims", ctl.ListRowClaims)
+	rg.POST("/claims/:id/verify", ctl.VerifyRowClaim)
+	rg.POST("/claims/:id/reject", ctl.RejectRowClaim)
+}
+
+func (ctl *FileController) CreateRowClaim(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req struct {
+		Relationship string `json:"relationship" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	claim, err := ctl.Service.CreateRowClaim(paramUint(c, "id"), req.Relationship, claims.UserID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"claim": claim})
+}
+
+func (ctl *FileController) GetRowClaims(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	rowClaims, err := ctl.Service.GetRowClaims(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to list claims")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"claims": rowClaims})
+}
+
+func (ctl *FileController) ListRowClaims(c *gin.Context) {
+	rowClaims, err := ctl.Service.ListRowClaims(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to list claims")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"claims": rowClaims})
+}
+
+func (ctl *FileController) VerifyRowClaim(c *gin.Context) {
+	ctl.reviewRowClaim(c, true)
+}
+
+func (ctl *FileController) RejectRowClaim(c *gin.Context) {
+	ctl.reviewRowClaim(c, false)
+}
+
+func (ctl *FileController) reviewRowClaim(c *gin.Context, verify bool) {
+	claims := middleware.GetClaims(c)
+	claim, err := ctl.Service.ReviewRowClaim(paramUint(c, "id"), verify, claims.UserID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"claim": claim})
+}
+
+func (ctl *FileController) RepairRequestMedia(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	report, err := ctl.Service.RepairRequestMedia(c.Request.Context(), paramUint(c, "id"), claims.UserID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+func (ctl *FileController) UploadFiles(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "missing file upload")})
+		return
+	}
+	private := c.PostForm("private") == "true"
+	dedupe := c.PostForm("dedupe") == "true"
+	checkDuplicates := c.PostForm("check_duplicates") == "true"
+	fixDates := c.PostForm("normalize_dates") == "true"
+	// Whitespace cleaning is on unless the uploader opts out: invisible
+	// characters break equality matching downstream, so keeping them is
+	// the exceptional case.
+	cleanWS := c.PostForm("clean_whitespace") != "false"
+	// Strict mode keeps detected source colors out of cell values,
+	// storing them as annotations only. Off by default: existing files
+	// have the labels baked in and matching against them expects it.
+	strictSources := c.PostForm("strict_sources") == "true"
+	uploadID, err := ctl.Service.StartUpload(header, private, dedupe, checkDuplicates, fixDates, cleanWS, strictSources, claims.UserID, claims.Role, claims.Communities)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrUnsupported) || errors.Is(err, ErrFilenameTaken) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"upload_id": uploadID})
+}
+
+// GetUploadStatus reports the stage of a background upload started by
+// UploadFiles.
+func (ctl *FileController) GetUploadStatus(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	status, err := ctl.Service.GetUploadStatus(c.Param("id"), claims.UserID, claims.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": status})
+}
+
+// Aggregate runs a grouped count over a file's rows for dashboards.
+func (ctl *FileController) Aggregate(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req AggregateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	buckets, err := ctl.Service.Aggregate(paramUint(c, "id"), req, claims.UserID, claims.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"buckets": buckets})
+}
+
+// UpdateColumns renames headers and/or reorders columns for a file
+// without requiring a re-upload.
+func (ctl *FileController) UpdateColumns(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var payload UpdateColumnsPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	file, err := ctl.Service.UpdateColumns(paramUint(c, "id"), payload, claims.UserID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"file": file})
+}
+
+// AddComputedColumn stores a derived column expression for a file.
+func (ctl *FileController) AddComputedColumn(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req struct {
+		Name       string `json:"name" binding:"required"`
+		Expression string `json:"expression" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	column, err := ctl.Service.AddComputedColumn(paramUint(c, "id"), req.Name, req.Expression, claims.UserID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"column": column})
+}
+
+func (ctl *FileController) ListComputedColumns(c *gin.Context) {
+	columns, err := ctl.Service.ListComputedColumns(paramUint(c, "id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to list computed columns")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"columns": columns})
+}
+
+func (ctl *FileController) DeleteComputedColumn(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	columnID, err := strconv.ParseUint(c.Param("columnId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "invalid column id")})
+		return
+	}
+	if err := ctl.Service.DeleteComputedColumn(paramUint(c, "id"), uint(columnID), claims.UserID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "computed column deleted"})
+}
+
+func (ctl *FileController) ReplaceFile(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	fileID := paramUint(c, "id")
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "missing file upload")})
+		return
+	}
+	dedupe := c.PostForm("dedupe") == "true"
+	cleanWS := c.PostForm("clean_whitespace") != "false"
+	strictSources := c.PostForm("strict_sources") == "true"
+	file, dedupeReport, err := ctl.Service.ReplaceFiles(c.Request.Context(), fileID, header, dedupe, cleanWS, strictSources, claims.UserID, claims.Role)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrFileNotFound) {
+			status = http.StatusNotFound
+		} else if errors.Is(err, ErrUnsupported) {
+			status = http.StatusBadRequest
+		} else if errors.Is(err, ErrIdenticalUpload) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	resp := gin.H{"file": file}
+	if dedupeReport != nil {
+		resp["dedupe"] = dedupeReport
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (ctl *FileController) GetAllFiles(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	files, err := ctl.Service.GetAllFiles(claims.UserID, claims.Role, c.Query("community"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to list files")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"files": files})
+}
+
+func (ctl *FileController) GetFileData(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	fileID := paramUint(c, "id")
+	version, _ := strconv.Atoi(c.Query("version"))
+	file, rows, err := ctl.Service.GetFileData(c.Request.Context(), fileID, version, claims.UserID, claims.Role)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrFileNotFound) {
+			status = http.StatusNotFound
+		} else if errors.Is(err, ErrAccessDenied) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	if version == 0 {
+		version = file.Version
+	}
+	if meta, metaErr := ctl.Service.VersionMeta(fileID, version); metaErr == nil {
+		c.Header("Last-Modified", meta.CreatedAt.UTC().Format(http.TimeFormat))
+	}
+	etag := dataETag(fileID, rows)
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"file": file, "rows": rows})
+}
+
+func (ctl *FileController) DownloadBundle(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	version, _ := strconv.Atoi(c.Query("version"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", "attachment; filename=\"bundle.zip\"")
+	c.Status(http.StatusOK)
+	if err := ctl.Service.StreamBundleZip(c.Request.Context(), paramUint(c, "id"), version, claims.UserID, claims.Role, c.Writer); err != nil {
+		media.AbortStream(c, err)
+	}
+}
+
+func (ctl *FileController) GetChangelog(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	entries, err := ctl.Service.BuildChangelog(paramUint(c, "id"), claims.UserID, claims.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"changelog": entries})
+}
+
+func (ctl *FileController) VerifyChangelog(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req struct {
+		Entries []ChangelogEntry `json:"entries" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	result, err := ctl.Service.VerifyChangelog(paramUint(c, "id"), req.Entries, claims.UserID, claims.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (ctl *FileController) DeleteFile(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if err := ctl.Service.DeleteFile(paramUint(c, "id"), claims.UserID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "file deleted"})
+}
+
+func (ctl *FileController) ResetFile(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	version, _ := strconv.Atoi(c.Query("version"))
+	if err := ctl.Service.ResetFile(paramUint(c, "id"), version, claims.UserID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "file restored"})
+}
+
+// RestoreVersion rehydrates an archived version's rows from GCS so old
+// versions can be read and reverted to again.
+func (ctl *FileController) RestoreVersion(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	version, _ := strconv.Atoi(c.Param("v"))
+	restored, err := ctl.Service.RestoreVersion(c.Request.Context(), paramUint(c, "id"), version, claims.UserID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"restored_rows": restored})
+}
+
+func (ctl *FileController) ListTrash(c *gin.Context) {
+	files, err := ctl.Service.ListTrash()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to list trash")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"files": files})
+}
+
+func (ctl *FileController) PublishFile(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var settings PublishSettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	file, err := ctl.Service.SetPublic(paramUint(c, "id"), settings, claims.UserID)
+	if err != nil {
+		if errors.Is(err, ErrFileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": i18n.TErr(c, err)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"file": file})
+}
+
+// UpdateMetadata edits a file's descriptive metadata (description,
+// source organization, citation, license, collection period).
+func (ctl *FileController) UpdateMetadata(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var meta MetadataUpdate
+	if err := c.ShouldBindJSON(&meta); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	file, err := ctl.Service.UpdateMetadata(paramUint(c, "id"), meta, claims.UserID, claims.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"file": file})
+}
+
+// CreateSnapshot freezes the file's current version under a citable
+// token URL.
+func (ctl *FileController) CreateSnapshot(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	snapshot, err := ctl.Service.CreateSnapshot(paramUint(c, "id"), req.Name, claims.UserID, claims.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"snapshot": snapshot})
+}
+
+// ListSnapshots returns a file's snapshots.
+func (ctl *FileController) ListSnapshots(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	snapshots, err := ctl.Service.ListSnapshots(paramUint(c, "id"), claims.UserID, claims.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"snapshots": snapshots})
+}
+
+// GetSnapshot serves the frozen data behind a snapshot token.
+func (ctl *FileController) GetSnapshot(c *gin.Context) {
+	file, snapshot, rows, err := ctl.Service.GetSnapshot(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"file": file, "snapshot": snapshot, "rows": rows})
+}
+
+// AddComment leaves a note on a file, optionally replying to another
+// comment.
+func (ctl *FileController) AddComment(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req struct {
+		Body     string `json:"body" binding:"required"`
+		ParentID *uint  `json:"parent_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	comment, err := ctl.Service.AddComment(paramUint(c, "id"), req.ParentID, req.Body, claims.UserID, claims.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"comment": comment})
+}
+
+// ListComments returns a file's comments with author names.
+func (ctl *FileController) ListComments(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	comments, err := ctl.Service.ListComments(paramUint(c, "id"), claims.UserID, claims.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"comments": comments})
+}
+
+// DeleteComment removes a comment (author or admin).
+func (ctl *FileController) DeleteComment(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if err := ctl.Service.DeleteComment(paramUint(c, "id"), claims.UserID, claims.Role); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "comment deleted"})
+}
+
+// ListAnnotations returns the cell-level provenance annotations on the
+// file's current version.
+func (ctl *FileController) ListAnnotations(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	annotations, err := ctl.Service.ListAnnotations(paramUint(c, "id"), claims.UserID, claims.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"annotations": annotations})
+}
+
+// SetAnnotation creates, updates, or clears the annotation on one cell.
+// An empty source and confidence clears it.
+func (ctl *FileController) SetAnnotation(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req struct {
+		RowID      uint   `json:"row_id" binding:"required"`
+		Column     string `json:"column" binding:"required"`
+		Source     string `json:"source"`
+		Confidence string `json:"confidence"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	annotation, err := ctl.Service.SetAnnotation(paramUint(c, "id"), req.RowID, req.Column, req.Source, req.Confidence, claims.UserID, claims.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	if annotation == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "annotation cleared"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"annotation": annotation})
+}
+
+// StripSourceLabels migrates a file imported before strict mode: baked
+// "(... SOURCE)" suffixes are removed from values and recorded as
+// annotations instead.
+func (ctl *FileController) StripSourceLabels(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	stripped, err := ctl.Service.StripSourceLabels(paramUint(c, "id"), claims.UserID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stripped": stripped})
+}
+
+// GetOriginal returns a signed download URL for the raw bytes of a file
+// version as uploaded. ?version= selects a version; 0 or absent means
+// current.
+func (ctl *FileController) GetOriginal(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	version, _ := strconv.Atoi(c.Query("version"))
+	url, err := ctl.Service.OriginalDownloadURL(c.Request.Context(), paramUint(c, "id"), version, claims.UserID, claims.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"url": url})
+}
+
+// SetDefaultSort changes the file's server-side default row order; an
+// empty column reverts to upload order.
+func (ctl *FileController) SetDefaultSort(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req struct {
+		Column    string `json:"column"`
+		Direction string `json:"direction"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	file, err := ctl.Service.SetDefaultSort(paramUint(c, "id"), req.Column, req.Direction, claims.UserID, claims.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"file": file})
+}
+
+// TransferOwnership reassigns a file to a new owner (Admin only).
+func (ctl *FileController) TransferOwnership(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req struct {
+		UserID uint `json:"user_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	if err := ctl.Service.TransferOwnership(paramUint(c, "id"), req.UserID, claims.UserID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ownership transferred"})
+}
+
+func (ctl *FileController) GrantAccess(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req struct {
+		UserID     uint       `json:"user_id" binding:"required"`
+		Permission string     `json:"permission"`
+		ExpiresAt  *time.Time `json:"expires_at"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	if err := ctl.Service.GrantAccess(paramUint(c, "id"), req.UserID, req.Permission, req.ExpiresAt, claims.UserID, claims.Role); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "access granted"})
+}
+
+func (ctl *FileController) RevokeAccess(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	userID, _ := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err := ctl.Service.RevokeAccess(paramUint(c, "id"), uint(userID), claims.UserID, claims.Role); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "access revoked"})
+}
+
+func (ctl *FileController) CreateEditRequest(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var payload CreateEditRequestPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	request, err := ctl.Service.CreateEditRequest(c.Request.Context(), payload, claims.UserID, claims.Role, claims.FirstName, claims.LastName, claims.Communities)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"request": request})
+}
+
+func (ctl *FileController) UpdateEditRequest(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var payload UpdateEditRequestPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	request, err := ctl.Service.UpdateEditRequest(c.Request.Context(), paramUint(c, "id"), payload, claims.UserID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"request": request})
+}
+
+func (ctl *FileController) GetEditRequests(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	requests, err := ctl.Service.GetEditRequests(claims.UserID, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to list requests")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"requests": requests})
+}
+
+func (ctl *FileController) GetPendingEditRequests(c *gin.Context) {
+	requests, err := ctl.Service.GetPendingEditRequests()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to list pending requests")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"requests": requests})
+}
+
+func (ctl *FileController) ApproveEditRequest(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req struct {
+		Overrides []DetailOverride `json:"overrides"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	if err := ctl.Service.ApproveEditRequest(c.Request.Context(), paramUint(c, "id"), req.Overrides, claims.UserID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "request approved"})
+}
+
+// UpdateEditRequestDetails lets a reviewer fix, add or remove proposed
+// changes on a pending request before approving it.
+func (ctl *FileController) UpdateEditRequestDetails(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req struct {
+		Edits []DetailEdit `json:"edits" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	details, err := ctl.Service.UpdateEditRequestDetails(paramUint(c, "id"), req.Edits, claims.UserID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"details": details})
+}
+
+func (ctl *FileController) RejectEditRequest(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if err := ctl.Service.RejectEditRequest(paramUint(c, "id"), claims.UserID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "request rejected"})
+}
+
+// UndoEditRequest reverses an approved request's changes, reporting
+// which fields were restored and which conflicted with later edits.
+func (ctl *FileController) UndoEditRequest(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	report, err := ctl.Service.UndoEditRequest(c.Request.Context(), paramUint(c, "id"), claims.UserID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// GetRowHistory lists the approved changes applied to a row over time.
+func (ctl *FileController) GetRowHistory(c *gin.Context) {
+	history, err := ctl.Service.GetRowHistory(paramUint(c, "id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to list row history")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"history": history})
+}
+
+// UpdatePhotoMeta edits a gallery photo's caption and sort order.
+func (ctl *FileController) UpdatePhotoMeta(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var meta PhotoMetaUpdate
+	if err := c.ShouldBindJSON(&meta); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	photo, err := ctl.Service.UpdatePhotoMeta(paramUint(c, "id"), meta, claims.UserID, claims.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"photo": photo})
+}
+
+func (ctl *FileController) GetPhotosByRow(c *gin.Context) {
+	photos, err := ctl.Service.GetPhotosByRow(paramUint(c, "id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to list photos")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"photos": photos})
+}
+
+func (ctl *FileController) GetDocsByRow(c *gin.Context) {
+	docs, err := ctl.Service.GetDocsByRow(paramUint(c, "id"), c.Query("category"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to list documents")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"documents": docs})
+}
+
+// ListDocumentCategories returns the labels documents can be filed
+// under, for upload forms and filters.
+func (ctl *FileController) ListDocumentCategories(c *gin.Context) {
+	categories, err := ctl.Service.ListDocumentCategories()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to list document categories")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"categories": categories})
+}
+
+// AddDocumentCategory creates a document category label.
+func (ctl *FileController) AddDocumentCategory(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	category, err := ctl.Service.AddDocumentCategory(req.Name, claims.UserID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"category": category})
+}
+
+// DeleteDocumentCategory removes a document category label.
+func (ctl *FileController) DeleteDocumentCategory(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if err := ctl.Service.DeleteDocumentCategory(paramUint(c, "id"), claims.UserID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "category deleted"})
+}
+
+// StreamMedia proxies a stored GCS object to the client, honoring
+// single-range requests so large PDFs can be scrubbed without a full
+// download.
+func (ctl *FileController) StreamMedia(c *gin.Context) {
+	gsURL := c.Query("path")
+	if gsURL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "missing path")})
+		return
+	}
+	offset, length := int64(0), int64(-1)
+	partial := false
+	if h := c.GetHeader("Range"); h != "" {
+		offset, length, partial = media.ParseRange(h)
+	}
+	reader, attrs, err := ctl.Service.OpenMediaHandle(c.Request.Context(), gsURL, offset, length)
+	if errors.Is(err, media.ErrUnsatisfiableRange) {
+		if attrs != nil {
+			c.Header("Content-Range", fmt.Sprintf("bytes */%d", attrs.Size))
+		}
+		c.Status(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.T(c, "media not found")})
+		return
+	}
+	defer reader.Close()
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("Content-Disposition", media.ContentDisposition(media.Disposition(attrs.ContentType), attrs.Name))
+	if partial {
+		start, n := media.SatisfiedRange(offset, length, attrs.Size)
+		c.Header("Content-Range", media.ContentRange(start, n, attrs.Size))
+		c.DataFromReader(http.StatusPartialContent, n, attrs.ContentType, reader, nil)
+		return
+	}
+	c.DataFromReader(http.StatusOK, attrs.Size, attrs.ContentType, reader, nil)
+}
+
+func paramUint(c *gin.Context, name string) uint {
+	v, _ := strconv.ParseUint(c.Param(name), 10, 32)
+	return uint(v)
+}
+
+func respondServiceError(c *gin.Context, err error) {
+	var parse *ParseErrors
+	if errors.As(err, &parse) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  i18n.TErr(c, err),
+			"errors": parse.Issues,
+		})
+		return
+	}
+	var dup *DuplicateRequestError
+	if errors.As(err, &dup) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":               i18n.TErr(c, err),
+			"existing_request_id": dup.ExistingID,
+		})
+		return
+	}
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrFileNotFound), errors.Is(err, ErrRequestNotFound),
+		errors.Is(err, ErrRowNotFound), errors.Is(err, ErrClaimNotFound),
+		errors.Is(err, ErrUploadNotFound), errors.Is(err, ErrComputedNotFound),
+		errors.Is(err, ErrPhotoNotFound), errors.Is(err, ErrCategoryNotFound),
+		errors.Is(err, ErrSnapshotNotFound), errors.Is(err, ErrCommentNotFound),
+		errors.Is(err, ErrNoOriginal):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrAccessDenied), errors.Is(err, ErrNotRequestOwner),
+		errors.Is(err, ErrCannotUpload), errors.Is(err, ErrEmailUnverified),
+		errors.Is(err, ErrNotCommentOwner):
+		status = http.StatusForbidden
+	case errors.Is(err, ErrNotPending), errors.Is(err, ErrNotDraft), errors.Is(err, ErrNotApproved),
+		errors.Is(err, ErrUnsupported),
+		errors.Is(err, ErrInvalidColumns), errors.Is(err, ErrInvalidExpression),
+		errors.Is(err, ErrInvalidAggregate), errors.Is(err, ErrVersionNotArchived),
+		errors.Is(err, ErrUnknownCategory), errors.Is(err, ErrInvalidSort),
+		errors.Is(err, ErrEmptyComment), errors.Is(err, ErrInvalidAnnotation):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrPayloadTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrClaimExists), errors.Is(err, ErrColumnNameTaken),
+		errors.Is(err, ErrCategoryExists), errors.Is(err, ErrIdenticalUpload):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{"error": i18n.TErr(c, err)})
+}
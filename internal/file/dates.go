@@ -0,0 +1,168 @@
+package file
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateColumnThreshold is the fraction of a column's non-empty values
+// that must look like dates before the column is treated as one; below
+// it the column is left untouched.
+const dateColumnThreshold = 0.8
+
+// dateOriginalSuffix is the shadow key the original value is kept under
+// in RowData when a cell is rewritten, so nothing is lost.
+const dateOriginalSuffix = "__original"
+
+var (
+	isoDateRe   = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	slashDateRe = regexp.MustCompile(`^(\d{1,2})/(\d{1,2})/(\d{4})$`)
+	serialRe    = regexp.MustCompile(`^\d{4,5}$`)
+)
+
+// excelEpoch is day zero of Excel's 1900 date system.
+var excelEpoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+// DateColumnReport describes what date normalization did to one column.
+// An ambiguous column — every slashed value could be read either
+// day-first or month-first — is left untouched and flagged for manual
+// resolution.
+type DateColumnReport struct {
+	Column     string `json:"column"`
+	Format     string `json:"format"`
+	Normalized int    `json:"normalized"`
+	Ambiguous  bool   `json:"ambiguous,omitempty"`
+	Example    string `json:"example,omitempty"`
+}
+
+// DateReport lists the columns the optional date pass touched or
+// flagged.
+type DateReport struct {
+	Columns []DateColumnReport `json:"columns"`
+}
+
+// normalizeDates detects date-like columns and rewrites their values to
+// ISO 8601 in place, keeping each original under a shadow key. Slash
+// dates take a per-column day-first/month-first decision from the
+// values that disambiguate it (a 13+ in either position); Excel serial
+// numbers are only trusted in columns whose header mentions "date",
+// since bare 4-digit integers are usually years or identifiers.
+func normalizeDates(sheet *parsedSheet) *DateReport {
+	report := &DateReport{}
+	for _, column := range sheet.Headers {
+		if col := normalizeDateColumn(sheet, column); col != nil {
+			report.Columns = append(report.Columns, *col)
+		}
+	}
+	if len(report.Columns) == 0 {
+		return nil
+	}
+	return report
+}
+
+func normalizeDateColumn(sheet *parsedSheet, column string) *DateColumnReport {
+	headerIsDate := strings.Contains(strings.ToLower(column), "date")
+	nonEmpty, dateLike, slashCount := 0, 0, 0
+	dayFirst, monthFirst := false, false
+	for _, row := range sheet.Rows {
+		value := strings.TrimSpace(row[column])
+		if value == "" {
+			continue
+		}
+		nonEmpty++
+		switch {
+		case isoDateRe.MatchString(value):
+			dateLike++
+		case slashDateRe.MatchString(value):
+			dateLike++
+			slashCount++
+			m := slashDateRe.FindStringSubmatch(value)
+			first, _ := strconv.Atoi(m[1])
+			second, _ := strconv.Atoi(m[2])
+			if first > 12 {
+				dayFirst = true
+			}
+			if second > 12 {
+				monthFirst = true
+			}
+		case headerIsDate && serialRe.MatchString(value):
+			dateLike++
+		}
+	}
+	if nonEmpty == 0 || float64(dateLike)/float64(nonEmpty) < dateColumnThreshold {
+		return nil
+	}
+
+	col := &DateColumnReport{Column: column}
+	switch {
+	case dayFirst && monthFirst:
+		col.Ambiguous = true
+		col.Format = "mixed day-first and month-first"
+	case dayFirst:
+		col.Format = "DD/MM/YYYY"
+	case monthFirst:
+		col.Format = "MM/DD/YYYY"
+	case slashCount > 0:
+		// Every slashed value reads both ways (e.g. 03/04/1952), so the
+		// whole column is flagged rather than guessed at.
+		col.Ambiguous = true
+		col.Format = "ambiguous D/M/YYYY"
+	default:
+		col.Format = "ISO 8601"
+	}
+
+	for _, row := range sheet.Rows {
+		value := strings.TrimSpace(row[column])
+		if value == "" || isoDateRe.MatchString(value) {
+			continue
+		}
+		if m := slashDateRe.FindStringSubmatch(value); m != nil {
+			if col.Ambiguous {
+				if col.Example == "" {
+					col.Example = value
+				}
+				continue
+			}
+			first, _ := strconv.Atoi(m[1])
+			second, _ := strconv.Atoi(m[2])
+			year, _ := strconv.Atoi(m[3])
+			day, month := first, second
+			if monthFirst {
+				day, month = second, first
+			}
+			normalized, ok := isoDate(year, month, day)
+			if !ok {
+				continue
+			}
+			row[column+dateOriginalSuffix] = value
+			row[column] = normalized
+			col.Normalized++
+			continue
+		}
+		if headerIsDate && serialRe.MatchString(value) {
+			serial, _ := strconv.Atoi(value)
+			row[column+dateOriginalSuffix] = value
+			row[column] = excelEpoch.AddDate(0, 0, serial).Format("2006-01-02")
+			col.Normalized++
+		}
+	}
+	if col.Normalized == 0 && !col.Ambiguous {
+		return nil
+	}
+	return col
+}
+
+// isoDate validates the parts as a real calendar date and formats them.
+func isoDate(year, month, day int) (string, bool) {
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return "", false
+	}
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if t.Year() != year || int(t.Month()) != month || t.Day() != day {
+		return "", false
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", year, month, day), true
+}
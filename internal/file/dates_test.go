@@ -0,0 +1,74 @@
+package file
+
+import "testing"
+
+func sheetWithColumn(column string, values []string) *parsedSheet {
+	sheet := &parsedSheet{Headers: []string{column, "Name"}}
+	for i, v := range values {
+		sheet.Rows = append(sheet.Rows, map[string]string{column: v, "Name": string(rune('A' + i))})
+	}
+	return sheet
+}
+
+func TestNormalizeDatesDayFirst(t *testing.T) {
+	sheet := sheetWithColumn("Birth Date", []string{"25/12/1950", "03/04/1952", "1960-01-02"})
+	report := normalizeDates(sheet)
+	if report == nil || len(report.Columns) != 1 {
+		t.Fatalf("report = %+v, want one column", report)
+	}
+	col := report.Columns[0]
+	if col.Format != "DD/MM/YYYY" || col.Normalized != 2 || col.Ambiguous {
+		t.Errorf("column report = %+v", col)
+	}
+	if got := sheet.Rows[0]["Birth Date"]; got != "1950-12-25" {
+		t.Errorf("row 0 = %q, want 1950-12-25", got)
+	}
+	if got := sheet.Rows[1]["Birth Date"]; got != "1952-04-03" {
+		t.Errorf("row 1 = %q, want 1952-04-03", got)
+	}
+	// The original value survives under the shadow key.
+	if got := sheet.Rows[0]["Birth Date__original"]; got != "25/12/1950" {
+		t.Errorf("shadow key = %q, want 25/12/1950", got)
+	}
+}
+
+func TestNormalizeDatesAmbiguousColumnLeftAlone(t *testing.T) {
+	sheet := sheetWithColumn("Date Joined", []string{"03/04/1952", "05/06/1960"})
+	report := normalizeDates(sheet)
+	if report == nil || len(report.Columns) != 1 {
+		t.Fatalf("report = %+v, want one flagged column", report)
+	}
+	col := report.Columns[0]
+	if !col.Ambiguous || col.Normalized != 0 || col.Example == "" {
+		t.Errorf("column report = %+v", col)
+	}
+	if got := sheet.Rows[0]["Date Joined"]; got != "03/04/1952" {
+		t.Errorf("ambiguous value rewritten to %q", got)
+	}
+}
+
+func TestNormalizeDatesExcelSerials(t *testing.T) {
+	// Serial 21916 is 1960-01-01 in Excel's 1900 date system.
+	sheet := sheetWithColumn("Date of Birth", []string{"21916"})
+	report := normalizeDates(sheet)
+	if report == nil {
+		t.Fatal("serial column in a date header not normalized")
+	}
+	if got := sheet.Rows[0]["Date of Birth"]; got != "1960-01-01" {
+		t.Errorf("serial = %q, want 1960-01-01", got)
+	}
+
+	// The same values under a non-date header are left alone: bare
+	// 4-5 digit integers are usually years or identifiers.
+	sheet = sheetWithColumn("Member ID", []string{"21916"})
+	if report := normalizeDates(sheet); report != nil {
+		t.Errorf("identifier column treated as dates: %+v", report)
+	}
+}
+
+func TestNormalizeDatesSkipsNonDateColumns(t *testing.T) {
+	sheet := sheetWithColumn("Community", []string{"Moose Factory", "Fort Albany", "12/05/1950"})
+	if report := normalizeDates(sheet); report != nil {
+		t.Errorf("below-threshold column normalized: %+v", report)
+	}
+}
@@ -0,0 +1,1009 @@
+package file
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/media"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+	"github.com/datanorthnordik/nordikdriveapi/internal/notifications"
+	"github.com/datanorthnordik/nordikdriveapi/internal/util"
+)
+
+var (
+	ErrRequestNotFound = errors.New("edit request not found")
+	ErrNotPending      = errors.New("edit request is not pending")
+	ErrNotApproved     = errors.New("edit request is not approved")
+	ErrNotDraft        = errors.New("edit request is not a draft")
+	ErrNotRequestOwner = errors.New("edit request belongs to another user")
+	ErrPayloadTooLarge = errors.New("upload exceeds the allowed size")
+)
+
+// DuplicateRequestError reports that the user already has a pending
+// request touching the same fields of the same row, so a double-submit
+// can point at the original instead of creating a second review.
+type DuplicateRequestError struct {
+	ExistingID uint
+}
+
+func (e *DuplicateRequestError) Error() string {
+	return "a pending edit request for this row already exists"
+}
+
+// EditRequestChange is one proposed field change in the create payload.
+type EditRequestChange struct {
+	FieldName string `json:"field_name" binding:"required"`
+	OldValue  string `json:"old_value"`
+	NewValue  string `json:"new_value"`
+}
+
+// EditRequestUpload is a base64-encoded photo or document in the create
+// payload.
+type EditRequestUpload struct {
+	Filename         string `json:"filename" binding:"required"`
+	ContentType      string `json:"content_type"`
+	Data             string `json:"data" binding:"required"`
+	DocumentCategory string `json:"document_category"`
+}
+
+// CreateEditRequestPayload is the body of POST /api/file/edit/request.
+// Draft saves the request without submitting it for review.
+type CreateEditRequestPayload struct {
+	FileID      uint                `json:"file_id" binding:"required"`
+	RowID       uint                `json:"row_id" binding:"required"`
+	Draft       bool                `json:"draft"`
+	Changes     []EditRequestChange `json:"changes"`
+	PhotosInApp []EditRequestUpload `json:"photos_in_app"`
+	Documents   []EditRequestUpload `json:"documents"`
+}
+
+// UpdateEditRequestPayload is the body of PUT /api/file/edit/request/:id.
+// Changes replace the draft's details; media is appended. Submit turns
+// the draft into a pending request.
+type UpdateEditRequestPayload struct {
+	Submit      bool                `json:"submit"`
+	Changes     []EditRequestChange `json:"changes"`
+	PhotosInApp []EditRequestUpload `json:"photos_in_app"`
+	Documents   []EditRequestUpload `json:"documents"`
+}
+
+// EditRequestWithDetails is a request joined with its proposed changes
+// for review screens.
+type EditRequestWithDetails struct {
+	models.FileEditRequest
+	Details []models.FileEditRequestDetail `json:"details"`
+}
+
+// CreateEditRequest stores a proposed correction and uploads its media to
+// GCS under the temp prefix pending approval. Requires contributor
+// permission on private files. The returned value includes the created
+// details with their IDs.
+func (s *FileService) CreateEditRequest(ctx context.Context, payload CreateEditRequestPayload, userID uint, role, firstName, lastName string, communities []string) (*EditRequestWithDetails, error) {
+	var file models.File
+	if err := s.DB.First(&file, payload.FileID).Error; err != nil {
+		return nil, ErrFileNotFound
+	}
+	if !s.HasFilePermission(&file, userID, role, PermissionContributor) {
+		return nil, ErrAccessDenied
+	}
+	if err := s.ensureVerified(userID, role); err != nil {
+		return nil, err
+	}
+	if err := s.checkUploadSizes(payload.PhotosInApp, payload.Documents); err != nil {
+		return nil, err
+	}
+	if err := s.validateDocumentCategories(payload.Documents); err != nil {
+		return nil, err
+	}
+
+	status := "pending"
+	if payload.Draft {
+		status = "draft"
+	}
+	if status == "pending" {
+		if err := s.checkDuplicateRequest(payload.FileID, payload.RowID, userID, payload.Changes); err != nil {
+			return nil, err
+		}
+	}
+	request := models.FileEditRequest{
+		FileID:            payload.FileID,
+		RowID:             payload.RowID,
+		Status:            status,
+		RequestedBy:       userID,
+		FirstName:         firstName,
+		LastName:          lastName,
+		UploaderCommunity: joinCommunities(communities),
+	}
+	var details []models.FileEditRequestDetail
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&request).Error; err != nil {
+			return fmt.Errorf("create edit request: %w", err)
+		}
+		var err error
+		details, err = insertEditRequestDetails(tx, request.ID, payload.Changes)
+		if err != nil {
+			return err
+		}
+		// Media is part of the same transaction: if an upload or its
+		// row fails, the request rolls back with it, so a retry is not
+		// blocked by the duplicate check on an orphaned request.
+		return s.storeEditRequestMedia(ctx, tx, &request, payload.PhotosInApp, payload.Documents, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logs.Record(userID, "CREATE_EDIT_REQUEST", fmt.Sprintf("request=%d file=%d row=%d", request.ID, request.FileID, request.RowID))
+	return &EditRequestWithDetails{FileEditRequest: request, Details: details}, nil
+}
+
+// insertEditRequestDetails batch-inserts the proposed changes for a
+// request; the returned rows carry their database IDs. One multi-row
+// insert instead of a round trip per field keeps large corrections fast.
+func insertEditRequestDetails(tx *gorm.DB, requestID uint, changes []EditRequestChange) ([]models.FileEditRequestDetail, error) {
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	details := make([]models.FileEditRequestDetail, 0, len(changes))
+	for _, change := range changes {
+		details = append(details, models.FileEditRequestDetail{
+			RequestID: requestID,
+			FieldName: change.FieldName,
+			OldValue:  change.OldValue,
+			NewValue:  change.NewValue,
+		})
+	}
+	if err := tx.CreateInBatches(details, insertBatchSize).Error; err != nil {
+		return nil, fmt.Errorf("create edit request details: %w", err)
+	}
+	return details, nil
+}
+
+// checkDuplicateRequest returns a DuplicateRequestError when the user
+// already has a pending request on the same row that touches any of the
+// proposed fields (or any pending request on the row, for media-only
+// submissions). Users double-submit when the first attempt seems slow;
+// a second review of the same change only confuses admins.
+func (s *FileService) checkDuplicateRequest(fileID, rowID, userID uint, changes []EditRequestChange) error {
+	q := s.DB.Model(&models.FileEditRequest{}).
+		Where("file_id = ? AND row_id = ? AND requested_by = ? AND status = ?",
+			fileID, rowID, userID, "pending")
+	if len(changes) > 0 {
+		fields := make([]string, 0, len(changes))
+		for _, change := range changes {
+			fields = append(fields, change.FieldName)
+		}
+		q = q.Where("id IN (?)", s.DB.Model(&models.FileEditRequestDetail{}).
+			Select("request_id").Where("field_name IN ?", fields))
+	}
+	var existing models.FileEditRequest
+	err := q.Select("id").First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return &DuplicateRequestError{ExistingID: existing.ID}
+}
+
+// storeEditRequestMedia uploads a request's photos and documents in one
+// concurrent batch and records the resulting rows on tx. When a row
+// insert fails the uploaded objects are deleted, so the caller's
+// transaction can roll back without stranding media in the bucket.
+func (s *FileService) storeEditRequestMedia(ctx context.Context, tx *gorm.DB, request *models.FileEditRequest, photos, documents []EditRequestUpload, userID uint) error {
+	uploads := make([]EditRequestUpload, 0, len(photos)+len(documents))
+	uploads = append(uploads, photos...)
+	uploads = append(uploads, documents...)
+	if len(uploads) == 0 {
+		return nil
+	}
+	gsURLs, err := s.uploadEditRequestMediaBatch(ctx, request, uploads)
+	if err != nil {
+		return err
+	}
+
+	photoRows := make([]models.FileEditRequestPhoto, 0, len(photos))
+	for i := range photos {
+		photoRows = append(photoRows, models.FileEditRequestPhoto{
+			RequestID:  request.ID,
+			FileID:     request.FileID,
+			RowID:      request.RowID,
+			GCSPath:    gsURLs[i],
+			Status:     "pending",
+			UploadedBy: userID,
+		})
+	}
+	docRows := make([]models.FileEditRequestDocument, 0, len(documents))
+	for i, upload := range documents {
+		docRows = append(docRows, models.FileEditRequestDocument{
+			RequestID:        request.ID,
+			FileID:           request.FileID,
+			RowID:            request.RowID,
+			GCSPath:          gsURLs[len(photos)+i],
+			DocumentCategory: upload.DocumentCategory,
+			UploadedBy:       userID,
+		})
+	}
+	if len(photoRows) > 0 {
+		if err := tx.CreateInBatches(photoRows, insertBatchSize).Error; err != nil {
+			s.deleteUploadedMedia(ctx, gsURLs)
+			return fmt.Errorf("create edit request photos: %w", err)
+		}
+	}
+	if len(docRows) > 0 {
+		if err := tx.CreateInBatches(docRows, insertBatchSize).Error; err != nil {
+			s.deleteUploadedMedia(ctx, gsURLs)
+			return fmt.Errorf("create edit request documents: %w", err)
+		}
+	}
+	return nil
+}
+
+// deleteUploadedMedia best-effort removes objects uploaded for a request
+// whose rows could not be recorded. A leak here only costs storage, so
+// failures are logged rather than propagated.
+func (s *FileService) deleteUploadedMedia(ctx context.Context, gsURLs []string) {
+	var objects []string
+	for _, gsURL := range gsURLs {
+		if _, object, err := util.ParseGSURL(gsURL); err == nil {
+			objects = append(objects, object)
+		}
+	}
+	if len(objects) == 0 {
+		return
+	}
+	if err := deleteGCSObjectsHook(ctx, s.Config.GCSBucket, objects); err != nil {
+		log.Printf("file: cleanup of %d uploaded media objects failed: %v", len(objects), err)
+	}
+}
+
+// checkUploadSizes enforces the per-item and per-request decoded size
+// limits before any bytes are decoded or sent to GCS.
+func (s *FileService) checkUploadSizes(groups ...[]EditRequestUpload) error {
+	total := 0
+	for _, group := range groups {
+		for _, upload := range group {
+			size := base64.StdEncoding.DecodedLen(len(upload.Data))
+			if s.Config.MaxEditUploadBytes > 0 && size > s.Config.MaxEditUploadBytes {
+				return fmt.Errorf("%w: %s is %d bytes (limit %d)",
+					ErrPayloadTooLarge, upload.Filename, size, s.Config.MaxEditUploadBytes)
+			}
+			total += size
+		}
+	}
+	if s.Config.MaxEditUploadTotalBytes > 0 && total > s.Config.MaxEditUploadTotalBytes {
+		return fmt.Errorf("%w: request uploads total %d bytes (limit %d)",
+			ErrPayloadTooLarge, total, s.Config.MaxEditUploadTotalBytes)
+	}
+	return nil
+}
+
+// editUploadConcurrency bounds how many edit-request media files upload
+// to GCS at once, mirroring formsubmission.uploadConcurrency.
+const editUploadConcurrency = 4
+
+// uploadEditRequestMedia streams the base64 payload through a decoder to
+// GCS so large uploads are never materialized in memory.
+func (s *FileService) uploadEditRequestMedia(ctx context.Context, request *models.FileEditRequest, upload EditRequestUpload) (string, error) {
+	object := fmt.Sprintf("%srequest_%d/%s", TempPrefix, request.ID, util.SanitizeFilename(upload.Filename))
+	decoder := base64.NewDecoder(base64.StdEncoding, strings.NewReader(upload.Data))
+	gsURL, err := uploadToGCSHook(ctx, s.Config.GCSBucket, object, decoder, upload.ContentType)
+	if err != nil {
+		return "", fmt.Errorf("upload %s: %w", upload.Filename, err)
+	}
+	return gsURL, nil
+}
+
+// uploadEditRequestMediaBatch uploads every item with bounded
+// concurrency. Per-item failures are aggregated; on any failure the
+// objects that did upload are deleted so approval never promotes a
+// half-written batch.
+func (s *FileService) uploadEditRequestMediaBatch(ctx context.Context, request *models.FileEditRequest, uploads []EditRequestUpload) ([]string, error) {
+	sem := make(chan struct{}, editUploadConcurrency)
+	var wg sync.WaitGroup
+	errs := make([]error, len(uploads))
+	gsURLs := make([]string, len(uploads))
+
+	for i, upload := range uploads {
+		wg.Add(1)
+		go func(i int, upload EditRequestUpload) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			gsURLs[i], errs[i] = s.uploadEditRequestMedia(ctx, request, upload)
+		}(i, upload)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		var uploaded []string
+		for _, gsURL := range gsURLs {
+			if gsURL == "" {
+				continue
+			}
+			if _, object, parseErr := util.ParseGSURL(gsURL); parseErr == nil {
+				uploaded = append(uploaded, object)
+			}
+		}
+		if len(uploaded) > 0 {
+			if cleanupErr := deleteGCSObjectsHook(ctx, s.Config.GCSBucket, uploaded); cleanupErr != nil {
+				err = errors.Join(err, fmt.Errorf("rollback uploads: %w", cleanupErr))
+			}
+		}
+		return nil, err
+	}
+	return gsURLs, nil
+}
+
+// UpdateEditRequest lets the requester keep working on a draft: changes
+// replace the existing details, new media is appended, and Submit moves
+// the draft to pending for admin review. Only drafts can be updated;
+// pending and reviewed requests are immutable.
+func (s *FileService) UpdateEditRequest(ctx context.Context, requestID uint, payload UpdateEditRequestPayload, userID uint) (*models.FileEditRequest, error) {
+	var request models.FileEditRequest
+	if err := s.DB.First(&request, requestID).Error; err != nil {
+		return nil, ErrRequestNotFound
+	}
+	if request.RequestedBy != userID {
+		return nil, ErrNotRequestOwner
+	}
+	if request.Status != "draft" {
+		return nil, ErrNotDraft
+	}
+	if err := s.checkUploadSizes(payload.PhotosInApp, payload.Documents); err != nil {
+		return nil, err
+	}
+	if err := s.validateDocumentCategories(payload.Documents); err != nil {
+		return nil, err
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if payload.Changes != nil {
+			if err := tx.Where("request_id = ?", request.ID).
+				Delete(&models.FileEditRequestDetail{}).Error; err != nil {
+				return err
+			}
+			if _, err := insertEditRequestDetails(tx, request.ID, payload.Changes); err != nil {
+				return err
+			}
+		}
+		return s.storeEditRequestMedia(ctx, tx, &request, payload.PhotosInApp, payload.Documents, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if payload.Submit {
+		if err := s.DB.Model(&request).Update("status", "pending").Error; err != nil {
+			return nil, err
+		}
+		request.Status = "pending"
+		s.Logs.Record(userID, "SUBMIT_EDIT_REQUEST", fmt.Sprintf("request=%d", request.ID))
+	} else {
+		s.Logs.Record(userID, "UPDATE_EDIT_REQUEST_DRAFT", fmt.Sprintf("request=%d", request.ID))
+	}
+	return &request, nil
+}
+
+// GetEditRequests returns the requests a user has made, newest first,
+// each with its details. Stale requests are hidden unless explicitly
+// asked for via the status filter.
+func (s *FileService) GetEditRequests(userID uint, status string) ([]EditRequestWithDetails, error) {
+	q := s.DB.Where("requested_by = ?", userID)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	} else {
+		q = q.Where("status <> ?", "stale")
+	}
+	var requests []models.FileEditRequest
+	if err := q.Order("created_at DESC").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return s.attachDetails(requests)
+}
+
+// MarkStaleEditRequests flags pending requests untouched for more than
+// olderThanDays as stale and notifies their requesters, so the review
+// queue only shows requests someone still cares about.
+func (s *FileService) MarkStaleEditRequests(olderThanDays int) (int, error) {
+	cutoff := time.Now().AddDate(0, 0, -olderThanDays)
+	var requests []models.FileEditRequest
+	if err := s.DB.Where("status = ? AND updated_at < ?", "pending", cutoff).
+		Find(&requests).Error; err != nil {
+		return 0, err
+	}
+	for _, request := range requests {
+		if err := s.DB.Model(&request).Update("status", "stale").Error; err != nil {
+			return 0, err
+		}
+		if s.Notifier != nil {
+			s.Notifier.Notify([]uint{request.RequestedBy}, notifications.EventEditRequestStale,
+				"Edit request marked stale",
+				fmt.Sprintf("Your edit request #%d sat unreviewed for %d days and was marked stale. Resubmit it if the correction still applies.", request.ID, olderThanDays))
+		}
+	}
+	if len(requests) > 0 {
+		s.Logs.Record(0, "MARK_STALE_EDIT_REQUESTS", fmt.Sprintf("count=%d days=%d", len(requests), olderThanDays))
+	}
+	return len(requests), nil
+}
+
+// RunStaleEditRequestLoop applies the staleness policy once a day until
+// ctx is cancelled. Started from main as a goroutine when configured.
+func (s *FileService) RunStaleEditRequestLoop(ctx context.Context, olderThanDays int) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+	for {
+		if marked, err := s.MarkStaleEditRequests(olderThanDays); err != nil {
+			log.Printf("file: stale edit request pass failed: %v", err)
+		} else if marked > 0 {
+			log.Printf("file: marked %d edit requests stale after %d days", marked, olderThanDays)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetPendingEditRequests returns all pending requests for admin review.
+func (s *FileService) GetPendingEditRequests() ([]EditRequestWithDetails, error) {
+	var requests []models.FileEditRequest
+	if err := s.DB.Where("status = ?", "pending").
+		Order("created_at ASC").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return s.attachDetails(requests)
+}
+
+// attachDetails loads the details for a result set with one IN query
+// and groups them in memory, rather than a query per request — review
+// pages list hundreds of requests on busy days.
+func (s *FileService) attachDetails(requests []models.FileEditRequest) ([]EditRequestWithDetails, error) {
+	out := make([]EditRequestWithDetails, 0, len(requests))
+	if len(requests) == 0 {
+		return out, nil
+	}
+	ids := make([]uint, 0, len(requests))
+	for _, request := range requests {
+		ids = append(ids, request.ID)
+	}
+	var details []models.FileEditRequestDetail
+	if err := s.DB.Where("request_id IN ?", ids).Order("id").Find(&details).Error; err != nil {
+		return nil, err
+	}
+	byRequest := make(map[uint][]models.FileEditRequestDetail, len(requests))
+	for _, detail := range details {
+		byRequest[detail.RequestID] = append(byRequest[detail.RequestID], detail)
+	}
+	for _, request := range requests {
+		out = append(out, EditRequestWithDetails{FileEditRequest: request, Details: byRequest[request.ID]})
+	}
+	return out, nil
+}
+
+// DetailOverride lets an approving admin adjust a proposed new value at
+// approval time.
+type DetailOverride struct {
+	DetailID uint   `json:"detail_id" binding:"required"`
+	NewValue string `json:"new_value"`
+}
+
+// ApproveEditRequest applies a pending request's changes to the row,
+// promotes its media out of the temp prefix, and marks it approved.
+func (s *FileService) ApproveEditRequest(ctx context.Context, requestID uint, overrides []DetailOverride, adminID uint) error {
+	db := s.DB.WithContext(ctx)
+	var request models.FileEditRequest
+	if err := db.First(&request, requestID).Error; err != nil {
+		return ErrRequestNotFound
+	}
+	if request.Status != "pending" {
+		return ErrNotPending
+	}
+
+	var details []models.FileEditRequestDetail
+	if err := db.Where("request_id = ?", request.ID).Find(&details).Error; err != nil {
+		return err
+	}
+	overrideByID := make(map[uint]string, len(overrides))
+	for _, o := range overrides {
+		overrideByID[o.DetailID] = o.NewValue
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var row models.FileData
+		if err := tx.First(&row, request.RowID).Error; err != nil {
+			return fmt.Errorf("load row %d: %w", request.RowID, err)
+		}
+		rowData := map[string]string{}
+		if err := json.Unmarshal(row.RowData, &rowData); err != nil {
+			return fmt.Errorf("decode row %d: %w", request.RowID, err)
+		}
+		for _, detail := range details {
+			newValue := detail.NewValue
+			if override, ok := overrideByID[detail.ID]; ok {
+				newValue = override
+				if err := tx.Model(&detail).Update("new_value", override).Error; err != nil {
+					return err
+				}
+			}
+			rowData[detail.FieldName] = newValue
+		}
+		raw, err := json.Marshal(rowData)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&row).Update("row_data", datatypes.JSON(raw)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&request).Updates(map[string]interface{}{
+			"status":      "approved",
+			"approved_by": adminID,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.FileEditRequestPhoto{}).
+			Where("request_id = ?", request.ID).
+			Update("status", "approved").Error
+	})
+	if err != nil {
+		return fmt.Errorf("approve request %d: %w", requestID, err)
+	}
+
+	srcPrefix := fmt.Sprintf("%srequest_%d/", TempPrefix, request.ID)
+	dstPrefix := fmt.Sprintf("%srequest_%d/", PermanentPrefix, request.ID)
+	if err := moveGCSFolderHook(ctx, s.Config.GCSBucket, srcPrefix, dstPrefix); err != nil {
+		return fmt.Errorf("move approved media: %w", err)
+	}
+	if err := s.repointMedia(request.ID, srcPrefix, dstPrefix); err != nil {
+		return err
+	}
+
+	s.Logs.Record(adminID, "APPROVE_EDIT_REQUEST", fmt.Sprintf("request=%d", request.ID))
+	s.notifyRequestReviewed(&request, "approved")
+	return nil
+}
+
+// UndoReport summarises an undo pass: which fields were restored to
+// their old value and which were left alone because a later change
+// already overwrote them.
+type UndoReport struct {
+	RequestID uint     `json:"request_id"`
+	Reverted  []string `json:"reverted_fields"`
+	Conflicts []string `json:"conflicted_fields"`
+}
+
+// UndoEditRequest reverses an approved request's changes on the row.
+// A field is only restored while the cell still holds the request's
+// new value; fields a later edit touched are reported as conflicts and
+// left as they are. The request is marked reverted and its media moved
+// back under the temp prefix, mirroring approval.
+func (s *FileService) UndoEditRequest(ctx context.Context, requestID uint, adminID uint) (*UndoReport, error) {
+	var request models.FileEditRequest
+	if err := s.DB.First(&request, requestID).Error; err != nil {
+		return nil, ErrRequestNotFound
+	}
+	if request.Status != "approved" {
+		return nil, ErrNotApproved
+	}
+	var details []models.FileEditRequestDetail
+	if err := s.DB.Where("request_id = ?", request.ID).Find(&details).Error; err != nil {
+		return nil, err
+	}
+
+	report := &UndoReport{RequestID: request.ID}
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var row models.FileData
+		if err := tx.First(&row, request.RowID).Error; err != nil {
+			return fmt.Errorf("load row %d: %w", request.RowID, err)
+		}
+		rowData := map[string]string{}
+		if err := json.Unmarshal(row.RowData, &rowData); err != nil {
+			return fmt.Errorf("decode row %d: %w", request.RowID, err)
+		}
+		for _, detail := range details {
+			if rowData[detail.FieldName] != detail.NewValue {
+				report.Conflicts = append(report.Conflicts, detail.FieldName)
+				continue
+			}
+			rowData[detail.FieldName] = detail.OldValue
+			report.Reverted = append(report.Reverted, detail.FieldName)
+		}
+		raw, err := json.Marshal(rowData)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&row).Update("row_data", datatypes.JSON(raw)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&request).Update("status", "reverted").Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.FileEditRequestPhoto{}).
+			Where("request_id = ?", request.ID).
+			Update("status", "reverted").Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("undo request %d: %w", requestID, err)
+	}
+
+	// Mirror of the approval move: reverted media goes back under the
+	// temp prefix so it no longer sits with approved evidence.
+	srcPrefix := fmt.Sprintf("%srequest_%d/", PermanentPrefix, request.ID)
+	dstPrefix := fmt.Sprintf("%srequest_%d/", TempPrefix, request.ID)
+	if err := moveGCSFolderHook(ctx, s.Config.GCSBucket, srcPrefix, dstPrefix); err != nil {
+		return nil, fmt.Errorf("move reverted media: %w", err)
+	}
+	if err := s.repointMedia(request.ID, srcPrefix, dstPrefix); err != nil {
+		return nil, err
+	}
+
+	s.Logs.Record(adminID, "UNDO_EDIT_REQUEST",
+		fmt.Sprintf("request=%d reverted=%d conflicts=%d", request.ID, len(report.Reverted), len(report.Conflicts)))
+	s.notifyRequestReviewed(&request, "reverted")
+	return report, nil
+}
+
+// notifyRequestReviewed tells the requester the outcome of their request
+// and, on approval, tells verified claimants of the row that it changed.
+func (s *FileService) notifyRequestReviewed(request *models.FileEditRequest, outcome string) {
+	if s.Notifier == nil {
+		return
+	}
+	s.Notifier.Notify([]uint{request.RequestedBy}, notifications.EventEditRequestReviewed,
+		fmt.Sprintf("Edit request %s", outcome),
+		fmt.Sprintf("Your edit request for row %d was %s.", request.RowID, outcome))
+	if outcome != "approved" {
+		return
+	}
+	claimants, err := s.VerifiedClaimants(request.RowID)
+	if err != nil {
+		return
+	}
+	followers := claimants[:0]
+	for _, userID := range claimants {
+		if userID != request.RequestedBy {
+			followers = append(followers, userID)
+		}
+	}
+	if len(followers) > 0 {
+		s.Notifier.Notify(followers, notifications.EventRowChanged,
+			"A record you follow was updated",
+			fmt.Sprintf("Row %d was updated through an approved edit request.", request.RowID))
+	}
+}
+
+func (s *FileService) repointMedia(requestID uint, srcPrefix, dstPrefix string) error {
+	src := "gs://" + s.Config.GCSBucket + "/" + srcPrefix
+	dst := "gs://" + s.Config.GCSBucket + "/" + dstPrefix
+	if err := s.DB.Model(&models.FileEditRequestPhoto{}).
+		Where("request_id = ?", requestID).
+		Update("gcs_path", gorm.Expr("replace(gcs_path, ?, ?)", src, dst)).Error; err != nil {
+		return err
+	}
+	return s.DB.Model(&models.FileEditRequestDocument{}).
+		Where("request_id = ?", requestID).
+		Update("gcs_path", gorm.Expr("replace(gcs_path, ?, ?)", src, dst)).Error
+}
+
+// RepairReport summarises one repair-media pass over an approved
+// request whose GCS move previously failed part-way.
+type RepairReport struct {
+	RequestID uint     `json:"request_id"`
+	Moved     []string `json:"moved"`
+	Missing   []string `json:"missing"`
+}
+
+// RepairRequestMedia retries the temp-to-approved GCS move for an
+// approved request. The move is idempotent, so objects that already
+// made it across are untouched; DB paths still pointing at the temp
+// prefix are repointed afterwards. Media rows whose object exists under
+// neither prefix are reported as missing.
+func (s *FileService) RepairRequestMedia(ctx context.Context, requestID uint, adminID uint) (*RepairReport, error) {
+	var request models.FileEditRequest
+	if err := s.DB.First(&request, requestID).Error; err != nil {
+		return nil, ErrRequestNotFound
+	}
+	if request.Status != "approved" {
+		return nil, ErrNotApproved
+	}
+
+	srcPrefix := fmt.Sprintf("%srequest_%d/", TempPrefix, request.ID)
+	dstPrefix := fmt.Sprintf("%srequest_%d/", PermanentPrefix, request.ID)
+
+	stuck, err := listGCSFolderHook(ctx, s.Config.GCSBucket, srcPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(stuck) > 0 {
+		if err := moveGCSFolderHook(ctx, s.Config.GCSBucket, srcPrefix, dstPrefix); err != nil {
+			return nil, fmt.Errorf("move stuck media: %w", err)
+		}
+	}
+	if err := s.repointMedia(request.ID, srcPrefix, dstPrefix); err != nil {
+		return nil, err
+	}
+
+	moved, err := listGCSFolderHook(ctx, s.Config.GCSBucket, dstPrefix)
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[string]bool, len(moved))
+	for _, name := range moved {
+		present[name] = true
+	}
+
+	report := &RepairReport{RequestID: request.ID, Moved: stuck}
+	var photos []models.FileEditRequestPhoto
+	if err := s.DB.Where("request_id = ?", request.ID).Find(&photos).Error; err != nil {
+		return nil, err
+	}
+	var docs []models.FileEditRequestDocument
+	if err := s.DB.Where("request_id = ?", request.ID).Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(photos)+len(docs))
+	for _, p := range photos {
+		paths = append(paths, p.GCSPath)
+	}
+	for _, d := range docs {
+		paths = append(paths, d.GCSPath)
+	}
+	for _, gsURL := range paths {
+		if _, object, err := util.ParseGSURL(gsURL); err == nil && !present[object] {
+			report.Missing = append(report.Missing, gsURL)
+		}
+	}
+
+	s.Logs.Record(adminID, "REPAIR_REQUEST_MEDIA",
+		fmt.Sprintf("request=%d moved=%d missing=%d", request.ID, len(report.Moved), len(report.Missing)))
+	return report, nil
+}
+
+// RejectEditRequest marks a pending request rejected without touching the
+// row data.
+func (s *FileService) RejectEditRequest(requestID uint, adminID uint) error {
+	var request models.FileEditRequest
+	if err := s.DB.First(&request, requestID).Error; err != nil {
+		return ErrRequestNotFound
+	}
+	if request.Status != "pending" {
+		return ErrNotPending
+	}
+	if err := s.DB.Model(&request).Updates(map[string]interface{}{
+		"status":      "rejected",
+		"approved_by": adminID,
+	}).Error; err != nil {
+		return err
+	}
+	s.Logs.Record(adminID, "REJECT_EDIT_REQUEST", fmt.Sprintf("request=%d", request.ID))
+	s.notifyRequestReviewed(&request, "rejected")
+	return nil
+}
+
+// DetailEdit is one reviewer change to a pending request's details.
+// ID zero adds a new detail; Remove deletes the identified one;
+// otherwise the identified detail is rewritten.
+type DetailEdit struct {
+	ID        uint   `json:"id"`
+	FieldName string `json:"field_name"`
+	OldValue  string `json:"old_value"`
+	NewValue  string `json:"new_value"`
+	Remove    bool   `json:"remove"`
+}
+
+// UpdateEditRequestDetails lets an admin correct, add or remove detail
+// rows on a pending request before approving it, recording exactly what
+// changed. Returns the request's details after the edit.
+func (s *FileService) UpdateEditRequestDetails(requestID uint, edits []DetailEdit, adminID uint) ([]models.FileEditRequestDetail, error) {
+	var request models.FileEditRequest
+	if err := s.DB.First(&request, requestID).Error; err != nil {
+		return nil, ErrRequestNotFound
+	}
+	if request.Status != "pending" {
+		return nil, ErrNotPending
+	}
+
+	var changes []string
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		for _, edit := range edits {
+			switch {
+			case edit.Remove && edit.ID != 0:
+				res := tx.Where("id = ? AND request_id = ?", edit.ID, request.ID).
+					Delete(&models.FileEditRequestDetail{})
+				if res.Error != nil {
+					return res.Error
+				}
+				if res.RowsAffected > 0 {
+					changes = append(changes, fmt.Sprintf("removed detail %d", edit.ID))
+				}
+			case edit.ID != 0:
+				var detail models.FileEditRequestDetail
+				if err := tx.Where("id = ? AND request_id = ?", edit.ID, request.ID).
+					First(&detail).Error; err != nil {
+					return ErrRequestNotFound
+				}
+				changes = append(changes, fmt.Sprintf("detail %d %q new_value %q -> %q",
+					detail.ID, edit.FieldName, detail.NewValue, edit.NewValue))
+				detail.FieldName = edit.FieldName
+				detail.OldValue = edit.OldValue
+				detail.NewValue = edit.NewValue
+				if err := tx.Save(&detail).Error; err != nil {
+					return err
+				}
+			default:
+				detail := models.FileEditRequestDetail{
+					RequestID: request.ID,
+					FieldName: edit.FieldName,
+					OldValue:  edit.OldValue,
+					NewValue:  edit.NewValue,
+				}
+				if err := tx.Create(&detail).Error; err != nil {
+					return err
+				}
+				changes = append(changes, fmt.Sprintf("added %q = %q", edit.FieldName, edit.NewValue))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changes) > 0 {
+		s.Logs.Record(adminID, "EDIT_REQUEST_DETAILS",
+			fmt.Sprintf("request=%d; %s", request.ID, strings.Join(changes, "; ")))
+	}
+	var details []models.FileEditRequestDetail
+	if err := s.DB.Where("request_id = ?", request.ID).Order("id").Find(&details).Error; err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+// GetPhotosByRow returns approved photos attached to a row.
+// RowHistoryEntry is one approved field change in a row's correction
+// history, with who proposed it and who signed it off.
+type RowHistoryEntry struct {
+	RequestID         uint      `json:"request_id"`
+	FieldName         string    `json:"field_name"`
+	OldValue          string    `json:"old_value"`
+	NewValue          string    `json:"new_value"`
+	RequestedBy       uint      `json:"requested_by"`
+	FirstName         string    `json:"firstname"`
+	LastName          string    `json:"lastname"`
+	ApprovedBy        uint      `json:"approved_by"`
+	ApproverFirstName string    `json:"approver_firstname"`
+	ApproverLastName  string    `json:"approver_lastname"`
+	RequestedAt       time.Time `json:"requested_at"`
+	ApprovedAt        time.Time `json:"approved_at"`
+}
+
+// GetRowHistory returns every approved change applied to a row, oldest
+// first, so reviewers can read a record's full correction history in
+// one place.
+func (s *FileService) GetRowHistory(rowID uint) ([]RowHistoryEntry, error) {
+	var history []RowHistoryEntry
+	err := s.DB.Table("file_edit_request_details AS d").
+		Joins("JOIN file_edit_request AS r ON r.id = d.request_id").
+		Joins("LEFT JOIN users AS approver ON approver.id = r.approved_by").
+		Where("r.row_id = ? AND r.status = ?", rowID, "approved").
+		Select(`r.id AS request_id, d.field_name, d.old_value, d.new_value,
+			r.requested_by, r.first_name, r.last_name, r.approved_by,
+			approver.first_name AS approver_first_name, approver.last_name AS approver_last_name,
+			r.created_at AS requested_at, r.updated_at AS approved_at`).
+		Order("r.updated_at, d.id").
+		Scan(&history).Error
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (s *FileService) GetPhotosByRow(rowID uint) ([]models.FileEditRequestPhoto, error) {
+	var photos []models.FileEditRequestPhoto
+	if err := s.DB.Where("row_id = ? AND status = ?", rowID, "approved").
+		Order("sort_order, id").
+		Find(&photos).Error; err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+var ErrPhotoNotFound = errors.New("photo not found")
+
+// PhotoMetaUpdate carries curator edits to a gallery photo. Nil fields
+// are left unchanged.
+type PhotoMetaUpdate struct {
+	Caption   *string `json:"caption"`
+	SortOrder *int    `json:"sort_order"`
+}
+
+// UpdatePhotoMeta edits a photo's caption and gallery position. Manager
+// permission on the photo's file is required: arranging the gallery is
+// curation, not contribution.
+func (s *FileService) UpdatePhotoMeta(photoID uint, meta PhotoMetaUpdate, userID uint, role string) (*models.FileEditRequestPhoto, error) {
+	var photo models.FileEditRequestPhoto
+	if err := s.DB.First(&photo, photoID).Error; err != nil {
+		return nil, ErrPhotoNotFound
+	}
+	var file models.File
+	if err := s.DB.First(&file, photo.FileID).Error; err != nil {
+		return nil, ErrFileNotFound
+	}
+	if !s.HasFilePermission(&file, userID, role, PermissionManager) {
+		return nil, ErrAccessDenied
+	}
+	updates := map[string]interface{}{}
+	if meta.Caption != nil {
+		updates["caption"] = *meta.Caption
+	}
+	if meta.SortOrder != nil {
+		updates["sort_order"] = *meta.SortOrder
+	}
+	if len(updates) == 0 {
+		return &photo, nil
+	}
+	if err := s.DB.Model(&photo).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	s.Logs.Record(userID, "UPDATE_PHOTO_META", fmt.Sprintf("photo=%d", photoID))
+	return &photo, nil
+}
+
+// GetDocsByRow returns documents attached to a row via approved
+// requests, optionally narrowed to one category.
+func (s *FileService) GetDocsByRow(rowID uint, category string) ([]models.FileEditRequestDocument, error) {
+	q := s.DB.
+		Joins("JOIN file_edit_request ON file_edit_request.id = file_edit_request_documents.request_id").
+		Where("file_edit_request_documents.row_id = ? AND file_edit_request.status = ?", rowID, "approved")
+	if category != "" {
+		q = q.Where("file_edit_request_documents.document_category = ?", category)
+	}
+	var docs []models.FileEditRequestDocument
+	if err := q.Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// OpenMediaHandle opens a stored gs:// path for streaming to the client,
+// returning the reader and the object's attributes. offset and length
+// select a byte range for partial (Range) requests; 0 and -1 read the
+// whole object.
+func (s *FileService) OpenMediaHandle(ctx context.Context, gsURL string, offset, length int64) (*storage.Reader, *storage.ObjectAttrs, error) {
+	bucket, object, err := util.ParseGSURL(gsURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := newGCSClientHook(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("gcs client: %w", err)
+	}
+	handle := client.Bucket(bucket).Object(object)
+	attrs, err := handle.Attrs(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("stat %s: %w", gsURL, err)
+	}
+	if offset > 0 && offset >= attrs.Size {
+		return nil, attrs, media.ErrUnsatisfiableRange
+	}
+	reader, err := handle.NewRangeReader(ctx, offset, length)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open %s: %w", gsURL, err)
+	}
+	return reader, attrs, nil
+}
@@ -0,0 +1,73 @@
+package file
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/unicode/norm"
+)
+
+// EncodingReport tells the uploader what the import pipeline did to
+// their file's text: which encoding the bytes arrived in, how many rows
+// had cells converted to UTF-8, and how many had cells recomposed to
+// NFC so "é" compares equal no matter how the spreadsheet wrote it.
+type EncodingReport struct {
+	SourceEncoding string `json:"source_encoding"`
+	ConvertedRows  int    `json:"converted_rows"`
+	NormalizedRows int    `json:"normalized_rows"`
+}
+
+// decodeToUTF8 sniffs the stream's encoding and returns UTF-8 bytes.
+// Community spreadsheets commonly arrive as Windows-1252 exports, which
+// is also a superset of Latin-1, so that is the fallback when the bytes
+// are not valid UTF-8 and carry no BOM.
+func decodeToUTF8(r io.Reader) ([]byte, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("read upload: %w", err)
+	}
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return data[3:], "utf-8", nil
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}), bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		decoded, err := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder().Bytes(data)
+		if err != nil {
+			return nil, "", fmt.Errorf("decode utf-16: %w", err)
+		}
+		return decoded, "utf-16", nil
+	case utf8.Valid(data):
+		return data, "utf-8", nil
+	default:
+		decoded, err := charmap.Windows1252.NewDecoder().Bytes(data)
+		if err != nil {
+			return nil, "", fmt.Errorf("decode windows-1252: %w", err)
+		}
+		return decoded, "windows-1252", nil
+	}
+}
+
+// normalizeCell NFC-normalizes one cell value, reporting whether it
+// changed.
+func normalizeCell(value string) (string, bool) {
+	if norm.NFC.IsNormalString(value) {
+		return value, false
+	}
+	return norm.NFC.String(value), true
+}
+
+// hasNonASCII reports whether any byte in the record is outside ASCII —
+// with a converted file, those are the cells the conversion rewrote.
+func hasNonASCII(record []string) bool {
+	for _, value := range record {
+		for i := 0; i < len(value); i++ {
+			if value[i] >= 0x80 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package file
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+// dataETag hashes one version's rows into a strong validator for
+// If-None-Match. Hashing the content rather than a stored per-version
+// stamp means in-place changes — an approved edit request rewrites
+// row_data without bumping the version — invalidate the tag naturally.
+// The rows still get queried on a 304, but the multi-MB JSON body does
+// not go over the wire again.
+func dataETag(fileID uint, rows []models.FileData) string {
+	h := sha256.New()
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(fileID))
+	h.Write(buf[:])
+	for i := range rows {
+		binary.BigEndian.PutUint64(buf[:], uint64(rows[i].ID))
+		h.Write(buf[:])
+		h.Write(rows[i].RowData)
+	}
+	return fmt.Sprintf(`"%x"`, h.Sum(nil)[:16])
+}
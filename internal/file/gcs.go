@@ -0,0 +1,45 @@
+package file
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"cloud.google.com/go/storage"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/gcs"
+)
+
+// TempPrefix is where edit-request media lives until the request is
+// approved, after which it is moved under the permanent prefix.
+const TempPrefix = "temp/"
+
+// PermanentPrefix is where approved edit-request media lives.
+const PermanentPrefix = "approved/"
+
+// Hooks so tests can run without a real GCS client. The defaults
+// delegate to the shared instrumented client in internal/gcs; tests can
+// swap either these or gcs.Default.
+var (
+	newGCSClientHook = func(ctx context.Context) (*storage.Client, error) {
+		return gcs.NewRawClientHook(ctx)
+	}
+	uploadToGCSHook = func(ctx context.Context, bucket, object string, r io.Reader, contentType string) (string, error) {
+		return gcs.Default.Upload(ctx, bucket, object, r, contentType)
+	}
+	moveGCSFolderHook = func(ctx context.Context, bucket, srcPrefix, dstPrefix string) error {
+		return gcs.Default.MovePrefix(ctx, bucket, srcPrefix, dstPrefix)
+	}
+	deleteGCSFolderHook = func(ctx context.Context, bucket, prefix string) error {
+		return gcs.Default.DeletePrefix(ctx, bucket, prefix)
+	}
+	deleteGCSObjectsHook = func(ctx context.Context, bucket string, objects []string) error {
+		return gcs.Default.Delete(ctx, bucket, objects)
+	}
+	listGCSFolderHook = func(ctx context.Context, bucket, prefix string) ([]string, error) {
+		return gcs.Default.List(ctx, bucket, prefix)
+	}
+	signGCSURLHook = func(ctx context.Context, bucket, object string, expires time.Duration) (string, error) {
+		return gcs.Default.SignURL(ctx, bucket, object, expires)
+	}
+)
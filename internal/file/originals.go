@@ -0,0 +1,107 @@
+package file
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/media"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+	"github.com/datanorthnordik/nordikdriveapi/internal/util"
+)
+
+var ErrNoOriginal = errors.New("original upload was not archived")
+
+// OriginalsPrefix is where raw uploaded spreadsheets are archived when
+// STORE_ORIGINAL_UPLOADS is on.
+const OriginalsPrefix = "originals/"
+
+// originalURLTTL is how long a signed download link for an archived
+// original stays valid.
+const originalURLTTL = 15 * time.Minute
+
+// archiveOriginal uploads the raw bytes of an upload to
+// originals/<file_id>/<version>/<filename> and records the gs:// URL and
+// SHA-256 checksum on the version row. Best-effort: callers log failures
+// rather than failing the upload, since the normalized rows are already
+// saved.
+func (s *FileService) archiveOriginal(ctx context.Context, fileID uint, version int, filename string, r io.Reader) error {
+	if !s.Config.StoreOriginals || s.Config.GCSBucket == "" {
+		return nil
+	}
+	hash := sha256.New()
+	object := fmt.Sprintf("%s%d/%d/%s", OriginalsPrefix, fileID, version, util.SanitizeFilename(filename))
+	gsURL, err := uploadToGCSHook(ctx, s.Config.GCSBucket, object, io.TeeReader(r, hash), media.ContentType(filename))
+	if err != nil {
+		return fmt.Errorf("archive original: %w", err)
+	}
+	return s.DB.Model(&models.FileVersion{}).
+		Where("file_id = ? AND version = ?", fileID, version).
+		Updates(map[string]interface{}{
+			"original_url": gsURL,
+			"checksum":     hex.EncodeToString(hash.Sum(nil)),
+		}).Error
+}
+
+// archiveOriginalLogged wraps archiveOriginal for the call sites that
+// treat archiving as best-effort.
+func (s *FileService) archiveOriginalLogged(ctx context.Context, fileID uint, version int, filename string, r io.Reader) {
+	if err := s.archiveOriginal(ctx, fileID, version, filename, r); err != nil {
+		log.Printf("file: archive original for file %d v%d failed: %v", fileID, version, err)
+	}
+}
+
+// archiveOriginalHeader re-opens a multipart upload and archives it,
+// logging rather than propagating failures.
+func (s *FileService) archiveOriginalHeader(ctx context.Context, fileID uint, version int, header *multipart.FileHeader) {
+	if !s.Config.StoreOriginals || s.Config.GCSBucket == "" {
+		return
+	}
+	src, err := header.Open()
+	if err != nil {
+		log.Printf("file: archive original for file %d v%d failed: %v", fileID, version, err)
+		return
+	}
+	defer src.Close()
+	s.archiveOriginalLogged(ctx, fileID, version, header.Filename, src)
+}
+
+// OriginalDownloadURL returns a signed, time-limited URL for the raw
+// bytes of a file version as uploaded, enforcing private-file access.
+// Version 0 means the current version.
+func (s *FileService) OriginalDownloadURL(ctx context.Context, fileID uint, version int, userID uint, role string) (string, error) {
+	var file models.File
+	if err := s.DB.First(&file, fileID).Error; err != nil {
+		return "", ErrFileNotFound
+	}
+	if !s.CanAccessFile(&file, userID, role) {
+		return "", ErrAccessDenied
+	}
+	if version == 0 {
+		version = file.Version
+	}
+	var fv models.FileVersion
+	err := s.DB.Where("file_id = ? AND version = ?", fileID, version).First(&fv).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrNoOriginal
+	}
+	if err != nil {
+		return "", err
+	}
+	if fv.OriginalURL == "" {
+		return "", ErrNoOriginal
+	}
+	bucket, object, err := util.ParseGSURL(fv.OriginalURL)
+	if err != nil {
+		return "", err
+	}
+	return signGCSURLHook(ctx, bucket, object, originalURLTTL)
+}
@@ -0,0 +1,152 @@
+package file
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+const (
+	// similaritySampleSize caps how many rows per side are hashed when
+	// comparing a new upload against an existing file.
+	similaritySampleSize = 200
+	// headerCandidateThreshold prunes files whose headers barely overlap
+	// before row sampling touches the database.
+	headerCandidateThreshold = 0.5
+	// similarityReportThreshold is the combined score below which a
+	// candidate is not worth warning the uploader about.
+	similarityReportThreshold = 0.8
+)
+
+// SimilarFile describes an existing file a new upload closely resembles.
+// Scores are fractions in [0, 1].
+type SimilarFile struct {
+	FileID      uint    `json:"file_id"`
+	Filename    string  `json:"filename"`
+	Similarity  float64 `json:"similarity"`
+	HeaderMatch float64 `json:"header_match"`
+	RowMatch    float64 `json:"row_match"`
+}
+
+// findSimilarFiles compares a parsed upload against every live file:
+// header sets via Jaccard similarity, then sampled row hashes for the
+// candidates that survive. Matches come back highest score first.
+func (s *FileService) findSimilarFiles(sheet *parsedSheet) ([]SimilarFile, error) {
+	var files []models.File
+	if err := s.DB.Where("is_delete = ?", false).Find(&files).Error; err != nil {
+		return nil, err
+	}
+	newHeaders := normalizeHeaderSet(sheet.Headers)
+	newHashes := sampleRowHashes(sheet.Rows)
+
+	var matches []SimilarFile
+	for _, f := range files {
+		var cols []string
+		if err := json.Unmarshal(f.ColumnsOrder, &cols); err != nil {
+			continue
+		}
+		headerMatch := jaccard(newHeaders, normalizeHeaderSet(cols))
+		if headerMatch < headerCandidateThreshold {
+			continue
+		}
+		rowMatch, err := s.sampleRowOverlap(f.ID, f.Version, newHashes)
+		if err != nil {
+			return nil, err
+		}
+		score := (headerMatch + rowMatch) / 2
+		if score < similarityReportThreshold {
+			continue
+		}
+		matches = append(matches, SimilarFile{
+			FileID:      f.ID,
+			Filename:    f.Filename,
+			Similarity:  roundScore(score),
+			HeaderMatch: roundScore(headerMatch),
+			RowMatch:    roundScore(rowMatch),
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Similarity > matches[j].Similarity })
+	return matches, nil
+}
+
+// sampleRowOverlap reports what fraction of the upload's sampled row
+// hashes also appear in a sample of the existing file's current version.
+func (s *FileService) sampleRowOverlap(fileID uint, version int, newHashes map[[sha256.Size]byte]bool) (float64, error) {
+	if len(newHashes) == 0 {
+		return 0, nil
+	}
+	var rows []models.FileData
+	if err := s.DB.Where("file_id = ? AND version = ?", fileID, version).
+		Limit(similaritySampleSize).Find(&rows).Error; err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	existing := make(map[[sha256.Size]byte]bool, len(rows))
+	for _, r := range rows {
+		var row map[string]string
+		if err := json.Unmarshal(r.RowData, &row); err != nil {
+			continue
+		}
+		if hash, ok := rowHash(row); ok {
+			existing[hash] = true
+		}
+	}
+	shared := 0
+	for hash := range newHashes {
+		if existing[hash] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(newHashes)), nil
+}
+
+// sampleRowHashes hashes up to similaritySampleSize rows.
+func sampleRowHashes(rows []map[string]string) map[[sha256.Size]byte]bool {
+	hashes := make(map[[sha256.Size]byte]bool, similaritySampleSize)
+	for i, row := range rows {
+		if i >= similaritySampleSize {
+			break
+		}
+		if hash, ok := rowHash(row); ok {
+			hashes[hash] = true
+		}
+	}
+	return hashes
+}
+
+// normalizeHeaderSet lowercases and trims headers into a set so cosmetic
+// differences don't hide a match.
+func normalizeHeaderSet(headers []string) map[string]bool {
+	set := make(map[string]bool, len(headers))
+	for _, h := range headers {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h != "" {
+			set[h] = true
+		}
+	}
+	return set
+}
+
+// jaccard is intersection over union of two sets.
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for k := range a {
+		if b[k] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+func roundScore(score float64) float64 {
+	return math.Round(score*100) / 100
+}
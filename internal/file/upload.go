@@ -0,0 +1,314 @@
+package file
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var ErrUploadNotFound = errors.New("upload not found")
+
+// Stages a background upload moves through, reported by GetUploadStatus.
+const (
+	UploadStageQueued    = "queued"
+	UploadStageParsing   = "parsing"
+	UploadStageInserting = "inserting"
+	UploadStageDone      = "done"
+	UploadStageFailed    = "failed"
+)
+
+// uploadJobTTL is how long finished jobs stay pollable before pruning.
+const uploadJobTTL = time.Hour
+
+// UploadStatus is the point-in-time view of a background upload job.
+// Inserted/Total are only meaningful during and after the inserting
+// stage.
+type UploadStatus struct {
+	ID       string        `json:"id"`
+	Stage    string        `json:"stage"`
+	Inserted int           `json:"inserted"`
+	Total    int           `json:"total"`
+	Error    string        `json:"error,omitempty"`
+	File     *models.File  `json:"file,omitempty"`
+	Dedupe   *DedupeReport `json:"dedupe,omitempty"`
+	// Encoding reports text conversion/normalization done during
+	// parsing; nil when the file arrived as clean UTF-8.
+	Encoding *EncodingReport `json:"encoding,omitempty"`
+	// Dates reports what the optional date-normalization pass did,
+	// including columns flagged as ambiguous for manual resolution.
+	Dates *DateReport `json:"dates,omitempty"`
+	// Similar lists existing files the upload closely resembles, only
+	// populated when the uploader asked for the duplicate check.
+	Similar []SimilarFile `json:"similar,omitempty"`
+}
+
+// uploadJob tracks one background upload. Jobs live in memory only: a
+// restart loses in-flight jobs and the client re-uploads, matching how
+// the API treats other transient state (see public.RateLimit).
+type uploadJob struct {
+	mu        sync.Mutex
+	status    UploadStatus
+	userID    uint
+	createdAt time.Time
+}
+
+func (j *uploadJob) update(fn func(*UploadStatus)) {
+	j.mu.Lock()
+	fn(&j.status)
+	j.mu.Unlock()
+}
+
+type uploadTracker struct {
+	mu   sync.Mutex
+	jobs map[string]*uploadJob
+}
+
+func newUploadTracker() *uploadTracker {
+	return &uploadTracker{jobs: map[string]*uploadJob{}}
+}
+
+func (t *uploadTracker) add(id string, job *uploadJob) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for existingID, existing := range t.jobs {
+		existing.mu.Lock()
+		expired := time.Since(existing.createdAt) > uploadJobTTL &&
+			(existing.status.Stage == UploadStageDone || existing.status.Stage == UploadStageFailed)
+		existing.mu.Unlock()
+		if expired {
+			delete(t.jobs, existingID)
+		}
+	}
+	t.jobs[id] = job
+}
+
+func (t *uploadTracker) get(id string) *uploadJob {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.jobs[id]
+}
+
+// StartUpload buffers an uploaded spreadsheet to a temp file and parses
+// and inserts it in the background, returning an upload ID the client
+// polls via GetUploadStatus. Cheap validation (extension, filename
+// collisions) still fails synchronously.
+func (s *FileService) StartUpload(header *multipart.FileHeader, private, dedupe, checkDuplicates, fixDates, cleanWS, strictSources bool, userID uint, role string, communities []string) (string, error) {
+	if err := s.ensureCanUpload(userID, role); err != nil {
+		return "", err
+	}
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	if ext != ".csv" && ext != ".xlsx" {
+		return "", ErrUnsupported
+	}
+	var existing models.File
+	if err := s.DB.Where("filename = ?", header.Filename).First(&existing).Error; err == nil {
+		return "", ErrFilenameTaken
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		return "", fmt.Errorf("open upload: %w", err)
+	}
+	defer src.Close()
+	tmp, err := os.CreateTemp("", "nordikdrive-upload-*"+ext)
+	if err != nil {
+		return "", fmt.Errorf("buffer upload: %w", err)
+	}
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("buffer upload: %w", err)
+	}
+	tmp.Close()
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("generate upload id: %w", err)
+	}
+	id := hex.EncodeToString(buf)
+	job := &uploadJob{
+		status:    UploadStatus{ID: id, Stage: UploadStageQueued},
+		userID:    userID,
+		createdAt: time.Now(),
+	}
+	s.uploads.add(id, job)
+	go s.runUpload(job, tmp.Name(), header.Filename, header.Size, private, dedupe, checkDuplicates, fixDates, cleanWS, strictSources, userID, communities)
+	return id, nil
+}
+
+// GetUploadStatus returns the current state of a background upload. Only
+// the uploader or an admin may view it.
+func (s *FileService) GetUploadStatus(id string, userID uint, role string) (*UploadStatus, error) {
+	job := s.uploads.get(id)
+	if job == nil {
+		return nil, ErrUploadNotFound
+	}
+	if job.userID != userID && role != "Admin" {
+		return nil, ErrAccessDenied
+	}
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	status := job.status
+	return &status, nil
+}
+
+// runUpload performs the parse/insert pipeline SaveFilesMultipart does
+// synchronously, updating the job after every stage and insert batch.
+func (s *FileService) runUpload(job *uploadJob, tmpPath, filename string, size int64, private, dedupe, checkDuplicates, fixDates, cleanWS, strictSources bool, userID uint, communities []string) {
+	defer os.Remove(tmpPath)
+	fail := func(err error) {
+		job.update(func(st *UploadStatus) {
+			st.Stage = UploadStageFailed
+			st.Error = err.Error()
+		})
+	}
+
+	job.update(func(st *UploadStatus) { st.Stage = UploadStageParsing })
+	f, err := os.Open(tmpPath)
+	if err != nil {
+		fail(fmt.Errorf("open upload: %w", err))
+		return
+	}
+	var sheet *parsedSheet
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		sheet, err = parseCSVReader(f)
+	case ".xlsx":
+		sheet, err = parseExcelReader(f)
+		if err == nil && !strictSources {
+			bakeSourceLabels(sheet)
+		}
+	default:
+		err = ErrUnsupported
+	}
+	f.Close()
+	if err != nil {
+		fail(err)
+		return
+	}
+	if cleanWS {
+		cleanSheet(sheet)
+	}
+	var dedupeReport *DedupeReport
+	if dedupe {
+		dedupeReport = sheet.dedupe()
+	}
+	var dateReport *DateReport
+	if fixDates {
+		dateReport = normalizeDates(sheet)
+	}
+	if checkDuplicates {
+		// The warning is advisory: a failed comparison never blocks the
+		// upload itself.
+		similar, err := s.findSimilarFiles(sheet)
+		if err != nil {
+			log.Printf("file: duplicate check for %s failed: %v", filename, err)
+		} else if len(similar) > 0 {
+			job.update(func(st *UploadStatus) { st.Similar = similar })
+		}
+	}
+
+	checksum := ""
+	if f, err := os.Open(tmpPath); err == nil {
+		checksum, _ = checksumReader(f)
+		f.Close()
+	}
+
+	columns, _ := json.Marshal(sheet.Headers)
+	file := models.File{
+		Filename:          filename,
+		ColumnsOrder:      datatypes.JSON(columns),
+		InsertedBy:        userID,
+		UploaderCommunity: joinCommunities(communities),
+		Private:           private,
+		Rows:              len(sheet.Rows),
+		Size:              size,
+		Version:           1,
+	}
+
+	job.update(func(st *UploadStatus) {
+		st.Stage = UploadStageInserting
+		st.Total = len(sheet.Rows)
+	})
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&file).Error; err != nil {
+			return err
+		}
+		version := models.FileVersion{FileID: file.ID, Version: 1, CreatedBy: userID, Checksum: checksum}
+		if err := tx.Create(&version).Error; err != nil {
+			return err
+		}
+		rowIDs, err := insertRowsProgress(tx, file.ID, 1, sheet.Rows, func(inserted int) {
+			job.update(func(st *UploadStatus) { st.Inserted = inserted })
+		})
+		if err != nil {
+			return err
+		}
+		return storeImportAnnotations(tx, file.ID, rowIDs, sheet, userID)
+	})
+	if err != nil {
+		fail(fmt.Errorf("save file: %w", err))
+		return
+	}
+	if s.Config.StoreOriginals {
+		if src, err := os.Open(tmpPath); err == nil {
+			s.archiveOriginalLogged(context.Background(), file.ID, 1, filename, src)
+			src.Close()
+		}
+	}
+	s.Logs.Record(userID, "UPLOAD_FILE", file.Filename)
+	job.update(func(st *UploadStatus) {
+		st.Stage = UploadStageDone
+		st.Inserted = len(sheet.Rows)
+		st.File = &file
+		st.Dedupe = dedupeReport
+		st.Encoding = sheet.Encoding
+		st.Dates = dateReport
+	})
+}
+
+// insertRowsProgress inserts rows in insertBatchSize chunks, reporting
+// the running count after each batch and returning the new row IDs in
+// sheet order.
+func insertRowsProgress(tx *gorm.DB, fileID uint, version int, rows []map[string]string, progress func(inserted int)) ([]uint, error) {
+	ids := make([]uint, 0, len(rows))
+	for start := 0; start < len(rows); start += insertBatchSize {
+		end := start + insertBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		batch := make([]models.FileData, 0, end-start)
+		for _, row := range rows[start:end] {
+			raw, err := json.Marshal(row)
+			if err != nil {
+				return nil, err
+			}
+			batch = append(batch, models.FileData{FileID: fileID, Version: version, RowData: datatypes.JSON(raw)})
+		}
+		if err := tx.Create(&batch).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range batch {
+			ids = append(ids, row.ID)
+		}
+		progress(end)
+	}
+	return ids, nil
+}
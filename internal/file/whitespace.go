@@ -0,0 +1,56 @@
+package file
+
+import "strings"
+
+// invisibleReplacer rewrites the characters spreadsheets smuggle in
+// that are indistinguishable on screen but break equality matching in
+// edit approvals and community filters: non-breaking and narrow
+// no-break spaces become plain spaces, zero-width characters and the
+// BOM disappear.
+var invisibleReplacer = strings.NewReplacer(
+	"\u00a0", " ", // no-break space
+	"\u202f", " ", // narrow no-break space
+	"\u200b", "", // zero-width space
+	"\u200c", "", // zero-width non-joiner
+	"\u200d", "", // zero-width joiner
+	"\ufeff", "", // BOM / zero-width no-break space
+)
+
+// cleanValue strips invisible characters, trims the ends and collapses
+// internal runs of spaces to one.
+func cleanValue(value string) string {
+	value = invisibleReplacer.Replace(value)
+	value = strings.TrimSpace(value)
+	for strings.Contains(value, "  ") {
+		value = strings.ReplaceAll(value, "  ", " ")
+	}
+	return value
+}
+
+// cleanSheet runs cleanValue over the headers and every cell, returning
+// how many cells (headers included) changed.
+func cleanSheet(sheet *parsedSheet) int {
+	changed := 0
+	for i, h := range sheet.Headers {
+		if cleaned := cleanValue(h); cleaned != h {
+			// Rekey the rows, which were built under the dirty header.
+			for _, row := range sheet.Rows {
+				if v, ok := row[h]; ok {
+					row[cleaned] = v
+					delete(row, h)
+				}
+			}
+			sheet.Headers[i] = cleaned
+			changed++
+		}
+	}
+	for _, row := range sheet.Rows {
+		for k, v := range row {
+			if cleaned := cleanValue(v); cleaned != v {
+				row[k] = cleaned
+				changed++
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,39 @@
+package file
+
+import "testing"
+
+func TestCleanValue(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"  Moose  Factory ", "Moose Factory"},
+		{"Fort\u00a0Albany", "Fort Albany"},
+		{"\ufeffAttawapiskat\u200b", "Attawapiskat"},
+		{"already clean", "already clean"},
+	}
+	for _, tc := range cases {
+		if got := cleanValue(tc.in); got != tc.want {
+			t.Errorf("cleanValue(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestCleanSheetRekeysDirtyHeaders(t *testing.T) {
+	sheet := &parsedSheet{
+		Headers: []string{"Name ", "Community"},
+		Rows: []map[string]string{
+			{"Name ": "A  B", "Community": "Moose Factory"},
+		},
+	}
+	changed := cleanSheet(sheet)
+	if changed != 2 { // one header, one cell
+		t.Errorf("changed = %d, want 2", changed)
+	}
+	if sheet.Headers[0] != "Name" {
+		t.Errorf("header = %q, want Name", sheet.Headers[0])
+	}
+	if got := sheet.Rows[0]["Name"]; got != "A B" {
+		t.Errorf("cell under rekeyed header = %q, want A B", got)
+	}
+	if _, ok := sheet.Rows[0]["Name "]; ok {
+		t.Error("dirty header key still present in row")
+	}
+}
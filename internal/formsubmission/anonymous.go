@@ -0,0 +1,161 @@
+package formsubmission
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/mail"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var (
+	ErrAnonymousNotAllowed = errors.New("this form does not accept anonymous submissions")
+	ErrInvalidVerifyToken  = errors.New("invalid or already used verification link")
+)
+
+// verifyCaptchaHook screens anonymous submissions before any database
+// work. The default accepts everything; deployments exposed to abuse
+// wire a real verifier (hCaptcha, Turnstile, ...) here at startup.
+var verifyCaptchaHook = func(ctx context.Context, token, clientIP string) error {
+	return nil
+}
+
+// AnonymousSubmissionRequest is the body of the unauthenticated submit
+// endpoint. CaptchaToken is passed through to the captcha hook.
+type AnonymousSubmissionRequest struct {
+	FormKey      string            `json:"form_key" binding:"required"`
+	FormLabel    string            `json:"form_label"`
+	FileKey      string            `json:"file_key" binding:"required"`
+	RowID        uint              `json:"row_id" binding:"required"`
+	Email        string            `json:"email" binding:"required,email"`
+	Consent      bool              `json:"consent"`
+	CaptchaToken string            `json:"captcha_token"`
+	Details      []FormDetailInput `json:"details"`
+}
+
+// SubmitAnonymous stores an unauthenticated submission for a form that
+// allows them and emails a verification link to the given address. The
+// submission stays invisible to the admin queue until the link is
+// opened, so a forged email address produces nothing reviewable.
+func (s *FormSubmissionService) SubmitAnonymous(ctx context.Context, req AnonymousSubmissionRequest, clientIP string) error {
+	if err := verifyCaptchaHook(ctx, req.CaptchaToken, clientIP); err != nil {
+		return fmt.Errorf("captcha: %w", err)
+	}
+	definition, err := s.GetDefinition(req.FormKey)
+	if err != nil {
+		if errors.Is(err, ErrDefinitionNotFound) {
+			return ErrAnonymousNotAllowed
+		}
+		return err
+	}
+	if !definition.AllowAnonymous {
+		return ErrAnonymousNotAllowed
+	}
+	if err := s.validateAgainstDefinition(SaveFormSubmissionRequest{
+		FormKey: req.FormKey,
+		FileKey: req.FileKey,
+		RowID:   req.RowID,
+		Consent: req.Consent,
+		Status:  "submitted",
+		Details: req.Details,
+	}); err != nil {
+		return err
+	}
+
+	token, err := generateVerifyToken()
+	if err != nil {
+		return err
+	}
+	submission := models.FormSubmission{
+		FormKey:        req.FormKey,
+		FormLabel:      req.FormLabel,
+		FileKey:        req.FileKey,
+		RowID:          req.RowID,
+		Consent:        req.Consent,
+		Status:         "submitted",
+		AnonymousEmail: req.Email,
+		VerifyToken:    token,
+	}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&submission).Error; err != nil {
+			return err
+		}
+		for _, input := range req.Details {
+			detail := models.FormSubmissionDetail{
+				SubmissionID: submission.ID,
+				FieldKey:     input.FieldKey,
+				FieldLabel:   input.FieldLabel,
+				FieldType:    input.FieldType,
+				Value:        input.Value,
+			}
+			if err := tx.Create(&detail).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("store anonymous submission: %w", err)
+	}
+
+	if err := mail.Send(s.Config, req.Email, verificationMessage(s.Config.PublicBaseURL, token)); err != nil {
+		return fmt.Errorf("send verification email: %w", err)
+	}
+	s.Logs.Record(0, "CREATE_ANONYMOUS_SUBMISSION",
+		fmt.Sprintf("submission=%d form=%s ip=%s", submission.ID, submission.FormKey, clientIP))
+	return nil
+}
+
+// VerifyAnonymous confirms the emailed link, surfacing the submission to
+// the admin queue and triggering its receipt.
+func (s *FormSubmissionService) VerifyAnonymous(token string) error {
+	if token == "" {
+		return ErrInvalidVerifyToken
+	}
+	var submission models.FormSubmission
+	err := s.DB.Where("verify_token = ?", token).First(&submission).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidVerifyToken
+		}
+		return err
+	}
+	if err := s.DB.Model(&submission).Updates(map[string]interface{}{
+		"verify_token": "",
+		"verified_at":  time.Now(),
+	}).Error; err != nil {
+		return err
+	}
+	s.Logs.Record(0, "VERIFY_ANONYMOUS_SUBMISSION",
+		fmt.Sprintf("submission=%d form=%s", submission.ID, submission.FormKey))
+	go s.generateReceiptAsync(submission.ID, 0)
+	return nil
+}
+
+func generateVerifyToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// verificationMessage builds the confirmation email. Without a
+// configured base URL the token is sent alone so the frontend can offer
+// a paste-the-code fallback.
+func verificationMessage(baseURL, token string) *mail.Message {
+	if baseURL == "" {
+		return mail.Notification("Confirm your NordikDrive submission",
+			"Your confirmation code is "+token+". Enter it on the submission page to finish.")
+	}
+	link := strings.TrimRight(baseURL, "/") + "/public/api/forms/verify?token=" + token
+	return mail.Notification("Confirm your NordikDrive submission",
+		"Open "+link+" to confirm your submission. It will not be reviewed until you do.")
+}
@@ -0,0 +1,350 @@
+package formsubmission
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/i18n"
+	"github.com/datanorthnordik/nordikdriveapi/internal/media"
+	"github.com/datanorthnordik/nordikdriveapi/internal/middleware"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+	"github.com/datanorthnordik/nordikdriveapi/internal/public"
+)
+
+// Service is the surface FormSubmissionController depends on,
+// implemented by FormSubmissionService. Kept as an interface so
+// alternate backends can be wired in by the server container.
+type Service interface {
+	Upsert(ctx context.Context, req SaveFormSubmissionRequest, userID uint) (*models.FormSubmission, error)
+	GetByRowAndForm(formKey, fileKey string, rowID, userID uint) (*SubmissionWithDetails, error)
+	List(filter ListFilter) ([]SubmissionWithDetails, int64, error)
+	PatchSubmission(ctx context.Context, submissionID uint, req PatchSubmissionRequest, userID uint) (*SubmissionWithDetails, error)
+	DeleteSubmission(ctx context.Context, submissionID, userID uint, role string) error
+	SetReviewStatus(submissionID uint, status string, adminID uint) error
+	Receipt(ctx context.Context, submissionID, userID uint, role string) ([]byte, string, error)
+	SubmitAnonymous(ctx context.Context, req AnonymousSubmissionRequest, clientIP string) error
+	VerifyAnonymous(token string) error
+	SaveDefinition(req SaveDefinitionRequest, adminID uint) (*models.FormDefinition, error)
+	GetDefinition(formKey string) (*models.FormDefinition, error)
+	ListDefinitions() ([]models.FormDefinition, error)
+	DeleteDefinition(formKey string, adminID uint) error
+	ExportSubmissionsXLSX(formKey string) ([]byte, error)
+}
+
+var _ Service = (*FormSubmissionService)(nil)
+
+// FormSubmissionController exposes the /api/forms endpoints.
+type FormSubmissionController struct {
+	Service Service
+}
+
+func NewFormSubmissionController(service Service) *FormSubmissionController {
+	return &FormSubmissionController{Service: service}
+}
+
+func (ctl *FormSubmissionController) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/forms/submission", ctl.Upsert)
+	rg.PATCH("/forms/submission/:id", ctl.Patch)
+	rg.DELETE("/forms/submission/:id", ctl.Delete)
+	rg.GET("/forms/submission", ctl.GetByRowAndForm)
+	rg.GET("/forms/submission/:id/receipt", ctl.Receipt)
+	rg.GET("/forms/submissions", middleware.RequireRole("Admin"), ctl.List)
+	rg.PUT("/forms/submissions/:id/review", middleware.RequireRole("Admin"), ctl.Review)
+
+	rg.GET("/forms/:formKey/export", middleware.RequireRole("Admin"), ctl.ExportSubmissions)
+
+	rg.GET("/forms/definitions", ctl.ListDefinitions)
+	rg.GET("/forms/definitions/:formKey", ctl.GetDefinition)
+	rg.PUT("/forms/definitions", middleware.RequireRole("Admin"), ctl.SaveDefinition)
+	rg.DELETE("/forms/definitions/:formKey", middleware.RequireRole("Admin"), ctl.DeleteDefinition)
+}
+
+// RegisterPublicRoutes mounts the unauthenticated submission endpoints
+// on the public group. The submit route gets its own tight rate limit on
+// top of the group-wide one; verification links are cheap and
+// idempotent so the group limit is enough there.
+func (ctl *FormSubmissionController) RegisterPublicRoutes(rg *gin.RouterGroup) {
+	rg.POST("/forms/submission", public.RateLimit(5, time.Hour), ctl.SubmitAnonymous)
+	rg.GET("/forms/verify", ctl.VerifyAnonymous)
+}
+
+// SubmitAnonymous accepts a submission from a visitor without an
+// account for forms that allow it.
+func (ctl *FormSubmissionController) SubmitAnonymous(c *gin.Context) {
+	var req AnonymousSubmissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	if err := ctl.Service.SubmitAnonymous(c.Request.Context(), req, c.ClientIP()); err != nil {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":  validationErr.Error(),
+				"fields": validationErr.Fields,
+			})
+			return
+		}
+		if errors.Is(err, ErrAnonymousNotAllowed) {
+			c.JSON(http.StatusForbidden, gin.H{"error": i18n.TErr(c, err)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to save submission")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "check your email to confirm the submission"})
+}
+
+// VerifyAnonymous confirms the link mailed after an anonymous
+// submission.
+func (ctl *FormSubmissionController) VerifyAnonymous(c *gin.Context) {
+	if err := ctl.Service.VerifyAnonymous(c.Query("token")); err != nil {
+		if errors.Is(err, ErrInvalidVerifyToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to save submission")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "submission confirmed"})
+}
+
+func (ctl *FormSubmissionController) Upsert(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req SaveFormSubmissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	submission, err := ctl.Service.Upsert(c.Request.Context(), req, claims.UserID)
+	if err != nil {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":  validationErr.Error(),
+				"fields": validationErr.Fields,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"submission": submission})
+}
+
+// Patch autosaves part of a submission, optionally finalizing a draft.
+func (ctl *FormSubmissionController) Patch(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	submissionID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var req PatchSubmissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	submission, err := ctl.Service.PatchSubmission(c.Request.Context(), uint(submissionID), req, claims.UserID)
+	if err != nil {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":  validationErr.Error(),
+				"fields": validationErr.Fields,
+			})
+			return
+		}
+		if errors.Is(err, ErrSubmissionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": i18n.TErr(c, err)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"submission": submission})
+}
+
+// Delete removes a submission (owner or admin) including its stored GCS
+// objects.
+func (ctl *FormSubmissionController) Delete(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	submissionID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	err := ctl.Service.DeleteSubmission(c.Request.Context(), uint(submissionID), claims.UserID, claims.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrSubmissionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": i18n.TErr(c, err)})
+		case errors.Is(err, ErrNotSubmissionOwner):
+			c.JSON(http.StatusForbidden, gin.H{"error": i18n.TErr(c, err)})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.TErr(c, err)})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "submission deleted"})
+}
+
+func (ctl *FormSubmissionController) GetByRowAndForm(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	rowID, _ := strconv.ParseUint(c.Query("row_id"), 10, 32)
+	submission, err := ctl.Service.GetByRowAndForm(c.Query("form_key"), c.Query("file_key"), uint(rowID), claims.UserID)
+	if err != nil {
+		if errors.Is(err, ErrSubmissionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": i18n.TErr(c, err)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to load submission")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"submission": submission})
+}
+
+// Receipt re-downloads the PDF receipt of a submitted form.
+func (ctl *FormSubmissionController) Receipt(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	submissionID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	pdf, filename, err := ctl.Service.Receipt(c.Request.Context(), uint(submissionID), claims.UserID, claims.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrSubmissionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": i18n.TErr(c, err)})
+		case errors.Is(err, ErrNoReceipt):
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to load receipt")})
+		}
+		return
+	}
+	c.Header("Content-Disposition", media.ContentDisposition("attachment", filename))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// List is the admin listing of form submissions with filters and
+// pagination.
+func (ctl *FormSubmissionController) List(c *gin.Context) {
+	filter := ListFilter{
+		FormKey:      c.Query("form_key"),
+		FileKey:      c.Query("file_key"),
+		ReviewStatus: c.Query("review_status"),
+	}
+	if v := c.Query("consent"); v != "" {
+		consent := v == "true"
+		filter.Consent = &consent
+	}
+	if v := c.Query("from"); v != "" {
+		if t, err := time.Parse("2006-01-02", v); err == nil {
+			filter.From = t
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if t, err := time.Parse("2006-01-02", v); err == nil {
+			// Make the upper bound inclusive of the whole day.
+			filter.To = t.Add(24*time.Hour - time.Second)
+		}
+	}
+	filter.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filter.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "50"))
+
+	submissions, total, err := ctl.Service.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to list submissions")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"submissions": submissions,
+		"total":       total,
+		"page":        filter.Page,
+		"page_size":   filter.PageSize,
+	})
+}
+
+// ExportSubmissions streams all of a form's submissions as a spreadsheet
+// with answers pivoted into columns.
+func (ctl *FormSubmissionController) ExportSubmissions(c *gin.Context) {
+	if format := c.DefaultQuery("format", "xlsx"); format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(c, "unsupported format; use xlsx")})
+		return
+	}
+	formKey := c.Param("formKey")
+	data, err := ctl.Service.ExportSubmissionsXLSX(formKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to build export")})
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=\""+formKey+"_submissions.xlsx\"")
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", data)
+}
+
+func (ctl *FormSubmissionController) ListDefinitions(c *gin.Context) {
+	definitions, err := ctl.Service.ListDefinitions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to list definitions")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"definitions": definitions})
+}
+
+func (ctl *FormSubmissionController) GetDefinition(c *gin.Context) {
+	definition, err := ctl.Service.GetDefinition(c.Param("formKey"))
+	if err != nil {
+		if errors.Is(err, ErrDefinitionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": i18n.TErr(c, err)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to load definition")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"definition": definition})
+}
+
+func (ctl *FormSubmissionController) SaveDefinition(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req SaveDefinitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	definition, err := ctl.Service.SaveDefinition(req, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"definition": definition})
+}
+
+func (ctl *FormSubmissionController) DeleteDefinition(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if err := ctl.Service.DeleteDefinition(c.Param("formKey"), claims.UserID); err != nil {
+		if errors.Is(err, ErrDefinitionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": i18n.TErr(c, err)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.T(c, "failed to delete definition")})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "definition deleted"})
+}
+
+// Review updates a submission's admin review status.
+func (ctl *FormSubmissionController) Review(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	submissionID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var req struct {
+		Status string `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	if err := ctl.Service.SetReviewStatus(uint(submissionID), req.Status, claims.UserID); err != nil {
+		if errors.Is(err, ErrSubmissionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": i18n.TErr(c, err)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.TErr(c, err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "review status updated"})
+}
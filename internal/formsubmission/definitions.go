@@ -0,0 +1,291 @@
+package formsubmission
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var ErrDefinitionNotFound = errors.New("form definition not found")
+
+// FieldDefinition describes one field of a registered form.
+type FieldDefinition struct {
+	Key      string   `json:"key" binding:"required"`
+	Label    string   `json:"label"`
+	Type     string   `json:"type" binding:"required"`
+	Required bool     `json:"required"`
+	Options  []string `json:"options,omitempty"`
+	// VisibleIf hides the field unless another answer matches; hidden
+	// fields are neither required nor allowed to carry a value.
+	// RequiredIf makes the field required only when another answer
+	// matches, for follow-up questions ("if yes, explain").
+	VisibleIf  *FieldCondition `json:"visible_if,omitempty"`
+	RequiredIf *FieldCondition `json:"required_if,omitempty"`
+}
+
+// FieldCondition matches when the referenced field's answer equals any
+// of the listed values.
+type FieldCondition struct {
+	Field  string   `json:"field" binding:"required"`
+	Equals []string `json:"equals"`
+}
+
+// fieldTypes are the field types the validator understands.
+var fieldTypes = map[string]bool{
+	"text":    true,
+	"number":  true,
+	"date":    true,
+	"boolean": true,
+	"select":  true,
+}
+
+// SaveDefinitionRequest is the body of the admin definition upsert.
+type SaveDefinitionRequest struct {
+	FormKey        string            `json:"form_key" binding:"required"`
+	Label          string            `json:"label"`
+	RequireConsent bool              `json:"require_consent"`
+	AllowAnonymous bool              `json:"allow_anonymous"`
+	Fields         []FieldDefinition `json:"fields" binding:"required"`
+}
+
+// ValidationError reports per-field validation failures so the client can
+// highlight the offending inputs.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("submission failed validation on %d field(s)", len(e.Fields))
+}
+
+// ListDefinitions returns every registered form definition.
+func (s *FormSubmissionService) ListDefinitions() ([]models.FormDefinition, error) {
+	var definitions []models.FormDefinition
+	if err := s.DB.Order("form_key").Find(&definitions).Error; err != nil {
+		return nil, err
+	}
+	return definitions, nil
+}
+
+// GetDefinition returns the definition for a form key, or
+// ErrDefinitionNotFound.
+func (s *FormSubmissionService) GetDefinition(formKey string) (*models.FormDefinition, error) {
+	var definition models.FormDefinition
+	if err := s.DB.Where("form_key = ?", formKey).First(&definition).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDefinitionNotFound
+		}
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// SaveDefinition creates or replaces the definition for a form key.
+func (s *FormSubmissionService) SaveDefinition(req SaveDefinitionRequest, adminID uint) (*models.FormDefinition, error) {
+	keys := make(map[string]bool, len(req.Fields))
+	for _, field := range req.Fields {
+		keys[field.Key] = true
+	}
+	for _, field := range req.Fields {
+		if !fieldTypes[field.Type] {
+			return nil, fmt.Errorf("unknown field type %q for field %q", field.Type, field.Key)
+		}
+		if field.Type == "select" && len(field.Options) == 0 {
+			return nil, fmt.Errorf("select field %q needs at least one option", field.Key)
+		}
+		if err := checkCondition("visible_if", field, field.VisibleIf, keys); err != nil {
+			return nil, err
+		}
+		if err := checkCondition("required_if", field, field.RequiredIf, keys); err != nil {
+			return nil, err
+		}
+	}
+	fields, err := json.Marshal(req.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var definition models.FormDefinition
+	err = s.DB.Where("form_key = ?", req.FormKey).First(&definition).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		definition = models.FormDefinition{
+			FormKey:        req.FormKey,
+			Label:          req.Label,
+			RequireConsent: req.RequireConsent,
+			AllowAnonymous: req.AllowAnonymous,
+			Fields:         datatypes.JSON(fields),
+		}
+		if err := s.DB.Create(&definition).Error; err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else {
+		if err := s.DB.Model(&definition).Updates(map[string]interface{}{
+			"label":           req.Label,
+			"require_consent": req.RequireConsent,
+			"allow_anonymous": req.AllowAnonymous,
+			"fields":          datatypes.JSON(fields),
+			"updated_at":      time.Now(),
+		}).Error; err != nil {
+			return nil, err
+		}
+	}
+	s.Logs.Record(adminID, "SAVE_FORM_DEFINITION", req.FormKey)
+	return &definition, nil
+}
+
+// DeleteDefinition removes a form definition. Existing submissions are
+// kept; new ones simply go back to being unvalidated.
+func (s *FormSubmissionService) DeleteDefinition(formKey string, adminID uint) error {
+	result := s.DB.Where("form_key = ?", formKey).Delete(&models.FormDefinition{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDefinitionNotFound
+	}
+	s.Logs.Record(adminID, "DELETE_FORM_DEFINITION", formKey)
+	return nil
+}
+
+// validateAgainstDefinition checks a save payload against the registered
+// definition for its form key. Forms without a definition pass untouched
+// so legacy clients keep working.
+func (s *FormSubmissionService) validateAgainstDefinition(req SaveFormSubmissionRequest) error {
+	definition, err := s.GetDefinition(req.FormKey)
+	if errors.Is(err, ErrDefinitionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var fields []FieldDefinition
+	if err := json.Unmarshal(definition.Fields, &fields); err != nil {
+		return fmt.Errorf("decode definition %s: %w", req.FormKey, err)
+	}
+	byKey := make(map[string]FieldDefinition, len(fields))
+	for _, field := range fields {
+		byKey[field.Key] = field
+	}
+
+	values := make(map[string]string, len(req.Details))
+	for _, detail := range req.Details {
+		values[detail.FieldKey] = detail.Value
+	}
+
+	problems := map[string]string{}
+	for _, detail := range req.Details {
+		field, ok := byKey[detail.FieldKey]
+		if !ok {
+			problems[detail.FieldKey] = "unknown field for this form"
+			continue
+		}
+		if !fieldVisible(field, values) {
+			// Answers to hidden fields are rejected, not silently kept,
+			// so a tampered client cannot smuggle values past the rules.
+			if detail.Value != "" {
+				problems[detail.FieldKey] = "not applicable given the other answers"
+			}
+			continue
+		}
+		if detail.Value == "" {
+			continue
+		}
+		if msg := checkFieldValue(field, detail.Value); msg != "" {
+			problems[detail.FieldKey] = msg
+		}
+	}
+	for _, field := range fields {
+		if !fieldVisible(field, values) {
+			continue
+		}
+		required := field.Required || conditionMatches(field.RequiredIf, values)
+		if required && strings.TrimSpace(values[field.Key]) == "" {
+			problems[field.Key] = "required field is missing"
+		}
+	}
+	if definition.RequireConsent && !req.Consent {
+		problems["consent"] = "this form requires consent"
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Fields: problems}
+	}
+	return nil
+}
+
+// checkCondition validates a conditional rule at definition save time:
+// it must point at another field of the form and list expected values.
+func checkCondition(name string, field FieldDefinition, cond *FieldCondition, keys map[string]bool) error {
+	if cond == nil {
+		return nil
+	}
+	if cond.Field == field.Key {
+		return fmt.Errorf("%s of field %q cannot reference itself", name, field.Key)
+	}
+	if !keys[cond.Field] {
+		return fmt.Errorf("%s of field %q references unknown field %q", name, field.Key, cond.Field)
+	}
+	if len(cond.Equals) == 0 {
+		return fmt.Errorf("%s of field %q needs at least one expected value", name, field.Key)
+	}
+	return nil
+}
+
+// fieldVisible reports whether the field applies given the other
+// answers; fields without a visible_if rule always apply.
+func fieldVisible(field FieldDefinition, values map[string]string) bool {
+	if field.VisibleIf == nil {
+		return true
+	}
+	return conditionMatches(field.VisibleIf, values)
+}
+
+// conditionMatches reports whether the referenced answer equals any of
+// the expected values. A nil condition never matches.
+func conditionMatches(cond *FieldCondition, values map[string]string) bool {
+	if cond == nil {
+		return false
+	}
+	answer := values[cond.Field]
+	for _, expected := range cond.Equals {
+		if answer == expected {
+			return true
+		}
+	}
+	return false
+}
+
+func checkFieldValue(field FieldDefinition, value string) string {
+	switch field.Type {
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "must be a number"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return "must be a date in YYYY-MM-DD format"
+		}
+	case "boolean":
+		if value != "true" && value != "false" {
+			return "must be true or false"
+		}
+	case "select":
+		for _, option := range field.Options {
+			if value == option {
+				return ""
+			}
+		}
+		return "must be one of the configured options"
+	}
+	return ""
+}
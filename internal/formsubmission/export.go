@@ -0,0 +1,136 @@
+package formsubmission
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+// exportBaseHeaders are the fixed leading columns of the submissions
+// export; answered fields pivot into the columns that follow.
+var exportBaseHeaders = []string{
+	"Submission ID", "File", "Row", "Submitted By", "Consent", "Review Status", "Created At",
+}
+
+// ExportSubmissionsXLSX renders every submission for a form as a
+// spreadsheet, one row per submission, pivoting FormSubmissionDetail
+// values into columns and appending links to uploaded media. It uses the
+// same stream-writer approach as the admin updates export.
+func (s *FormSubmissionService) ExportSubmissionsXLSX(formKey string) ([]byte, error) {
+	var submissions []models.FormSubmission
+	if err := s.DB.Where("form_key = ?", formKey).
+		Order("created_at ASC").Find(&submissions).Error; err != nil {
+		return nil, err
+	}
+
+	detailsBySubmission := make(map[uint][]models.FormSubmissionDetail, len(submissions))
+	uploadsBySubmission := make(map[uint][]models.FormSubmissionUpload, len(submissions))
+	if len(submissions) > 0 {
+		ids := make([]uint, 0, len(submissions))
+		for _, submission := range submissions {
+			ids = append(ids, submission.ID)
+		}
+		var details []models.FormSubmissionDetail
+		if err := s.DB.Where("submission_id IN ?", ids).Find(&details).Error; err != nil {
+			return nil, err
+		}
+		for _, detail := range details {
+			detailsBySubmission[detail.SubmissionID] = append(detailsBySubmission[detail.SubmissionID], detail)
+		}
+		var uploads []models.FormSubmissionUpload
+		if err := s.DB.Where("submission_id IN ?", ids).Find(&uploads).Error; err != nil {
+			return nil, err
+		}
+		for _, upload := range uploads {
+			uploadsBySubmission[upload.SubmissionID] = append(uploadsBySubmission[upload.SubmissionID], upload)
+		}
+	}
+
+	fieldKeys := s.exportFieldOrder(formKey, submissions, detailsBySubmission)
+
+	wb := excelize.NewFile()
+	defer wb.Close()
+	sheet := wb.GetSheetName(0)
+	sw, err := wb.NewStreamWriter(sheet)
+	if err != nil {
+		return nil, fmt.Errorf("stream writer: %w", err)
+	}
+
+	header := make([]interface{}, 0, len(exportBaseHeaders)+len(fieldKeys)+1)
+	for _, h := range exportBaseHeaders {
+		header = append(header, h)
+	}
+	for _, key := range fieldKeys {
+		header = append(header, key)
+	}
+	header = append(header, "Uploads")
+	if err := sw.SetRow("A1", header); err != nil {
+		return nil, err
+	}
+
+	for i, submission := range submissions {
+		valueByKey := map[string]string{}
+		for _, detail := range detailsBySubmission[submission.ID] {
+			valueByKey[detail.FieldKey] = detail.Value
+		}
+		links := make([]string, 0, len(uploadsBySubmission[submission.ID]))
+		for _, upload := range uploadsBySubmission[submission.ID] {
+			links = append(links, upload.GCSPath)
+		}
+
+		row := []interface{}{
+			submission.ID, submission.FileKey, submission.RowID, submission.SubmittedBy,
+			submission.Consent, submission.ReviewStatus,
+			submission.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+		for _, key := range fieldKeys {
+			row = append(row, valueByKey[key])
+		}
+		row = append(row, strings.Join(links, "\n"))
+
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err := sw.SetRow(cell, row); err != nil {
+			return nil, err
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := wb.Write(&buf); err != nil {
+		return nil, fmt.Errorf("write xlsx: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// exportFieldOrder prefers the registered definition's field order and
+// falls back to the order fields first appear in the submissions.
+func (s *FormSubmissionService) exportFieldOrder(formKey string, submissions []models.FormSubmission, detailsBySubmission map[uint][]models.FormSubmissionDetail) []string {
+	var keys []string
+	seen := map[string]bool{}
+
+	if definition, err := s.GetDefinition(formKey); err == nil {
+		var fields []FieldDefinition
+		if json.Unmarshal(definition.Fields, &fields) == nil {
+			for _, field := range fields {
+				keys = append(keys, field.Key)
+				seen[field.Key] = true
+			}
+		}
+	}
+	for _, submission := range submissions {
+		for _, detail := range detailsBySubmission[submission.ID] {
+			if !seen[detail.FieldKey] {
+				keys = append(keys, detail.FieldKey)
+				seen[detail.FieldKey] = true
+			}
+		}
+	}
+	return keys
+}
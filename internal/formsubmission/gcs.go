@@ -0,0 +1,22 @@
+package formsubmission
+
+import (
+	"context"
+	"io"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/gcs"
+)
+
+// Hooks so the service can run without a real GCS client in tests. The
+// defaults delegate to the shared instrumented client in internal/gcs.
+var (
+	uploadFormFileHook = func(ctx context.Context, bucket, object string, r io.Reader, contentType string) (string, error) {
+		return gcs.Default.Upload(ctx, bucket, object, r, contentType)
+	}
+	downloadFormFileHook = func(ctx context.Context, bucket, object string) ([]byte, error) {
+		return gcs.Default.Download(ctx, bucket, object)
+	}
+	deleteFormFolderHook = func(ctx context.Context, bucket, prefix string) error {
+		return gcs.Default.DeletePrefix(ctx, bucket, prefix)
+	}
+)
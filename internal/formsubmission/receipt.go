@@ -0,0 +1,256 @@
+package formsubmission
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/mail"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+	"github.com/datanorthnordik/nordikdriveapi/internal/util"
+)
+
+var ErrNoReceipt = errors.New("receipt is only available after submission")
+
+// receiptTimeout bounds the background generation pass.
+const receiptTimeout = time.Minute
+
+// receiptRef builds the human-readable reference number printed on the
+// receipt, stable across regenerations of the same submission.
+func receiptRef(submission *models.FormSubmission) string {
+	return fmt.Sprintf("NDR-%s-%06d", submission.CreatedAt.Format("2006"), submission.ID)
+}
+
+// generateReceiptAsync runs receipt generation in the background: the
+// receipt must not block or fail the submission itself, and the user can
+// re-download it later.
+func (s *FormSubmissionService) generateReceiptAsync(submissionID, userID uint) {
+	ctx, cancel := context.WithTimeout(context.Background(), receiptTimeout)
+	defer cancel()
+	if err := s.generateReceipt(ctx, submissionID, userID); err != nil {
+		log.Printf("formsubmission: receipt for submission %d: %v", submissionID, err)
+	}
+}
+
+// generateReceipt builds the PDF receipt, stores it in GCS next to the
+// submission's uploads and emails it to the submitter.
+func (s *FormSubmissionService) generateReceipt(ctx context.Context, submissionID, userID uint) error {
+	var submission models.FormSubmission
+	if err := s.DB.First(&submission, submissionID).Error; err != nil {
+		return err
+	}
+	full, err := s.withChildren(submission)
+	if err != nil {
+		return err
+	}
+	ref := receiptRef(&submission)
+	pdf := buildReceiptPDF(full, ref)
+
+	object := fmt.Sprintf("requests/%s/%s_%d/receipt-%s.pdf",
+		submission.FormKey, submission.FileKey, submission.RowID, ref)
+	gsURL, err := uploadFormFileHook(ctx, s.Config.GCSBucket, object, bytes.NewReader(pdf), "application/pdf")
+	if err != nil {
+		return fmt.Errorf("store receipt: %w", err)
+	}
+	if err := s.DB.Model(&submission).Updates(map[string]interface{}{
+		"receipt_ref":  ref,
+		"receipt_path": gsURL,
+	}).Error; err != nil {
+		return err
+	}
+
+	recipient := submission.AnonymousEmail
+	if submission.SubmittedBy != 0 {
+		var user models.Auth
+		if err := s.DB.First(&user, submission.SubmittedBy).Error; err != nil {
+			return fmt.Errorf("load submitter: %w", err)
+		}
+		recipient = user.Email
+	}
+	msg := mail.Receipt(receiptFormLabel(&submission), ref)
+	msg.Attachments = append(msg.Attachments, mail.Attachment{
+		Filename:    "receipt-" + ref + ".pdf",
+		ContentType: "application/pdf",
+		Data:        pdf,
+	})
+	if err := mail.Send(s.Config, recipient, msg); err != nil {
+		return fmt.Errorf("email receipt: %w", err)
+	}
+	s.Logs.Record(userID, "GENERATE_RECEIPT", fmt.Sprintf("submission=%d ref=%s", submission.ID, ref))
+	return nil
+}
+
+// Receipt returns the stored PDF receipt for re-download by the
+// submitter or an admin. When the stored object is gone (or generation
+// never ran) the receipt is rebuilt from the database.
+func (s *FormSubmissionService) Receipt(ctx context.Context, submissionID, userID uint, role string) ([]byte, string, error) {
+	var submission models.FormSubmission
+	if err := s.DB.First(&submission, submissionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrSubmissionNotFound
+		}
+		return nil, "", err
+	}
+	// Hide other users' submissions entirely rather than acknowledging
+	// them with a 403.
+	if role != "Admin" && submission.SubmittedBy != userID {
+		return nil, "", ErrSubmissionNotFound
+	}
+	if submission.Status != "submitted" {
+		return nil, "", ErrNoReceipt
+	}
+
+	ref := submission.ReceiptRef
+	if ref == "" {
+		ref = receiptRef(&submission)
+	}
+	filename := "receipt-" + ref + ".pdf"
+	if submission.ReceiptPath != "" {
+		if bucket, object, err := util.ParseGSURL(submission.ReceiptPath); err == nil {
+			if pdf, err := downloadFormFileHook(ctx, bucket, object); err == nil {
+				return pdf, filename, nil
+			}
+		}
+	}
+	full, err := s.withChildren(submission)
+	if err != nil {
+		return nil, "", err
+	}
+	return buildReceiptPDF(full, ref), filename, nil
+}
+
+// receiptFormLabel falls back to the form key when the frontend never
+// supplied a display label.
+func receiptFormLabel(submission *models.FormSubmission) string {
+	if submission.FormLabel != "" {
+		return submission.FormLabel
+	}
+	return submission.FormKey
+}
+
+// buildReceiptPDF lays the submission out as text lines and renders them
+// with the minimal PDF writer below.
+func buildReceiptPDF(submission *SubmissionWithDetails, ref string) []byte {
+	lines := []string{
+		"NordikDrive submission receipt",
+		"",
+		"Reference number: " + ref,
+		"Form: " + receiptFormLabel(&submission.FormSubmission),
+		"Submitted: " + submission.CreatedAt.Format("2006-01-02 15:04 MST"),
+		"",
+	}
+	for _, detail := range submission.Details {
+		label := detail.FieldLabel
+		if label == "" {
+			label = detail.FieldKey
+		}
+		lines = append(lines, label+": "+detail.Value)
+	}
+	if len(submission.Uploads) > 0 {
+		lines = append(lines, "", fmt.Sprintf("Attached files: %d", len(submission.Uploads)))
+		for _, upload := range submission.Uploads {
+			lines = append(lines, "  - "+upload.Filename)
+		}
+	}
+	lines = append(lines, "")
+	if submission.Consent {
+		lines = append(lines, "The submitter consented to the use of this information as described in the form.")
+	} else {
+		lines = append(lines, "The submitter did not give consent; this submission is for record-keeping only.")
+	}
+	lines = append(lines, "", "Generated "+time.Now().Format("2006-01-02 15:04 MST"))
+	return renderPDF(lines)
+}
+
+// PDF page geometry: US Letter with a margin, 11pt Helvetica lines.
+const (
+	pdfPageWidth  = 612
+	pdfPageHeight = 792
+	pdfMargin     = 56
+	pdfLineStep   = 16
+	pdfFontSize   = 11
+)
+
+// renderPDF writes the lines as a self-contained PDF, paginating as
+// needed. It emits the handful of objects a text-only document needs
+// directly, which keeps the receipt free of a PDF library dependency;
+// text is encoded as WinAnsi, covering the Latin-1 range the intake
+// forms use.
+func renderPDF(lines []string) []byte {
+	linesPerPage := (pdfPageHeight - 2*pdfMargin) / pdfLineStep
+	var pages [][]string
+	for start := 0; start < len(lines); start += linesPerPage {
+		end := start + linesPerPage
+		if end > len(lines) {
+			end = len(lines)
+		}
+		pages = append(pages, lines[start:end])
+	}
+	if len(pages) == 0 {
+		pages = [][]string{{}}
+	}
+
+	// Object layout: 1 catalog, 2 page tree, 3 font, then one page and
+	// one content stream per page.
+	var body bytes.Buffer
+	var offsets []int
+	addObject := func(content string) {
+		offsets = append(offsets, body.Len())
+		fmt.Fprintf(&body, "%d 0 obj\n%s\nendobj\n", len(offsets), content)
+	}
+
+	body.WriteString("%PDF-1.4\n")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	addObject("<< /Type /Catalog /Pages 2 0 R >>")
+	addObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	addObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	for i, page := range pages {
+		addObject(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 5+2*i))
+		var stream bytes.Buffer
+		fmt.Fprintf(&stream, "BT /F1 %d Tf %d %d Td %d TL\n",
+			pdfFontSize, pdfMargin, pdfPageHeight-pdfMargin, pdfLineStep)
+		for _, line := range page {
+			fmt.Fprintf(&stream, "(%s) Tj T*\n", escapePDFText(line))
+		}
+		stream.WriteString("ET")
+		addObject(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", stream.Len(), stream.String()))
+	}
+
+	xrefOffset := body.Len()
+	fmt.Fprintf(&body, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&body, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&body, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(offsets)+1, xrefOffset)
+	return body.Bytes()
+}
+
+// escapePDFText escapes the string-literal delimiters and folds
+// characters outside WinAnsi to '?' so the stream stays valid.
+func escapePDFText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 255:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,478 @@
+package formsubmission
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/config"
+	"github.com/datanorthnordik/nordikdriveapi/internal/logs"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+	"github.com/datanorthnordik/nordikdriveapi/internal/util"
+)
+
+var (
+	ErrSubmissionNotFound = errors.New("form submission not found")
+	ErrNotSubmissionOwner = errors.New("only the submitter or an admin can delete a submission")
+)
+
+// uploadConcurrency bounds how many form files upload to GCS at once.
+const uploadConcurrency = 4
+
+// FormSubmissionService stores intake form answers made against file
+// rows, with their uploaded photos and documents.
+type FormSubmissionService struct {
+	DB     *gorm.DB
+	Config *config.Config
+	Logs   *logs.LogService
+}
+
+func NewFormSubmissionService(db *gorm.DB, cfg *config.Config, logSvc *logs.LogService) *FormSubmissionService {
+	return &FormSubmissionService{DB: db, Config: cfg, Logs: logSvc}
+}
+
+// FormDetailInput is one answered field in the save payload.
+type FormDetailInput struct {
+	FieldKey   string `json:"field_key" binding:"required"`
+	FieldLabel string `json:"field_label"`
+	FieldType  string `json:"field_type"`
+	Value      string `json:"value"`
+}
+
+// FormFileInput is a base64-encoded photo or document in the save
+// payload.
+type FormFileInput struct {
+	Filename    string `json:"filename" binding:"required"`
+	ContentType string `json:"content_type"`
+	Kind        string `json:"kind"`
+	Data        string `json:"data" binding:"required"`
+}
+
+// SaveFormSubmissionRequest is the body of POST /api/forms/submission.
+type SaveFormSubmissionRequest struct {
+	FormKey   string            `json:"form_key" binding:"required"`
+	FormLabel string            `json:"form_label"`
+	FileKey   string            `json:"file_key" binding:"required"`
+	RowID     uint              `json:"row_id" binding:"required"`
+	Consent   bool              `json:"consent"`
+	Status    string            `json:"status"`
+	Details   []FormDetailInput `json:"details"`
+	Files     []FormFileInput   `json:"files"`
+}
+
+// SubmissionWithDetails is a submission joined with its answers and
+// uploads for read endpoints.
+type SubmissionWithDetails struct {
+	models.FormSubmission
+	Details []models.FormSubmissionDetail `json:"details"`
+	Uploads []models.FormSubmissionUpload `json:"uploads"`
+}
+
+// Upsert creates or updates the submission for (form, file, row, user),
+// replacing its details and appending any newly uploaded files.
+func (s *FormSubmissionService) Upsert(ctx context.Context, req SaveFormSubmissionRequest, userID uint) (*models.FormSubmission, error) {
+	if req.Status == "" {
+		req.Status = "submitted"
+	}
+	if req.Status != "draft" && req.Status != "submitted" {
+		return nil, fmt.Errorf("invalid submission status %q", req.Status)
+	}
+	// Drafts are allowed to be incomplete; full validation runs when the
+	// user actually submits.
+	if req.Status == "submitted" {
+		if err := s.validateAgainstDefinition(req); err != nil {
+			return nil, err
+		}
+	}
+
+	var submission models.FormSubmission
+	err := s.DB.Where("form_key = ? AND file_key = ? AND row_id = ? AND submitted_by = ?",
+		req.FormKey, req.FileKey, req.RowID, userID).First(&submission).Error
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return nil, err
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if isNew {
+			submission = models.FormSubmission{
+				FormKey:     req.FormKey,
+				FormLabel:   req.FormLabel,
+				FileKey:     req.FileKey,
+				RowID:       req.RowID,
+				SubmittedBy: userID,
+				Consent:     req.Consent,
+				Status:      req.Status,
+			}
+			if err := tx.Create(&submission).Error; err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Model(&submission).Updates(map[string]interface{}{
+				"form_label": req.FormLabel,
+				"consent":    req.Consent,
+				"status":     req.Status,
+				"updated_at": time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("submission_id = ?", submission.ID).
+				Delete(&models.FormSubmissionDetail{}).Error; err != nil {
+				return err
+			}
+		}
+		for _, input := range req.Details {
+			detail := models.FormSubmissionDetail{
+				SubmissionID: submission.ID,
+				FieldKey:     input.FieldKey,
+				FieldLabel:   input.FieldLabel,
+				FieldType:    input.FieldType,
+				Value:        input.Value,
+			}
+			if err := tx.Create(&detail).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("upsert submission: %w", err)
+	}
+
+	if len(req.Files) > 0 {
+		if err := s.uploadFormFiles(ctx, &submission, req.Files); err != nil {
+			return nil, err
+		}
+	}
+
+	action := "UPDATE_FORM_SUBMISSION"
+	if isNew {
+		action = "CREATE_FORM_SUBMISSION"
+	}
+	s.Logs.Record(userID, action, fmt.Sprintf("submission=%d form=%s", submission.ID, submission.FormKey))
+	if req.Status == "submitted" {
+		go s.generateReceiptAsync(submission.ID, userID)
+	}
+	return &submission, nil
+}
+
+// uploadFormFiles pushes the submission's files to GCS under
+// requests/<form>/<file>_<row>/ with bounded concurrency.
+func (s *FormSubmissionService) uploadFormFiles(ctx context.Context, submission *models.FormSubmission, files []FormFileInput) error {
+	sem := make(chan struct{}, uploadConcurrency)
+	var wg sync.WaitGroup
+	errs := make([]error, len(files))
+	uploads := make([]models.FormSubmissionUpload, len(files))
+
+	for i, input := range files {
+		wg.Add(1)
+		go func(i int, input FormFileInput) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			data, err := base64.StdEncoding.DecodeString(input.Data)
+			if err != nil {
+				errs[i] = fmt.Errorf("decode %s: %w", input.Filename, err)
+				return
+			}
+			object := fmt.Sprintf("requests/%s/%s_%d/%s",
+				submission.FormKey, submission.FileKey, submission.RowID,
+				util.SanitizeFilename(input.Filename))
+			gsURL, err := uploadFormFileHook(ctx, s.Config.GCSBucket, object, strings.NewReader(string(data)), input.ContentType)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			uploads[i] = models.FormSubmissionUpload{
+				SubmissionID: submission.ID,
+				Kind:         input.Kind,
+				Filename:     input.Filename,
+				GCSPath:      gsURL,
+			}
+		}(i, input)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return fmt.Errorf("upload form files: %w", err)
+		}
+	}
+	for _, upload := range uploads {
+		if err := s.DB.Create(&upload).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PatchSubmissionRequest is the body of PATCH /api/forms/submission/:id.
+// Only provided pieces are touched, so the frontend can autosave a long
+// form field-by-field.
+type PatchSubmissionRequest struct {
+	Consent *bool             `json:"consent"`
+	Status  string            `json:"status"`
+	Details []FormDetailInput `json:"details"`
+	Files   []FormFileInput   `json:"files"`
+}
+
+// PatchSubmission incrementally updates the caller's submission:
+// provided details are upserted by field key, and a status of
+// "submitted" runs full validation before finalizing the draft.
+func (s *FormSubmissionService) PatchSubmission(ctx context.Context, submissionID uint, req PatchSubmissionRequest, userID uint) (*SubmissionWithDetails, error) {
+	var submission models.FormSubmission
+	if err := s.DB.Where("id = ? AND submitted_by = ?", submissionID, userID).
+		First(&submission).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSubmissionNotFound
+		}
+		return nil, err
+	}
+	if req.Status != "" && req.Status != "draft" && req.Status != "submitted" {
+		return nil, fmt.Errorf("invalid submission status %q", req.Status)
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		for _, input := range req.Details {
+			var detail models.FormSubmissionDetail
+			err := tx.Where("submission_id = ? AND field_key = ?", submission.ID, input.FieldKey).
+				First(&detail).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				detail = models.FormSubmissionDetail{
+					SubmissionID: submission.ID,
+					FieldKey:     input.FieldKey,
+					FieldLabel:   input.FieldLabel,
+					FieldType:    input.FieldType,
+					Value:        input.Value,
+				}
+				if err := tx.Create(&detail).Error; err != nil {
+					return err
+				}
+			} else if err != nil {
+				return err
+			} else if err := tx.Model(&detail).Updates(map[string]interface{}{
+				"field_label": input.FieldLabel,
+				"field_type":  input.FieldType,
+				"value":       input.Value,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		updates := map[string]interface{}{"updated_at": time.Now()}
+		if req.Consent != nil {
+			updates["consent"] = *req.Consent
+		}
+		if req.Status != "" {
+			updates["status"] = req.Status
+		}
+		return tx.Model(&submission).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("patch submission: %w", err)
+	}
+
+	if len(req.Files) > 0 {
+		if err := s.uploadFormFiles(ctx, &submission, req.Files); err != nil {
+			return nil, err
+		}
+	}
+
+	// Finalizing a draft gets the same validation as a direct submit,
+	// built from the merged state now in the database.
+	if req.Status == "submitted" {
+		merged, err := s.withChildren(submission)
+		if err != nil {
+			return nil, err
+		}
+		check := SaveFormSubmissionRequest{
+			FormKey: submission.FormKey,
+			FileKey: submission.FileKey,
+			RowID:   submission.RowID,
+			Consent: submission.Consent,
+			Status:  "submitted",
+		}
+		if req.Consent != nil {
+			check.Consent = *req.Consent
+		}
+		for _, detail := range merged.Details {
+			check.Details = append(check.Details, FormDetailInput{
+				FieldKey:   detail.FieldKey,
+				FieldLabel: detail.FieldLabel,
+				FieldType:  detail.FieldType,
+				Value:      detail.Value,
+			})
+		}
+		if err := s.validateAgainstDefinition(check); err != nil {
+			// Roll the status back so the draft remains resumable.
+			s.DB.Model(&submission).Update("status", "draft")
+			return nil, err
+		}
+		go s.generateReceiptAsync(submission.ID, userID)
+	}
+
+	s.Logs.Record(userID, "PATCH_FORM_SUBMISSION", fmt.Sprintf("submission=%d status=%s", submission.ID, req.Status))
+	return s.withChildren(submission)
+}
+
+// GetByRowAndForm returns the caller's submission for a (form, file, row)
+// with details and uploads, or ErrSubmissionNotFound. Drafts are returned
+// as-is so a partially answered form can be resumed.
+func (s *FormSubmissionService) GetByRowAndForm(formKey, fileKey string, rowID, userID uint) (*SubmissionWithDetails, error) {
+	var submission models.FormSubmission
+	err := s.DB.Where("form_key = ? AND file_key = ? AND row_id = ? AND submitted_by = ?",
+		formKey, fileKey, rowID, userID).First(&submission).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSubmissionNotFound
+		}
+		return nil, err
+	}
+	return s.withChildren(submission)
+}
+
+func (s *FormSubmissionService) withChildren(submission models.FormSubmission) (*SubmissionWithDetails, error) {
+	out := &SubmissionWithDetails{FormSubmission: submission}
+	if err := s.DB.Where("submission_id = ?", submission.ID).Find(&out.Details).Error; err != nil {
+		return nil, err
+	}
+	if err := s.DB.Where("submission_id = ?", submission.ID).Find(&out.Uploads).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteSubmission removes a submission with its details and upload rows
+// in one transaction, then clears the submission's GCS folder. Only the
+// owner or an admin may delete.
+func (s *FormSubmissionService) DeleteSubmission(ctx context.Context, submissionID, userID uint, role string) error {
+	var submission models.FormSubmission
+	if err := s.DB.First(&submission, submissionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSubmissionNotFound
+		}
+		return err
+	}
+	if role != "Admin" && submission.SubmittedBy != userID {
+		return ErrNotSubmissionOwner
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("submission_id = ?", submission.ID).
+			Delete(&models.FormSubmissionDetail{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("submission_id = ?", submission.ID).
+			Delete(&models.FormSubmissionUpload{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&submission).Error
+	})
+	if err != nil {
+		return fmt.Errorf("delete submission %d: %w", submissionID, err)
+	}
+
+	prefix := fmt.Sprintf("requests/%s/%s_%d/", submission.FormKey, submission.FileKey, submission.RowID)
+	if err := deleteFormFolderHook(ctx, s.Config.GCSBucket, prefix); err != nil {
+		// The DB rows are gone; report the orphaned objects rather than
+		// pretending the delete failed entirely.
+		return fmt.Errorf("submission deleted but GCS cleanup failed: %w", err)
+	}
+
+	s.Logs.Record(userID, "DELETE_FORM_SUBMISSION",
+		fmt.Sprintf("submission=%d form=%s file=%s row=%d", submission.ID, submission.FormKey, submission.FileKey, submission.RowID))
+	return nil
+}
+
+// ListFilter narrows the admin submission listing.
+type ListFilter struct {
+	FormKey      string
+	FileKey      string
+	Consent      *bool
+	ReviewStatus string
+	From         time.Time
+	To           time.Time
+	Page         int
+	PageSize     int
+}
+
+// List returns submissions matching the filter, newest first, with
+// details attached, plus the total match count for pagination.
+func (s *FormSubmissionService) List(filter ListFilter) ([]SubmissionWithDetails, int64, error) {
+	if filter.PageSize <= 0 || filter.PageSize > 200 {
+		filter.PageSize = 50
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+
+	// Anonymous submissions stay out of the queue until their email
+	// address is confirmed.
+	q := s.DB.Model(&models.FormSubmission{}).
+		Where("anonymous_email = '' OR verified_at IS NOT NULL")
+	if filter.FormKey != "" {
+		q = q.Where("form_key = ?", filter.FormKey)
+	}
+	if filter.FileKey != "" {
+		q = q.Where("file_key = ?", filter.FileKey)
+	}
+	if filter.Consent != nil {
+		q = q.Where("consent = ?", *filter.Consent)
+	}
+	if filter.ReviewStatus != "" {
+		q = q.Where("review_status = ?", filter.ReviewStatus)
+	}
+	if !filter.From.IsZero() {
+		q = q.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		q = q.Where("created_at <= ?", filter.To)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var submissions []models.FormSubmission
+	if err := q.Order("created_at DESC").
+		Limit(filter.PageSize).Offset((filter.Page - 1) * filter.PageSize).
+		Find(&submissions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	out := make([]SubmissionWithDetails, 0, len(submissions))
+	for _, submission := range submissions {
+		withChildren, err := s.withChildren(submission)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, *withChildren)
+	}
+	return out, total, nil
+}
+
+// SetReviewStatus marks a submission as reviewed (or back to new) for the
+// admin review queue.
+func (s *FormSubmissionService) SetReviewStatus(submissionID uint, status string, adminID uint) error {
+	if status != "new" && status != "reviewed" {
+		return fmt.Errorf("invalid review status %q", status)
+	}
+	result := s.DB.Model(&models.FormSubmission{}).
+		Where("id = ?", submissionID).Update("review_status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSubmissionNotFound
+	}
+	s.Logs.Record(adminID, "REVIEW_FORM_SUBMISSION", fmt.Sprintf("submission=%d status=%s", submissionID, status))
+	return nil
+}
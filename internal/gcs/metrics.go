@@ -0,0 +1,113 @@
+package gcs
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// OpStats is one operation's aggregate health since process start.
+type OpStats struct {
+	Calls     int64   `json:"calls"`
+	Errors    int64   `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	AvgMillis float64 `json:"avg_millis"`
+}
+
+var (
+	statsMu sync.Mutex
+	stats   = map[string]*opCounter{}
+)
+
+type opCounter struct {
+	calls  int64
+	errors int64
+	total  time.Duration
+}
+
+func record(op string, start time.Time, err error) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	c := stats[op]
+	if c == nil {
+		c = &opCounter{}
+		stats[op] = c
+	}
+	c.calls++
+	c.total += time.Since(start)
+	if err != nil {
+		c.errors++
+	}
+}
+
+// Snapshot returns per-operation call counts, error rates, and average
+// latency for every GCS operation issued through Default.
+func Snapshot() map[string]OpStats {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	out := make(map[string]OpStats, len(stats))
+	for op, c := range stats {
+		s := OpStats{Calls: c.calls, Errors: c.errors}
+		if c.calls > 0 {
+			s.ErrorRate = float64(c.errors) / float64(c.calls)
+			s.AvgMillis = float64(c.total.Milliseconds()) / float64(c.calls)
+		}
+		out[op] = s
+	}
+	return out
+}
+
+// instrumented wraps a Client, timing every call and counting failures.
+type instrumented struct {
+	next Client
+}
+
+func (i *instrumented) Upload(ctx context.Context, bucket, object string, r io.Reader, contentType string) (string, error) {
+	start := time.Now()
+	url, err := i.next.Upload(ctx, bucket, object, r, contentType)
+	record("upload", start, err)
+	return url, err
+}
+
+func (i *instrumented) Download(ctx context.Context, bucket, object string) ([]byte, error) {
+	start := time.Now()
+	data, err := i.next.Download(ctx, bucket, object)
+	record("download", start, err)
+	return data, err
+}
+
+func (i *instrumented) List(ctx context.Context, bucket, prefix string) ([]string, error) {
+	start := time.Now()
+	names, err := i.next.List(ctx, bucket, prefix)
+	record("list", start, err)
+	return names, err
+}
+
+func (i *instrumented) Delete(ctx context.Context, bucket string, objects []string) error {
+	start := time.Now()
+	err := i.next.Delete(ctx, bucket, objects)
+	record("delete", start, err)
+	return err
+}
+
+func (i *instrumented) DeletePrefix(ctx context.Context, bucket, prefix string) error {
+	start := time.Now()
+	err := i.next.DeletePrefix(ctx, bucket, prefix)
+	record("delete_prefix", start, err)
+	return err
+}
+
+func (i *instrumented) MovePrefix(ctx context.Context, bucket, src, dst string) error {
+	start := time.Now()
+	err := i.next.MovePrefix(ctx, bucket, src, dst)
+	record("move_prefix", start, err)
+	return err
+}
+
+func (i *instrumented) SignURL(ctx context.Context, bucket, object string, expires time.Duration) (string, error) {
+	start := time.Now()
+	url, err := i.next.SignURL(ctx, bucket, object, expires)
+	record("sign_url", start, err)
+	return url, err
+}
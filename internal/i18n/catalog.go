@@ -0,0 +1,95 @@
+package i18n
+
+// french maps English controller/service messages to their French
+// equivalents. Keys must match the English text exactly; missing entries
+// fall back to English.
+var french = map[string]string{
+	// Sentinel errors.
+	"a file with this name already exists":               "un fichier portant ce nom existe déjà",
+	"a pending edit request for this row already exists": "une demande de modification en attente existe déjà pour cette ligne",
+	"an account with this email already exists":          "un compte avec ce courriel existe déjà",
+	"claim not found":                                        "revendication introuvable",
+	"comment not found":                                      "commentaire introuvable",
+	"comment body cannot be empty":                           "le commentaire ne peut pas être vide",
+	"only the author or an admin can delete a comment":       "seul l'auteur ou un administrateur peut supprimer un commentaire",
+	"document category already exists":                       "cette catégorie de document existe déjà",
+	"document category not found":                            "catégorie de document introuvable",
+	"unknown document category":                              "catégorie de document inconnue",
+	"column name already in use":                             "ce nom de colonne est déjà utilisé",
+	"computed column not found":                              "colonne calculée introuvable",
+	"edit request belongs to another user":                   "la demande de modification appartient à un autre utilisateur",
+	"edit request is not a draft":                            "la demande de modification n'est pas un brouillon",
+	"edit request is not approved":                           "la demande de modification n'est pas approuvée",
+	"edit request is not pending":                            "la demande de modification n'est pas en attente",
+	"edit request not found":                                 "demande de modification introuvable",
+	"file content is identical to the current version":       "le contenu du fichier est identique à la version actuelle",
+	"file not found":                                         "fichier introuvable",
+	"photo not found":                                        "photo introuvable",
+	"form submission not found":                              "soumission de formulaire introuvable",
+	"invalid aggregate query":                                "requête d'agrégation invalide",
+	"invalid column update":                                  "mise à jour de colonne invalide",
+	"invalid email or password":                              "courriel ou mot de passe invalide",
+	"invalid annotation":                                     "annotation invalide",
+	"invalid expression":                                     "expression invalide",
+	"invalid or expired code":                                "code invalide ou expiré",
+	"too many codes requested; try again later":              "trop de codes demandés; réessayez plus tard",
+	"only the submitter or an admin can delete a submission": "seul l'auteur ou un administrateur peut supprimer une soumission",
+	"private files cannot be published":                      "les fichiers privés ne peuvent pas être publiés",
+	"receipt is only available after submission":             "le reçu n'est disponible qu'après la soumission",
+	"publishing requires a column allow-list":                "la publication exige une liste de colonnes autorisées",
+	"please verify your email address first":                 "veuillez d'abord vérifier votre adresse courriel",
+	"original upload was not archived":                       "le fichier original n'a pas été archivé",
+	"row not found":                                          "ligne introuvable",
+	"this form does not accept anonymous submissions":        "ce formulaire n'accepte pas les soumissions anonymes",
+	"invalid or already used verification link":              "lien de vérification invalide ou déjà utilisé",
+	"unsupported file type; upload .csv or .xlsx":            "type de fichier non pris en charge; téléversez un .csv ou .xlsx",
+	"upload exceeds the allowed size":                        "le téléversement dépasse la taille permise",
+	"upload not found":                                       "téléversement introuvable",
+	"version is not archived":                                "cette version n'est pas archivée",
+	"you do not have access to this file":                    "vous n'avez pas accès à ce fichier",
+	"you have already claimed this row":                      "vous avez déjà revendiqué cette ligne",
+
+	// Fixed controller messages.
+	"failed to build export":                      "échec de la préparation de l'exportation",
+	"failed to create account":                    "échec de la création du compte",
+	"failed to delete definition":                 "échec de la suppression de la définition",
+	"failed to list claims":                       "échec du chargement des revendications",
+	"failed to list computed columns":             "échec du chargement des colonnes calculées",
+	"failed to list definitions":                  "échec du chargement des définitions",
+	"failed to list document categories":          "échec du chargement des catégories de documents",
+	"failed to list documents":                    "échec du chargement des documents",
+	"failed to list files":                        "échec du chargement des fichiers",
+	"failed to list pending requests":             "échec du chargement des demandes en attente",
+	"failed to list photos":                       "échec du chargement des photos",
+	"failed to list requests":                     "échec du chargement des demandes",
+	"failed to list row history":                  "échec du chargement de l'historique de la ligne",
+	"failed to list login history":                "échec du chargement de l'historique de connexion",
+	"invalid sort column or direction":            "colonne ou direction de tri invalide",
+	"snapshot not found":                          "instantané introuvable",
+	"failed to list submissions":                  "échec du chargement des soumissions",
+	"failed to list trash":                        "échec du chargement de la corbeille",
+	"failed to load definition":                   "échec du chargement de la définition",
+	"failed to load submission":                   "échec du chargement de la soumission",
+	"failed to load receipt":                      "échec du chargement du reçu",
+	"failed to save submission":                   "échec de l'enregistrement de la soumission",
+	"failed to reset password":                    "échec de la réinitialisation du mot de passe",
+	"failed to send code":                         "échec de l'envoi du code",
+	"failed to verify email":                      "échec de la vérification du courriel",
+	"failed to update profile":                    "échec de la mise à jour du profil",
+	"current password is incorrect":               "le mot de passe actuel est incorrect",
+	"failed to change password":                   "échec du changement de mot de passe",
+	"your account is not allowed to upload files": "votre compte n'est pas autorisé à téléverser des fichiers",
+	"invalid column id":                           "identifiant de colonne invalide",
+	"invalid refresh token":                       "jeton de rafraîchissement invalide",
+	"invalid user id":                             "identifiant d'utilisateur invalide",
+	"login failed":                                "échec de la connexion",
+	"media not found":                             "média introuvable",
+	"missing file upload":                         "fichier téléversé manquant",
+	"missing path":                                "chemin manquant",
+	"unsupported format; use xlsx":                "format non pris en charge; utilisez xlsx",
+	"user not found":                              "utilisateur introuvable",
+	"you are already yourself":                    "vous êtes déjà vous-même",
+
+	"the platform is under maintenance; changes are temporarily disabled but browsing still works": "la plateforme est en maintenance; les modifications sont temporairement désactivées mais la consultation fonctionne toujours",
+	"the server is busy; please retry in a moment":                                                 "le serveur est occupé; veuillez réessayer dans un instant",
+}
@@ -0,0 +1,47 @@
+package i18n
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The platform serves bilingual regions, so user-facing controller
+// messages go through this package. English strings double as catalog
+// keys: a message with no translation falls back to itself, which keeps
+// adding new messages cheap.
+
+// Lang returns the language for a request based on its Accept-Language
+// header: "fr" when French is listed first among the supported
+// languages, "en" otherwise.
+func Lang(c *gin.Context) string {
+	header := c.GetHeader("Accept-Language")
+	for _, part := range strings.Split(header, ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		switch {
+		case strings.HasPrefix(tag, "fr"):
+			return "fr"
+		case strings.HasPrefix(tag, "en"):
+			return "en"
+		}
+	}
+	return "en"
+}
+
+// T translates an English message for the request's language, falling
+// back to the English text when no translation exists.
+func T(c *gin.Context, msg string) string {
+	if Lang(c) == "fr" {
+		if translated, ok := french[msg]; ok {
+			return translated
+		}
+	}
+	return msg
+}
+
+// TErr translates an error's message. Sentinel errors have catalog
+// entries; anything else (validation output, wrapped errors) falls back
+// to its English text.
+func TErr(c *gin.Context, err error) string {
+	return T(c, err.Error())
+}
@@ -0,0 +1,121 @@
+package linkage
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/middleware"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+// Service is the surface LinkageController depends on, implemented by
+// LinkageService. Kept as an interface so alternate backends can be
+// wired in by the server container.
+type Service interface {
+	CreateRule(req CreateRuleRequest, adminID uint) (*models.LinkRule, error)
+	ListRules() ([]models.LinkRule, error)
+	RunRule(ruleID uint, adminID uint) (*RunReport, error)
+	ListLinks(ruleID uint, status string) ([]models.RecordLink, error)
+	ReviewLink(linkID uint, confirm bool, adminID uint) (*models.RecordLink, error)
+}
+
+var _ Service = (*LinkageService)(nil)
+
+// LinkageController exposes the admin-only /api/admin/linkage endpoints.
+type LinkageController struct {
+	Service Service
+}
+
+func NewLinkageController(service Service) *LinkageController {
+	return &LinkageController{Service: service}
+}
+
+// RegisterAdminRoutes mounts the linkage endpoints on the admin group.
+func (ctl *LinkageController) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	rg.POST("/linkage/rules", ctl.CreateRule)
+	rg.GET("/linkage/rules", ctl.ListRules)
+	rg.POST("/linkage/rules/:id/run", ctl.RunRule)
+	rg.GET("/linkage/rules/:id/links", ctl.ListLinks)
+	rg.POST("/linkage/links/:id/confirm", ctl.ConfirmLink)
+	rg.POST("/linkage/links/:id/reject", ctl.RejectLink)
+}
+
+func (ctl *LinkageController) CreateRule(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req CreateRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule, err := ctl.Service.CreateRule(req, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"rule": rule})
+}
+
+func (ctl *LinkageController) ListRules(c *gin.Context) {
+	rules, err := ctl.Service.ListRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list rules"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+func (ctl *LinkageController) RunRule(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	report, err := ctl.Service.RunRule(paramUint(c, "id"), claims.UserID)
+	if err != nil {
+		respondLinkageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+func (ctl *LinkageController) ListLinks(c *gin.Context) {
+	links, err := ctl.Service.ListLinks(paramUint(c, "id"), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list links"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"links": links})
+}
+
+func (ctl *LinkageController) ConfirmLink(c *gin.Context) {
+	ctl.review(c, true)
+}
+
+func (ctl *LinkageController) RejectLink(c *gin.Context) {
+	ctl.review(c, false)
+}
+
+func (ctl *LinkageController) review(c *gin.Context, confirm bool) {
+	claims := middleware.GetClaims(c)
+	link, err := ctl.Service.ReviewLink(paramUint(c, "id"), confirm, claims.UserID)
+	if err != nil {
+		respondLinkageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"link": link})
+}
+
+func respondLinkageError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrRuleNotFound), errors.Is(err, ErrLinkNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrLinkReviewed):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+func paramUint(c *gin.Context, name string) uint {
+	v, _ := strconv.ParseUint(c.Param(name), 10, 32)
+	return uint(v)
+}
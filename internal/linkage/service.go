@@ -0,0 +1,277 @@
+package linkage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/config"
+	"github.com/datanorthnordik/nordikdriveapi/internal/logs"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var (
+	ErrRuleNotFound = errors.New("link rule not found")
+	ErrLinkNotFound = errors.New("record link not found")
+	ErrLinkReviewed = errors.New("record link already reviewed")
+)
+
+// LinkField pairs one column of file A with one column of file B. Two
+// rows are candidates when every pair matches after normalization.
+type LinkField struct {
+	ColumnA string `json:"column_a"`
+	ColumnB string `json:"column_b"`
+}
+
+// CreateRuleRequest is the payload for defining a new match rule.
+type CreateRuleRequest struct {
+	Name    string      `json:"name" binding:"required"`
+	FileAID uint        `json:"file_a_id" binding:"required"`
+	FileBID uint        `json:"file_b_id" binding:"required"`
+	Fields  []LinkField `json:"fields" binding:"required"`
+}
+
+// RunReport summarises one candidate-generation pass over a rule.
+type RunReport struct {
+	RuleID     uint `json:"rule_id"`
+	RowsA      int  `json:"rows_a"`
+	RowsB      int  `json:"rows_b"`
+	Candidates int  `json:"candidates"`
+	Created    int  `json:"created"`
+}
+
+// LinkageService maintains match rules between files and the candidate
+// record links they produce.
+type LinkageService struct {
+	DB     *gorm.DB
+	Config *config.Config
+	Logs   *logs.LogService
+}
+
+func NewLinkageService(db *gorm.DB, cfg *config.Config, logSvc *logs.LogService) *LinkageService {
+	return &LinkageService{DB: db, Config: cfg, Logs: logSvc}
+}
+
+// CreateRule stores a new match rule after checking both files exist.
+func (s *LinkageService) CreateRule(req CreateRuleRequest, adminID uint) (*models.LinkRule, error) {
+	if len(req.Fields) == 0 {
+		return nil, errors.New("at least one field pair is required")
+	}
+	for _, f := range req.Fields {
+		if strings.TrimSpace(f.ColumnA) == "" || strings.TrimSpace(f.ColumnB) == "" {
+			return nil, errors.New("field pairs must name a column on both sides")
+		}
+	}
+	var count int64
+	if err := s.DB.Model(&models.File{}).
+		Where("id IN ? AND is_delete = false", []uint{req.FileAID, req.FileBID}).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	want := int64(2)
+	if req.FileAID == req.FileBID {
+		want = 1
+	}
+	if count != want {
+		return nil, errors.New("both files must exist")
+	}
+
+	fields, err := json.Marshal(req.Fields)
+	if err != nil {
+		return nil, err
+	}
+	rule := models.LinkRule{
+		Name:      req.Name,
+		FileAID:   req.FileAID,
+		FileBID:   req.FileBID,
+		Fields:    fields,
+		CreatedBy: adminID,
+	}
+	if err := s.DB.Create(&rule).Error; err != nil {
+		return nil, err
+	}
+	s.Logs.Record(adminID, "LINK_RULE_CREATED", fmt.Sprintf("rule %d (%s): file %d <-> file %d", rule.ID, rule.Name, rule.FileAID, rule.FileBID))
+	return &rule, nil
+}
+
+// ListRules returns all defined match rules.
+func (s *LinkageService) ListRules() ([]models.LinkRule, error) {
+	var rules []models.LinkRule
+	if err := s.DB.Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// RunRule regenerates candidate links for a rule. Rows are matched when
+// every field pair is equal after trimming and lower-casing; pairs that
+// already have a link (whatever its status) are left untouched so
+// confirmed and rejected decisions survive re-runs.
+func (s *LinkageService) RunRule(ruleID uint, adminID uint) (*RunReport, error) {
+	var rule models.LinkRule
+	if err := s.DB.First(&rule, ruleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRuleNotFound
+		}
+		return nil, err
+	}
+	var fields []LinkField
+	if err := json.Unmarshal(rule.Fields, &fields); err != nil {
+		return nil, fmt.Errorf("decode rule fields: %w", err)
+	}
+
+	rowsA, err := s.currentRows(rule.FileAID)
+	if err != nil {
+		return nil, err
+	}
+	rowsB, err := s.currentRows(rule.FileBID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Index file A rows by their composite match key, then probe with
+	// each file B row. Rows missing any match column are skipped.
+	index := make(map[string][]uint, len(rowsA))
+	for _, row := range rowsA {
+		key, ok := matchKey(row, fields, true)
+		if !ok {
+			continue
+		}
+		index[key] = append(index[key], row.ID)
+	}
+
+	existing, err := s.existingPairs(rule.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := RunReport{RuleID: rule.ID, RowsA: len(rowsA), RowsB: len(rowsB)}
+	var links []models.RecordLink
+	for _, row := range rowsB {
+		key, ok := matchKey(row, fields, false)
+		if !ok {
+			continue
+		}
+		for _, rowAID := range index[key] {
+			if rule.FileAID == rule.FileBID && rowAID == row.ID {
+				continue
+			}
+			report.Candidates++
+			if existing[pairKey(rowAID, row.ID)] {
+				continue
+			}
+			links = append(links, models.RecordLink{
+				RuleID: rule.ID,
+				RowAID: rowAID,
+				RowBID: row.ID,
+				Score:  1,
+				Status: "pending",
+			})
+		}
+	}
+	if len(links) > 0 {
+		if err := s.DB.CreateInBatches(links, 500).Error; err != nil {
+			return nil, err
+		}
+	}
+	report.Created = len(links)
+	s.Logs.Record(adminID, "LINK_RULE_RUN", fmt.Sprintf("rule %d: %d candidates, %d new links", rule.ID, report.Candidates, report.Created))
+	return &report, nil
+}
+
+// ListLinks returns candidate links for a rule, optionally filtered by
+// status.
+func (s *LinkageService) ListLinks(ruleID uint, status string) ([]models.RecordLink, error) {
+	q := s.DB.Where("rule_id = ?", ruleID).Order("id")
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var links []models.RecordLink
+	if err := q.Find(&links).Error; err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// ReviewLink confirms or rejects one pending candidate link.
+func (s *LinkageService) ReviewLink(linkID uint, confirm bool, adminID uint) (*models.RecordLink, error) {
+	var link models.RecordLink
+	if err := s.DB.First(&link, linkID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, err
+	}
+	if link.Status != "pending" {
+		return nil, ErrLinkReviewed
+	}
+	link.Status = "rejected"
+	if confirm {
+		link.Status = "confirmed"
+	}
+	link.ReviewedBy = adminID
+	if err := s.DB.Save(&link).Error; err != nil {
+		return nil, err
+	}
+	s.Logs.Record(adminID, "LINK_REVIEWED", fmt.Sprintf("link %d %s (rows %d <-> %d)", link.ID, link.Status, link.RowAID, link.RowBID))
+	return &link, nil
+}
+
+// currentRows loads the current-version rows of a file.
+func (s *LinkageService) currentRows(fileID uint) ([]models.FileData, error) {
+	var file models.File
+	if err := s.DB.First(&file, "id = ? AND is_delete = false", fileID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("file %d not found", fileID)
+		}
+		return nil, err
+	}
+	var rows []models.FileData
+	if err := s.DB.Where("file_id = ? AND version = ?", fileID, file.Version).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// existingPairs returns the (rowA, rowB) pairs already linked by a rule.
+func (s *LinkageService) existingPairs(ruleID uint) (map[string]bool, error) {
+	var links []models.RecordLink
+	if err := s.DB.Select("row_a_id", "row_b_id").Where("rule_id = ?", ruleID).Find(&links).Error; err != nil {
+		return nil, err
+	}
+	pairs := make(map[string]bool, len(links))
+	for _, l := range links {
+		pairs[pairKey(l.RowAID, l.RowBID)] = true
+	}
+	return pairs, nil
+}
+
+func pairKey(a, b uint) string {
+	return fmt.Sprintf("%d:%d", a, b)
+}
+
+// matchKey builds the composite match key of one row. sideA selects
+// which column of each field pair to read. The second return is false
+// when any match column is empty, which excludes the row from matching.
+func matchKey(row models.FileData, fields []LinkField, sideA bool) (string, bool) {
+	var data map[string]string
+	if err := json.Unmarshal(row.RowData, &data); err != nil {
+		return "", false
+	}
+	parts := make([]string, 0, len(fields))
+	for _, f := range fields {
+		column := f.ColumnB
+		if sideA {
+			column = f.ColumnA
+		}
+		value := strings.ToLower(strings.TrimSpace(data[column]))
+		if value == "" {
+			return "", false
+		}
+		parts = append(parts, value)
+	}
+	return strings.Join(parts, "\x1f"), true
+}
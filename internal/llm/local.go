@@ -0,0 +1,54 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+// LocalProvider is a hermetic Provider for development and tests: it
+// echoes a canned answer instead of calling out to Vertex.
+type LocalProvider struct {
+	// Response, when set, is returned verbatim from GenerateContent and
+	// Stream. Tests can also capture prompts via OnPrompt.
+	Response string
+	OnPrompt func(model, prompt string)
+}
+
+func NewLocalProvider() *LocalProvider {
+	return &LocalProvider{}
+}
+
+func (p *LocalProvider) answer(prompt string) string {
+	if p.Response != "" {
+		return p.Response
+	}
+	return fmt.Sprintf("[local provider] received a %d character prompt", len(prompt))
+}
+
+func (p *LocalProvider) GenerateContent(ctx context.Context, model, prompt string, opts *GenOptions) (*Result, error) {
+	if p.OnPrompt != nil {
+		p.OnPrompt(model, prompt)
+	}
+	return &Result{
+		Text:  p.answer(prompt),
+		Usage: &Usage{PromptTokens: int32(len(prompt) / 4), ResponseTokens: int32(len(p.answer(prompt)) / 4)},
+	}, nil
+}
+
+func (p *LocalProvider) Stream(ctx context.Context, model, prompt string, fn func(chunk string) error) (*Usage, error) {
+	if p.OnPrompt != nil {
+		p.OnPrompt(model, prompt)
+	}
+	answer := p.answer(prompt)
+	if err := fn(answer); err != nil {
+		return nil, err
+	}
+	return &Usage{PromptTokens: int32(len(prompt) / 4), ResponseTokens: int32(len(answer) / 4)}, nil
+}
+
+func (p *LocalProvider) TTS(ctx context.Context, text string, opts TTSOptions) ([]byte, error) {
+	// An empty MP3 payload is enough for clients that just check wiring.
+	return []byte{}, nil
+}
+
+func (p *LocalProvider) Close() error { return nil }
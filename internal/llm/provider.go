@@ -0,0 +1,70 @@
+// Package llm abstracts the model backend used by the chat subsystem so
+// it can be swapped (Vertex, local mock) without touching the services.
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/config"
+)
+
+// Usage carries the token counts a provider reported for one call, when
+// the backend exposes them.
+type Usage struct {
+	PromptTokens   int32
+	ResponseTokens int32
+}
+
+// Result is the outcome of a generate call.
+type Result struct {
+	Text  string
+	Usage *Usage
+}
+
+// GenOptions tunes one generate call. Nil/zero fields fall back to the
+// backend defaults.
+type GenOptions struct {
+	// Temperature in [0, 2]; nil leaves the model default.
+	Temperature *float32
+	// MaxOutputTokens caps the answer length; 0 leaves the model default.
+	MaxOutputTokens int32
+	// SafetyThreshold picks the harm-blocking level applied to every
+	// category: "none", "only_high", "medium_and_above" or
+	// "low_and_above". Empty leaves the platform default.
+	SafetyThreshold string
+}
+
+// TTSOptions select the synthesized voice. Zero values fall back to the
+// platform defaults.
+type TTSOptions struct {
+	Voice        string
+	LanguageCode string
+	SpeakingRate float64
+}
+
+// Provider is a text-generation and speech backend.
+type Provider interface {
+	// GenerateContent runs one prompt against the named model. opts may
+	// be nil for the backend defaults.
+	GenerateContent(ctx context.Context, model, prompt string, opts *GenOptions) (*Result, error)
+	// Stream runs one prompt and delivers the answer incrementally via
+	// fn; returning an error from fn aborts the stream.
+	Stream(ctx context.Context, model, prompt string, fn func(chunk string) error) (*Usage, error)
+	// TTS synthesizes text to MP3 audio.
+	TTS(ctx context.Context, text string, opts TTSOptions) ([]byte, error)
+	// Close releases backend resources.
+	Close() error
+}
+
+// New builds the provider selected by cfg.LLMProvider.
+func New(ctx context.Context, cfg *config.Config) (Provider, error) {
+	switch cfg.LLMProvider {
+	case "", "vertex":
+		return NewVertexProvider(ctx, cfg)
+	case "local":
+		return NewLocalProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", cfg.LLMProvider)
+	}
+}
@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	texttospeech "cloud.google.com/go/texttospeech/apiv1"
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"cloud.google.com/go/vertexai/genai"
+	"google.golang.org/api/iterator"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/config"
+)
+
+// VertexProvider backs the Provider interface with Gemini on Vertex AI
+// and Google Cloud Text-to-Speech.
+type VertexProvider struct {
+	client *genai.Client
+}
+
+func NewVertexProvider(ctx context.Context, cfg *config.Config) (*VertexProvider, error) {
+	client, err := genai.NewClient(ctx, cfg.GCPProject, cfg.GCPLocation)
+	if err != nil {
+		return nil, fmt.Errorf("vertex client: %w", err)
+	}
+	return &VertexProvider{client: client}, nil
+}
+
+func (p *VertexProvider) GenerateContent(ctx context.Context, model, prompt string, opts *GenOptions) (*Result, error) {
+	gm := p.client.GenerativeModel(model)
+	applyGenOptions(gm, opts)
+	resp, err := gm.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return nil, fmt.Errorf("generate content: %w", err)
+	}
+	result := &Result{Text: extractText(resp)}
+	if resp.UsageMetadata != nil {
+		result.Usage = &Usage{
+			PromptTokens:   resp.UsageMetadata.PromptTokenCount,
+			ResponseTokens: resp.UsageMetadata.CandidatesTokenCount,
+		}
+	}
+	return result, nil
+}
+
+func (p *VertexProvider) Stream(ctx context.Context, model, prompt string, fn func(chunk string) error) (*Usage, error) {
+	it := p.client.GenerativeModel(model).GenerateContentStream(ctx, genai.Text(prompt))
+	var usage *Usage
+	for {
+		resp, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return usage, fmt.Errorf("stream content: %w", err)
+		}
+		if resp.UsageMetadata != nil {
+			usage = &Usage{
+				PromptTokens:   resp.UsageMetadata.PromptTokenCount,
+				ResponseTokens: resp.UsageMetadata.CandidatesTokenCount,
+			}
+		}
+		if chunk := extractText(resp); chunk != "" {
+			if err := fn(chunk); err != nil {
+				return usage, err
+			}
+		}
+	}
+	return usage, nil
+}
+
+func (p *VertexProvider) TTS(ctx context.Context, text string, opts TTSOptions) ([]byte, error) {
+	client, err := texttospeech.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("tts client: %w", err)
+	}
+	defer client.Close()
+
+	if opts.Voice == "" {
+		opts.Voice = "en-US-Neural2-F"
+	}
+	if opts.LanguageCode == "" {
+		opts.LanguageCode = "en-US"
+	}
+	audioConfig := &texttospeechpb.AudioConfig{
+		AudioEncoding: texttospeechpb.AudioEncoding_MP3,
+	}
+	if opts.SpeakingRate != 0 {
+		audioConfig.SpeakingRate = opts.SpeakingRate
+	}
+	resp, err := client.SynthesizeSpeech(ctx, &texttospeechpb.SynthesizeSpeechRequest{
+		Input: &texttospeechpb.SynthesisInput{
+			InputSource: &texttospeechpb.SynthesisInput_Text{Text: text},
+		},
+		Voice: &texttospeechpb.VoiceSelectionParams{
+			LanguageCode: opts.LanguageCode,
+			Name:         opts.Voice,
+		},
+		AudioConfig: audioConfig,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("synthesize speech: %w", err)
+	}
+	return resp.AudioContent, nil
+}
+
+func (p *VertexProvider) Close() error { return p.client.Close() }
+
+// applyGenOptions copies the tunables onto the model handle.
+func applyGenOptions(gm *genai.GenerativeModel, opts *GenOptions) {
+	if opts == nil {
+		return
+	}
+	if opts.Temperature != nil {
+		gm.SetTemperature(*opts.Temperature)
+	}
+	if opts.MaxOutputTokens > 0 {
+		gm.SetMaxOutputTokens(opts.MaxOutputTokens)
+	}
+	if threshold, ok := safetyThresholds[opts.SafetyThreshold]; ok {
+		categories := []genai.HarmCategory{
+			genai.HarmCategoryHarassment,
+			genai.HarmCategoryHateSpeech,
+			genai.HarmCategorySexuallyExplicit,
+			genai.HarmCategoryDangerousContent,
+		}
+		settings := make([]*genai.SafetySetting, 0, len(categories))
+		for _, category := range categories {
+			settings = append(settings, &genai.SafetySetting{Category: category, Threshold: threshold})
+		}
+		gm.SafetySettings = settings
+	}
+}
+
+// safetyThresholds maps the config/API names onto genai constants.
+var safetyThresholds = map[string]genai.HarmBlockThreshold{
+	"none":             genai.HarmBlockNone,
+	"only_high":        genai.HarmBlockOnlyHigh,
+	"medium_and_above": genai.HarmBlockMediumAndAbove,
+	"low_and_above":    genai.HarmBlockLowAndAbove,
+}
+
+func extractText(resp *genai.GenerateContentResponse) string {
+	var b strings.Builder
+	for _, candidate := range resp.Candidates {
+		if candidate.Content == nil {
+			continue
+		}
+		for _, part := range candidate.Content.Parts {
+			if text, ok := part.(genai.Text); ok {
+				b.WriteString(string(text))
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,172 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/gcs"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+	"github.com/datanorthnordik/nordikdriveapi/internal/util"
+)
+
+const archiveObjectPrefix = "log-archives/"
+
+// archiveBatchSize bounds how many rows one archive pass exports so a
+// huge backlog is chewed through incrementally.
+const archiveBatchSize = 50000
+
+// Hooks so archival can run without a real GCS client in tests.
+var (
+	uploadLogArchiveHook = uploadLogArchive
+	readLogArchiveHook   = readLogArchive
+)
+
+// ArchiveOldLogs exports log rows older than olderThanDays to compressed
+// NDJSON in the archive bucket and deletes them from Postgres, recording
+// the archive so it can be listed and restored later.
+func (s *LogService) ArchiveOldLogs(ctx context.Context, olderThanDays int) (int64, error) {
+	if olderThanDays <= 0 {
+		return 0, fmt.Errorf("retention days must be positive")
+	}
+	cutoff := time.Now().AddDate(0, 0, -olderThanDays)
+
+	var entries []models.SystemLog
+	if err := s.DB.Where("created_at < ?", cutoff).
+		Order("created_at ASC").Limit(archiveBatchSize).Find(&entries).Error; err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(gz)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return 0, fmt.Errorf("encode log %d: %w", entry.ID, err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return 0, err
+	}
+
+	from := entries[0].CreatedAt
+	to := entries[len(entries)-1].CreatedAt
+	object := fmt.Sprintf("%s%s_%s.ndjson.gz",
+		archiveObjectPrefix, from.Format("20060102T150405"), to.Format("20060102T150405"))
+	if err := uploadLogArchiveHook(ctx, s.Config.LogArchiveBucket, object, buf.Bytes()); err != nil {
+		return 0, fmt.Errorf("upload archive: %w", err)
+	}
+
+	archive := models.LogArchive{
+		ObjectPath: "gs://" + s.Config.LogArchiveBucket + "/" + object,
+		From:       from,
+		To:         to,
+		EntryCount: int64(len(entries)),
+	}
+	if err := s.DB.Create(&archive).Error; err != nil {
+		return 0, err
+	}
+
+	ids := make([]uint, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	if err := s.DB.Where("id IN ?", ids).Delete(&models.SystemLog{}).Error; err != nil {
+		return 0, fmt.Errorf("delete archived logs: %w", err)
+	}
+	return int64(len(entries)), nil
+}
+
+// ListArchives returns the recorded log archives, newest first.
+func (s *LogService) ListArchives() ([]models.LogArchive, error) {
+	var archives []models.LogArchive
+	if err := s.DB.Order("\"from\" DESC").Find(&archives).Error; err != nil {
+		return nil, err
+	}
+	return archives, nil
+}
+
+// RestoreArchives re-imports archived entries whose range overlaps
+// [from, to] back into the logs table. Entries keep their original
+// timestamps but get fresh IDs.
+func (s *LogService) RestoreArchives(ctx context.Context, from, to time.Time) (int64, error) {
+	var archives []models.LogArchive
+	if err := s.DB.Where("\"from\" <= ? AND \"to\" >= ?", to, from).
+		Find(&archives).Error; err != nil {
+		return 0, err
+	}
+
+	var restored int64
+	for _, archive := range archives {
+		data, err := readLogArchiveHook(ctx, archive.ObjectPath)
+		if err != nil {
+			return restored, fmt.Errorf("read %s: %w", archive.ObjectPath, err)
+		}
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return restored, fmt.Errorf("decompress %s: %w", archive.ObjectPath, err)
+		}
+		scanner := bufio.NewScanner(gz)
+		scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+		for scanner.Scan() {
+			var entry models.SystemLog
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				continue
+			}
+			if entry.CreatedAt.Before(from) || entry.CreatedAt.After(to) {
+				continue
+			}
+			entry.ID = 0
+			if err := s.DB.Create(&entry).Error; err != nil {
+				return restored, err
+			}
+			restored++
+		}
+		gz.Close()
+		if err := scanner.Err(); err != nil {
+			return restored, err
+		}
+	}
+	return restored, nil
+}
+
+// RunRetentionLoop archives old logs once a day until ctx is cancelled.
+// Intended to be started from main as a goroutine when retention is
+// configured.
+func (s *LogService) RunRetentionLoop(ctx context.Context, olderThanDays int) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+	for {
+		if archived, err := s.ArchiveOldLogs(ctx, olderThanDays); err != nil {
+			log.Printf("logs: retention pass failed: %v", err)
+		} else if archived > 0 {
+			log.Printf("logs: archived %d entries older than %d days", archived, olderThanDays)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func uploadLogArchive(ctx context.Context, bucket, object string, data []byte) error {
+	_, err := gcs.Default.Upload(ctx, bucket, object, bytes.NewReader(data), "application/gzip")
+	return err
+}
+
+func readLogArchive(ctx context.Context, gsURL string) ([]byte, error) {
+	bucket, object, err := util.ParseGSURL(gsURL)
+	if err != nil {
+		return nil, err
+	}
+	return gcs.Default.Download(ctx, bucket, object)
+}
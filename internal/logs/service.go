@@ -0,0 +1,99 @@
+package logs
+
+import (
+	"log"
+	"strconv"
+
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/config"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+	"github.com/datanorthnordik/nordikdriveapi/internal/search"
+)
+
+// LogService writes audit entries to the logs table. Failures are logged
+// to stderr but never surfaced to callers: auditing must not break the
+// action being audited.
+type LogService struct {
+	DB     *gorm.DB
+	Config *config.Config
+}
+
+func NewLogService(db *gorm.DB, cfg *config.Config) *LogService {
+	return &LogService{DB: db, Config: cfg}
+}
+
+// Record appends a system log entry for the given user and action.
+func (s *LogService) Record(userID uint, action, detail string) {
+	entry := models.SystemLog{UserID: userID, Action: action, Detail: detail}
+	if err := s.DB.Create(&entry).Error; err != nil {
+		log.Printf("logs: failed to record %s: %v", action, err)
+	}
+}
+
+// logSearch declares the filterable columns of the logs table. The
+// fixed filters below build their conditions through it so the
+// column allow-list lives in one declaration (see internal/search).
+var logSearch = search.NewRegistry(map[string]search.Field{
+	"user_id": {Column: "user_id", Type: search.TypeNumber},
+	"action":  {Column: "action"},
+	"detail":  {Column: "detail"},
+}, nil)
+
+// SecurityEvents returns the AUTH_DENIED entries written by the auth
+// middleware, optionally narrowed by user, denial reason, and route
+// substring, most recent first.
+func (s *LogService) SecurityEvents(userID uint, reason, route string, limit, offset int) ([]models.SystemLog, int64, error) {
+	q := s.DB.Model(&models.SystemLog{}).Where("action = ?", "AUTH_DENIED")
+	var err error
+	if userID != 0 {
+		if q, err = logSearch.Apply(q, "user_id", "eq", strconv.FormatUint(uint64(userID), 10)); err != nil {
+			return nil, 0, err
+		}
+	}
+	if reason != "" {
+		if q, err = logSearch.Apply(q, "detail", "contains", "reason="+reason); err != nil {
+			return nil, 0, err
+		}
+	}
+	if route != "" {
+		if q, err = logSearch.Apply(q, "detail", "contains", "route="+route); err != nil {
+			return nil, 0, err
+		}
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var entries []models.SystemLog
+	if err := q.Order("created_at DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// Search returns log entries filtered by optional user and action, most
+// recent first.
+func (s *LogService) Search(userID uint, action string, limit, offset int) ([]models.SystemLog, int64, error) {
+	q := s.DB.Model(&models.SystemLog{})
+	var err error
+	if userID != 0 {
+		if q, err = logSearch.Apply(q, "user_id", "eq", strconv.FormatUint(uint64(userID), 10)); err != nil {
+			return nil, 0, err
+		}
+	}
+	if action != "" {
+		if q, err = logSearch.Apply(q, "action", "eq", action); err != nil {
+			return nil, 0, err
+		}
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var entries []models.SystemLog
+	if err := q.Order("created_at DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
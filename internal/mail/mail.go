@@ -0,0 +1,119 @@
+package mail
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/config"
+)
+
+// Message is a fully rendered email with an HTML body and a plain-text
+// fallback for clients that do not render HTML.
+type Message struct {
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Attachment is a file carried with the message, e.g. a PDF receipt.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Send delivers the message over SMTP as multipart/alternative. When
+// MAIL_DRY_RUN_DIR is set the message is rendered to that directory
+// instead, so templates can be reviewed without a mail server.
+func Send(cfg *config.Config, to string, msg *Message) error {
+	if cfg.MailDryRunDir != "" {
+		return writeDryRun(cfg.MailDryRunDir, to, msg)
+	}
+	addr := fmt.Sprintf("%s:%d", cfg.SMTPHost, cfg.SMTPPort)
+	auth := smtp.PlainAuth("", cfg.SMTPUser, cfg.SMTPPass, cfg.SMTPHost)
+	return smtp.SendMail(addr, auth, cfg.MailFrom, []string{to}, encode(cfg.MailFrom, to, msg))
+}
+
+// encode builds the raw multipart/alternative payload. The plain-text
+// part comes first so clients fall back to it only when they cannot
+// render the HTML part. Messages with attachments are wrapped in an
+// outer multipart/mixed container.
+func encode(from, to string, msg *Message) []byte {
+	const boundary = "nordikdrive-alt"
+	const mixedBoundary = "nordikdrive-mix"
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	if len(msg.Attachments) > 0 {
+		b.WriteString("Content-Type: multipart/mixed; boundary=" + mixedBoundary + "\r\n\r\n")
+		b.WriteString("--" + mixedBoundary + "\r\n")
+	}
+	b.WriteString("Content-Type: multipart/alternative; boundary=" + boundary + "\r\n\r\n")
+	b.WriteString("--" + boundary + "\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(msg.Text + "\r\n\r\n")
+	b.WriteString("--" + boundary + "\r\n")
+	b.WriteString("Content-Type: text/html; charset=utf-8\r\n\r\n")
+	b.WriteString(msg.HTML + "\r\n\r\n")
+	b.WriteString("--" + boundary + "--\r\n")
+	for _, att := range msg.Attachments {
+		contentType := att.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		b.WriteString("--" + mixedBoundary + "\r\n")
+		b.WriteString("Content-Type: " + contentType + "\r\n")
+		b.WriteString("Content-Disposition: attachment; filename=\"" + att.Filename + "\"\r\n")
+		b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+		encoded := base64.StdEncoding.EncodeToString(att.Data)
+		// RFC 2045 caps encoded lines at 76 characters.
+		for len(encoded) > 76 {
+			b.WriteString(encoded[:76] + "\r\n")
+			encoded = encoded[76:]
+		}
+		b.WriteString(encoded + "\r\n")
+	}
+	if len(msg.Attachments) > 0 {
+		b.WriteString("--" + mixedBoundary + "--\r\n")
+	}
+	return []byte(b.String())
+}
+
+// writeDryRun saves the rendered HTML and text bodies under dir, one
+// pair of files per message, named by timestamp and recipient.
+func writeDryRun(dir, to string, msg *Message) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("mail dry-run dir: %w", err)
+	}
+	stem := fmt.Sprintf("%s-%s", time.Now().Format("20060102-150405.000"), sanitizeRecipient(to))
+	if err := os.WriteFile(filepath.Join(dir, stem+".html"), []byte(msg.HTML), 0o644); err != nil {
+		return err
+	}
+	for _, att := range msg.Attachments {
+		if err := os.WriteFile(filepath.Join(dir, stem+"-"+att.Filename), att.Data, 0o644); err != nil {
+			return err
+		}
+	}
+	body := "Subject: " + msg.Subject + "\n\n" + msg.Text + "\n"
+	return os.WriteFile(filepath.Join(dir, stem+".txt"), []byte(body), 0o644)
+}
+
+// sanitizeRecipient keeps the dry-run filename portable.
+func sanitizeRecipient(to string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, to)
+}
@@ -0,0 +1,96 @@
+package mail
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// layout wraps every message body in the shared NordikDrive branding.
+// Styles are inlined because most mail clients strip <style> blocks.
+const layout = `<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:0;background:#f4f6f8;font-family:Arial,Helvetica,sans-serif;">
+  <table role="presentation" width="100%" cellpadding="0" cellspacing="0">
+    <tr><td align="center" style="padding:24px;">
+      <table role="presentation" width="560" cellpadding="0" cellspacing="0" style="background:#ffffff;border-radius:8px;overflow:hidden;">
+        <tr><td style="background:#1b3a5c;color:#ffffff;padding:16px 24px;font-size:18px;font-weight:bold;">NordikDrive</td></tr>
+        <tr><td style="padding:24px;color:#24292f;font-size:14px;line-height:1.6;">{{.Body}}</td></tr>
+        <tr><td style="padding:16px 24px;color:#6e7781;font-size:12px;border-top:1px solid #eaeef2;">This message was sent by NordikDrive. If you were not expecting it, you can ignore it.</td></tr>
+      </table>
+    </td></tr>
+  </table>
+</body>
+</html>`
+
+var layoutTmpl = template.Must(template.New("layout").Parse(layout))
+
+// render wraps an already-escaped HTML fragment in the branded layout.
+func render(body template.HTML) string {
+	var b strings.Builder
+	// The layout template cannot fail on a struct with a matching field.
+	_ = layoutTmpl.Execute(&b, struct{ Body template.HTML }{body})
+	return b.String()
+}
+
+// htmlf formats the fragment with every argument HTML-escaped.
+func htmlf(format string, args ...any) template.HTML {
+	escaped := make([]any, len(args))
+	for i, a := range args {
+		escaped[i] = template.HTMLEscapeString(fmt.Sprint(a))
+	}
+	return template.HTML(fmt.Sprintf(format, escaped...))
+}
+
+// OTP renders the password-reset verification code email.
+func OTP(code string, minutes int) *Message {
+	return &Message{
+		Subject: "NordikDrive verification code",
+		Text:    fmt.Sprintf("Your NordikDrive verification code is %s. It expires in %d minutes.", code, minutes),
+		HTML: render(htmlf(
+			`<p>Your verification code is:</p>
+<p style="font-size:28px;font-weight:bold;letter-spacing:4px;">%s</p>
+<p>It expires in %s minutes. Do not share this code with anyone.</p>`, code, minutes)),
+	}
+}
+
+// Notification renders an event from the in-app inbox for email
+// delivery, e.g. an edit request being approved, rejected or stale.
+func Notification(title, body string) *Message {
+	return &Message{
+		Subject: title,
+		Text:    body,
+		HTML: render(htmlf(
+			`<p style="font-size:16px;font-weight:bold;">%s</p>
+<p>%s</p>`, title, body)),
+	}
+}
+
+// Receipt renders the submission-receipt email; the PDF itself travels
+// as an attachment.
+func Receipt(formLabel, reference string) *Message {
+	return &Message{
+		Subject: "Your NordikDrive submission receipt",
+		Text: fmt.Sprintf("Thank you for your submission to %s. Your reference number is %s. A PDF receipt is attached for your records.",
+			formLabel, reference),
+		HTML: render(htmlf(
+			`<p>Thank you for your submission to <strong>%s</strong>.</p>
+<p>Your reference number is <strong>%s</strong>.</p>
+<p>A PDF receipt is attached for your records.</p>`, formLabel, reference)),
+	}
+}
+
+// Invite renders an invitation to join NordikDrive sent by an existing
+// user.
+func Invite(inviterName, link string) *Message {
+	return &Message{
+		Subject: "You have been invited to NordikDrive",
+		Text: fmt.Sprintf("%s has invited you to join NordikDrive. Open %s to create your account.",
+			inviterName, link),
+		HTML: render(htmlf(
+			`<p>%s has invited you to join NordikDrive.</p>
+<p><a href="%s" style="display:inline-block;background:#1b3a5c;color:#ffffff;padding:10px 20px;border-radius:4px;text-decoration:none;">Create your account</a></p>
+<p>If the button does not work, copy this link into your browser:<br>%s</p>`,
+			inviterName, link, link)),
+	}
+}
@@ -0,0 +1,167 @@
+// Package media centralizes the HTTP plumbing for serving stored files:
+// content-type resolution by extension, Content-Disposition values
+// (inline vs attachment decided by type, RFC 5987 encoding for non-ASCII
+// filenames), and single-range parsing so large objects like PDFs can be
+// streamed in chunks. The per-controller copies of this logic had
+// already drifted on quoting and type coverage.
+package media
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// contentTypes covers the extensions the API actually stores; anything
+// else falls through to the platform MIME table, then octet-stream.
+var contentTypes = map[string]string{
+	".csv":  "text/csv",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".pdf":  "application/pdf",
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".mp3":  "audio/mpeg",
+	".mp4":  "video/mp4",
+	".txt":  "text/plain",
+	".zip":  "application/zip",
+	".gz":   "application/gzip",
+}
+
+// ContentType resolves a content type from the filename's extension.
+func ContentType(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ct, ok := contentTypes[ext]; ok {
+		return ct
+	}
+	if ct := mime.TypeByExtension(ext); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// Disposition returns "inline" for types browsers render natively
+// (images, PDFs, audio, video, plain text) and "attachment" for
+// everything else, so spreadsheets and archives download instead of
+// producing a garbled tab.
+func Disposition(contentType string) string {
+	switch {
+	case strings.HasPrefix(contentType, "image/"),
+		strings.HasPrefix(contentType, "audio/"),
+		strings.HasPrefix(contentType, "video/"),
+		contentType == "application/pdf",
+		contentType == "text/plain":
+		return "inline"
+	}
+	return "attachment"
+}
+
+// ContentDisposition builds the header value with an ASCII fallback name
+// and, when the filename contains anything beyond it, an RFC 5987
+// filename* parameter so unicode names survive the round trip.
+func ContentDisposition(disposition, filename string) string {
+	fallback := asciiFallback(filename)
+	v := disposition + `; filename="` + fallback + `"`
+	if fallback != filename {
+		v += "; filename*=UTF-8''" + percentEncode(filename)
+	}
+	return v
+}
+
+// asciiFallback replaces everything a quoted filename parameter can't
+// carry with underscores.
+func asciiFallback(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r > 0x20 && r < 0x7f && r != '"' && r != '\\' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// percentEncode escapes a string per RFC 5987 attr-char rules.
+func percentEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+			strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// ErrUnsatisfiableRange is returned when a Range header is syntactically
+// valid but lies entirely outside the object; callers should answer 416.
+var ErrUnsatisfiableRange = errors.New("requested range not satisfiable")
+
+// ParseRange interprets a single-range bytes= header into (offset,
+// length) arguments for a range read: offset may be negative for suffix
+// ranges (counting back from the end) and length -1 means "to the end",
+// matching the GCS range-reader convention. ok is false when the header
+// is absent, malformed, or multi-range, in which case callers serve the
+// whole object, as RFC 9110 permits.
+func ParseRange(header string) (offset, length int64, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, -1, false
+	}
+	first, last, found := strings.Cut(spec, "-")
+	if !found {
+		return 0, -1, false
+	}
+	if first == "" {
+		// Suffix form: the last N bytes.
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, -1, false
+		}
+		return -n, -1, true
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, -1, false
+	}
+	if last == "" {
+		return start, -1, true
+	}
+	end, err := strconv.ParseInt(last, 10, 64)
+	if err != nil || end < start {
+		return 0, -1, false
+	}
+	return start, end - start + 1, true
+}
+
+// SatisfiedRange resolves a (offset, length) pair from ParseRange
+// against the object's actual size, returning the absolute start and the
+// byte count that will actually be served.
+func SatisfiedRange(offset, length, size int64) (start, n int64) {
+	start = offset
+	if start < 0 {
+		start = size + offset
+		if start < 0 {
+			start = 0
+		}
+	}
+	n = size - start
+	if length >= 0 && length < n {
+		n = length
+	}
+	return start, n
+}
+
+// ContentRange formats the Content-Range header for a 206 response.
+func ContentRange(start, length, size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, size)
+}
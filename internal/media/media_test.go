@@ -0,0 +1,94 @@
+package media
+
+import "testing"
+
+func TestContentType(t *testing.T) {
+	cases := []struct{ name, want string }{
+		{"data.csv", "text/csv"},
+		{"Photo.JPG", "image/jpeg"},
+		{"bundle.zip", "application/zip"},
+		{"answer.mp3", "audio/mpeg"},
+		{"no-extension", "application/octet-stream"},
+	}
+	for _, tc := range cases {
+		if got := ContentType(tc.name); got != tc.want {
+			t.Errorf("ContentType(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDisposition(t *testing.T) {
+	inline := []string{"image/png", "application/pdf", "audio/mpeg", "video/mp4", "text/plain"}
+	for _, ct := range inline {
+		if got := Disposition(ct); got != "inline" {
+			t.Errorf("Disposition(%q) = %q, want inline", ct, got)
+		}
+	}
+	attachment := []string{"text/csv", "application/zip", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"}
+	for _, ct := range attachment {
+		if got := Disposition(ct); got != "attachment" {
+			t.Errorf("Disposition(%q) = %q, want attachment", ct, got)
+		}
+	}
+}
+
+func TestContentDisposition(t *testing.T) {
+	if got := ContentDisposition("attachment", "report.csv"); got != `attachment; filename="report.csv"` {
+		t.Errorf("ascii name: %q", got)
+	}
+	got := ContentDisposition("inline", "première nation.pdf")
+	want := `inline; filename="premi_re_nation.pdf"; filename*=UTF-8''premi%C3%A8re%20nation.pdf`
+	if got != want {
+		t.Errorf("unicode name:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	cases := []struct {
+		header         string
+		offset, length int64
+		ok             bool
+	}{
+		{"bytes=0-499", 0, 500, true},
+		{"bytes=500-", 500, -1, true},
+		{"bytes=-200", -200, -1, true}, // suffix: last 200 bytes
+		{"", 0, -1, false},
+		{"bytes=0-499,600-700", 0, -1, false}, // multi-range unsupported
+		{"bytes=500-100", 0, -1, false},
+		{"bytes=abc-def", 0, -1, false},
+		{"items=0-499", 0, -1, false},
+	}
+	for _, tc := range cases {
+		offset, length, ok := ParseRange(tc.header)
+		if offset != tc.offset || length != tc.length || ok != tc.ok {
+			t.Errorf("ParseRange(%q) = (%d, %d, %v), want (%d, %d, %v)",
+				tc.header, offset, length, ok, tc.offset, tc.length, tc.ok)
+		}
+	}
+}
+
+func TestSatisfiedRange(t *testing.T) {
+	cases := []struct {
+		offset, length, size int64
+		start, n             int64
+	}{
+		{0, 500, 1000, 0, 500},
+		{500, -1, 1000, 500, 500},
+		{-200, -1, 1000, 800, 200},
+		{-2000, -1, 1000, 0, 1000}, // suffix longer than the object
+		{0, 5000, 1000, 0, 1000},   // length clamped to the object
+	}
+	for _, tc := range cases {
+		start, n := SatisfiedRange(tc.offset, tc.length, tc.size)
+		if start != tc.start || n != tc.n {
+			t.Errorf("SatisfiedRange(%d, %d, %d) = (%d, %d), want (%d, %d)",
+				tc.offset, tc.length, tc.size, start, n, tc.start, tc.n)
+		}
+	}
+}
+
+func TestContentRange(t *testing.T) {
+	if got := ContentRange(500, 200, 1000); got != "bytes 500-699/1000" {
+		t.Errorf("ContentRange = %q, want bytes 500-699/1000", got)
+	}
+}
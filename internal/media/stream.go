@@ -0,0 +1,12 @@
+package media
+
+import "github.com/gin-gonic/gin"
+
+// AbortStream records an error that occurred after response headers were
+// already sent, as in the streaming ZIP and CSV downloads. The payload
+// cannot be fixed at that point — the truncated body is the only signal
+// the client gets — so the error is attached to the gin context for the
+// logging middleware instead.
+func AbortStream(c *gin.Context, err error) {
+	_ = c.Error(err)
+}
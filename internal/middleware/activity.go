@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// activityThrottle is the minimum gap between two last_active_at writes
+// for the same user, so a busy session costs one UPDATE instead of one
+// per request.
+const activityThrottle = 5 * time.Minute
+
+// TrackActivity stamps users.last_active_at for authenticated requests.
+// It must run after RequireAuth; requests without claims pass through
+// untouched. Writes happen off the request path and are throttled per
+// user.
+func TrackActivity(db *gorm.DB) gin.HandlerFunc {
+	var mu sync.Mutex
+	lastStamp := make(map[uint]time.Time)
+
+	return func(c *gin.Context) {
+		c.Next()
+
+		claims := GetClaims(c)
+		if claims == nil {
+			return
+		}
+		now := time.Now()
+		mu.Lock()
+		if now.Sub(lastStamp[claims.UserID]) < activityThrottle {
+			mu.Unlock()
+			return
+		}
+		lastStamp[claims.UserID] = now
+		mu.Unlock()
+
+		go func(userID uint) {
+			if err := db.Table("users").Where("id = ?", userID).
+				Update("last_active_at", now).Error; err != nil {
+				log.Printf("activity: stamp user %d: %v", userID, err)
+			}
+		}(claims.UserID)
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/i18n"
+)
+
+// heavySem caps how many expensive requests (upload parsing, ZIP
+// exports, chat completions) run at once, so one admin's giant export
+// doesn't starve everyone's file reads on a small instance. Nil means
+// unlimited.
+var heavySem chan struct{}
+
+// backpressureRetryAfter is the Retry-After hint sent with a 429. The
+// limited operations are seconds-long, not milliseconds-long, so a
+// short fixed hint is good enough.
+const backpressureRetryAfter = 5 * time.Second
+
+// SetHeavyConcurrency sizes the semaphore behind Backpressure. Seeded
+// from config at startup; max <= 0 disables the limiter.
+func SetHeavyConcurrency(max int) {
+	if max <= 0 {
+		heavySem = nil
+		return
+	}
+	heavySem = make(chan struct{}, max)
+}
+
+// Backpressure admits a request only while a heavy-work slot is free,
+// answering 429 with a Retry-After hint when all slots are taken. It
+// never queues: a saturated instance should shed load immediately
+// rather than stack up multi-minute requests.
+func Backpressure() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sem := heavySem
+		if sem == nil {
+			c.Next()
+			return
+		}
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+			c.Next()
+		default:
+			c.Header("Retry-After", strconv.Itoa(int(backpressureRetryAfter.Seconds())))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests,
+				gin.H{"error": i18n.T(c, "the server is busy; please retry in a moment")})
+		}
+	}
+}
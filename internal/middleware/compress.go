@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// compressMinBytes is the response size below which compression is not
+// worth the CPU or the header overhead; small bodies pass through.
+const compressMinBytes = 1 << 10
+
+// compressibleTypes is the content-type allow-list. Images, ZIPs and
+// audio are already compressed and only get bigger inside gzip.
+var compressibleTypes = []string{
+	"application/json",
+	"application/x-ndjson",
+	"text/plain",
+	"text/csv",
+	"text/html",
+}
+
+// Compress gzips responses for clients that advertise it. The decision
+// is deferred: writes are buffered until compressMinBytes so small
+// bodies skip compression, and streaming handlers that Flush early get
+// a decision at the first flush so chunks keep going out incrementally.
+func Compress() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+			c.Next()
+			return
+		}
+		cw := &compressWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = cw
+		defer cw.close()
+		c.Next()
+	}
+}
+
+// compressWriter wraps the gin writer, buffering the first chunk of the
+// body until it can decide whether to gzip.
+type compressWriter struct {
+	gin.ResponseWriter
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	// Recorded, not forwarded: the Content-Encoding header has to be in
+	// place before the underlying writer sends the header block.
+	w.status = code
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) >= compressMinBytes {
+			if err := w.decide(true); err != nil {
+				return 0, err
+			}
+		}
+		return len(b), nil
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// decide commits to compressing or not, sends the header block, and
+// drains the buffer. Bodies that never reached the threshold pass
+// through uncompressed unless force says otherwise.
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.ResponseWriter.Header()
+	if compress && header.Get("Content-Encoding") == "" && typeCompressible(header.Get("Content-Type")) {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", "gzip")
+		header.Add("Vary", "Accept-Encoding")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	var err error
+	if len(w.buf) > 0 {
+		if w.gz != nil {
+			_, err = w.gz.Write(w.buf)
+		} else {
+			_, err = w.ResponseWriter.Write(w.buf)
+		}
+		w.buf = nil
+	}
+	return err
+}
+
+// Flush lets streaming handlers push chunks through; the first flush
+// forces the compression decision on whatever is buffered.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(len(w.buf) >= compressMinBytes)
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// close finishes the response at the end of the request.
+func (w *compressWriter) close() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+	}
+}
+
+func typeCompressible(contentType string) bool {
+	for _, t := range compressibleTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/i18n"
+)
+
+// maintenanceMode is the process-wide read-only switch. Seeded from
+// config at startup and flippable at runtime via the admin endpoint.
+var maintenanceMode atomic.Bool
+
+// SetMaintenanceMode turns the read-only switch on or off.
+func SetMaintenanceMode(on bool) { maintenanceMode.Store(on) }
+
+// InMaintenanceMode reports whether the switch is currently on.
+func InMaintenanceMode() bool { return maintenanceMode.Load() }
+
+// maintenanceExemptPath is the admin toggle itself, which must stay
+// writable so maintenance mode can be turned off again.
+const maintenanceExemptPath = "/api/admin/maintenance"
+
+// MaintenanceGuard rejects mutating requests with 503 while maintenance
+// mode is on, leaving reads working so schema migrations and bucket
+// reorganizations can run without taking the API down entirely.
+func MaintenanceGuard() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maintenanceMode.Load() {
+			switch c.Request.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+			default:
+				if c.FullPath() != maintenanceExemptPath {
+					c.AbortWithStatusJSON(http.StatusServiceUnavailable,
+						gin.H{"error": i18n.T(c, "the platform is under maintenance; changes are temporarily disabled but browsing still works")})
+					return
+				}
+			}
+		}
+		c.Next()
+	}
+}
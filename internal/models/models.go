@@ -0,0 +1,505 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Auth is a registered user account. The table is named "users" for
+// historical reasons; the struct keeps the Auth name used throughout the
+// auth package.
+type Auth struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	FirstName   string         `json:"firstname"`
+	LastName    string         `json:"lastname"`
+	Email       string         `gorm:"uniqueIndex" json:"email"`
+	Password    string         `json:"-"`
+	Role        string         `gorm:"default:User" json:"role"`
+	Communities datatypes.JSON `json:"communities"`
+	// CanUpload gates dataset creation and replacement; users without it
+	// can still submit edit requests and forms. Admins always may.
+	CanUpload bool `gorm:"default:true" json:"can_upload"`
+	// EmailVerified is set once the user confirms the code mailed at
+	// signup. Unverified accounts can browse but not upload files or
+	// submit edit requests; accounts predating the column verify once
+	// through the same resend flow.
+	EmailVerified bool `gorm:"default:false" json:"email_verified"`
+	// EthicsReviewer allows an admin to export chat transcripts for
+	// research-governance review.
+	EthicsReviewer bool `gorm:"default:false" json:"ethics_reviewer"`
+	// PasswordChangedAt invalidates refresh tokens issued before it, so
+	// a password change revokes every other session.
+	PasswordChangedAt *time.Time `json:"-"`
+	// LastLoginAt is stamped on every successful login; LastActiveAt is
+	// stamped (throttled) by middleware on authenticated requests so
+	// dormant accounts can be identified.
+	LastLoginAt  *time.Time `json:"last_login_at"`
+	LastActiveAt *time.Time `json:"last_active_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (Auth) TableName() string { return "users" }
+
+// Role is a lookup row for the role dropdowns in the admin UI.
+type Role struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Name string `gorm:"uniqueIndex" json:"name"`
+	// Permissions is a JSON array of named permissions the role grants;
+	// see the permissions package for the defined names. Admin holds
+	// every permission implicitly.
+	Permissions datatypes.JSON `json:"permissions"`
+}
+
+// File is an uploaded dataset. Row data lives in FileData keyed by the
+// current version; replacing a file bumps Version and inserts new rows.
+type File struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	Filename     string         `gorm:"uniqueIndex" json:"filename"`
+	ColumnsOrder datatypes.JSON `json:"columns_order"`
+	InsertedBy   uint           `json:"inserted_by"`
+	// UploaderCommunity is the uploader's communities at upload time,
+	// comma-separated, captured from JWT claims.
+	UploaderCommunity string         `json:"uploader_community"`
+	Private           bool           `json:"private"`
+	IsDelete          bool           `gorm:"default:false" json:"is_delete"`
+	DeletedBy         *uint          `json:"deleted_by"`
+	DeletedAt         *time.Time     `json:"deleted_at"`
+	Rows              int            `json:"rows"`
+	Size              int64          `json:"size"`
+	Version           int            `gorm:"default:1" json:"version"`
+	Public            bool           `gorm:"default:false" json:"public"`
+	PublicColumns     datatypes.JSON `json:"public_columns"`
+	EmbargoUntil      *time.Time     `json:"embargo_until"`
+	// Dataset metadata, editable by the owner or a manager, so exports
+	// are self-describing.
+	Description      string `gorm:"type:text" json:"description"`
+	SourceOrg        string `json:"source_org"`
+	Citation         string `gorm:"type:text" json:"citation"`
+	License          string `json:"license"`
+	CollectionPeriod string `json:"collection_period"`
+	// DefaultSortColumn/Dir order GetFileData responses server-side;
+	// empty column means upload order (by row id). The column is
+	// validated against ColumnsOrder when set.
+	DefaultSortColumn string    `json:"default_sort_column"`
+	DefaultSortDir    string    `json:"default_sort_dir"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+func (File) TableName() string { return "file" }
+
+// FileVersion records each upload/replacement of a file.
+type FileVersion struct {
+	ID        uint `gorm:"primaryKey" json:"id"`
+	FileID    uint `gorm:"index" json:"file_id"`
+	Version   int  `json:"version"`
+	CreatedBy uint `json:"created_by"`
+	// ArchiveURL points at the compressed NDJSON export of this
+	// version's rows once they were moved out of file_data. ArchivedAt
+	// is unset while the rows are hot, including after rehydration.
+	ArchiveURL string     `json:"archive_url,omitempty"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	// OriginalURL points at the raw uploaded bytes archived under
+	// originals/ when STORE_ORIGINAL_UPLOADS is on; Checksum is the
+	// SHA-256 of those bytes, kept for provenance.
+	OriginalURL string    `json:"original_url,omitempty"`
+	Checksum    string    `gorm:"index" json:"checksum,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// FileSnapshot freezes one version of a file under a named, shareable
+// token so researchers can cite the data "as of" a date. Snapshots are
+// immutable: later replacements bump the file's version but the
+// snapshot keeps serving the version recorded here.
+type FileSnapshot struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	FileID    uint      `gorm:"index" json:"file_id"`
+	Version   int       `json:"version"`
+	Name      string    `json:"name"`
+	Token     string    `gorm:"uniqueIndex" json:"token"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (FileSnapshot) TableName() string { return "file_snapshots" }
+
+// CellAnnotation records provenance for one cell — the source a fill
+// color marked at import, or a reviewer-entered note — separately from
+// the value, so exports can show sources without altering the data
+// itself. RowID references the file_data row.
+type CellAnnotation struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	FileID     uint      `gorm:"index;uniqueIndex:idx_cell_annotation" json:"file_id"`
+	RowID      uint      `gorm:"uniqueIndex:idx_cell_annotation" json:"row_id"`
+	Column     string    `gorm:"uniqueIndex:idx_cell_annotation" json:"column"`
+	Source     string    `json:"source"`
+	Confidence string    `json:"confidence"`
+	CreatedBy  uint      `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (CellAnnotation) TableName() string { return "cell_annotations" }
+
+// FileComment is a threaded note on a file left by an admin or
+// contributor — caveats about columns, provenance pointers, anything a
+// future maintainer of the dataset should know. Replies reference their
+// parent comment.
+type FileComment struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	FileID    uint      `gorm:"index" json:"file_id"`
+	ParentID  *uint     `gorm:"index" json:"parent_id,omitempty"`
+	Body      string    `gorm:"type:text" json:"body"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (FileComment) TableName() string { return "file_comments" }
+
+// FileData is a single row of an uploaded dataset. RowData maps header
+// name to cell value.
+type FileData struct {
+	ID      uint           `gorm:"primaryKey" json:"id"`
+	FileID  uint           `gorm:"index" json:"file_id"`
+	Version int            `gorm:"index" json:"version"`
+	RowData datatypes.JSON `json:"row_data"`
+}
+
+func (FileData) TableName() string { return "file_data" }
+
+// FileAccess grants a user access to a private file at one of three
+// levels: viewer (read), contributor (read + edit requests), manager
+// (read + edit requests + access management).
+type FileAccess struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	FileID     uint   `gorm:"index" json:"file_id"`
+	UserID     uint   `gorm:"index" json:"user_id"`
+	Permission string `gorm:"default:viewer" json:"permission"`
+	// ExpiresAt, when set, makes the grant lapse on its own; nil grants
+	// last until revoked. Expired rows are kept for the audit trail.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (FileAccess) TableName() string { return "file_access" }
+
+// FileEditRequest is a community-submitted correction to a row, reviewed
+// by an admin before being applied to file_data.
+type FileEditRequest struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	FileID      uint   `gorm:"index" json:"file_id"`
+	RowID       uint   `gorm:"index" json:"row_id"`
+	Status      string `gorm:"default:pending" json:"status"`
+	RequestedBy uint   `json:"requested_by"`
+	FirstName   string `json:"firstname"`
+	LastName    string `json:"lastname"`
+	// UploaderCommunity is the requester's communities at creation time,
+	// comma-separated; the admin search groups and filters on it.
+	UploaderCommunity string    `json:"uploader_community"`
+	ApprovedBy        uint      `json:"approved_by"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+func (FileEditRequest) TableName() string { return "file_edit_request" }
+
+// FileEditRequestDetail is one proposed field change within a request.
+type FileEditRequestDetail struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	RequestID uint   `gorm:"index" json:"request_id"`
+	FieldName string `json:"field_name"`
+	OldValue  string `json:"old_value"`
+	NewValue  string `json:"new_value"`
+}
+
+func (FileEditRequestDetail) TableName() string { return "file_edit_request_details" }
+
+// FileEditRequestPhoto is a photo attached to an edit request. Photos sit
+// under a temp GCS prefix until the request is approved.
+type FileEditRequestPhoto struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	RequestID  uint   `gorm:"index" json:"request_id"`
+	FileID     uint   `gorm:"index" json:"file_id"`
+	RowID      uint   `gorm:"index" json:"row_id"`
+	GCSPath    string `json:"gcs_path"`
+	Status     string `gorm:"default:pending" json:"status"`
+	UploadedBy uint   `json:"uploaded_by"`
+	// Caption and SortOrder let curators arrange how approved photos
+	// appear in a record's gallery.
+	Caption   string    `json:"caption"`
+	SortOrder int       `gorm:"default:0" json:"sort_order"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (FileEditRequestPhoto) TableName() string { return "file_edit_request_photos" }
+
+// DocumentCategory is one admin-managed label for supporting documents
+// (death certificate, band record, news clipping, ...). While the table
+// is empty, document categories are uncontrolled free text.
+type DocumentCategory struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"uniqueIndex" json:"name"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (DocumentCategory) TableName() string { return "document_categories" }
+
+// FileEditRequestDocument is a supporting document attached to an edit
+// request (death certificate, band record, ...).
+type FileEditRequestDocument struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	RequestID        uint      `gorm:"index" json:"request_id"`
+	FileID           uint      `gorm:"index" json:"file_id"`
+	RowID            uint      `gorm:"index" json:"row_id"`
+	GCSPath          string    `json:"gcs_path"`
+	DocumentCategory string    `json:"document_category"`
+	UploadedBy       uint      `json:"uploaded_by"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func (FileEditRequestDocument) TableName() string { return "file_edit_request_documents" }
+
+// FormSubmission is an intake form answered against a specific row of a
+// file. One submission per (form_key, file, row, user); repeated saves
+// upsert.
+type FormSubmission struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	FormKey      string `gorm:"index" json:"form_key"`
+	FormLabel    string `json:"form_label"`
+	FileKey      string `gorm:"index" json:"file_key"`
+	RowID        uint   `gorm:"index" json:"row_id"`
+	SubmittedBy  uint   `gorm:"index" json:"submitted_by"`
+	Consent      bool   `json:"consent"`
+	Status       string `gorm:"default:submitted" json:"status"`
+	ReviewStatus string `gorm:"default:new" json:"review_status"`
+	// ReceiptRef is the human-readable reference number printed on the
+	// PDF receipt; ReceiptPath is where the generated PDF lives in GCS.
+	ReceiptRef  string `json:"receipt_ref"`
+	ReceiptPath string `json:"-"`
+	// AnonymousEmail is set instead of SubmittedBy for unauthenticated
+	// submissions; VerifyToken holds the emailed confirmation token
+	// until VerifiedAt is stamped. Anonymous submissions stay hidden
+	// from the admin queue until verified.
+	AnonymousEmail string     `json:"anonymous_email,omitempty"`
+	VerifyToken    string     `gorm:"index" json:"-"`
+	VerifiedAt     *time.Time `json:"verified_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (FormSubmission) TableName() string { return "form_submission" }
+
+// FormDefinition registers the fields, types and consent requirements of
+// a form so submissions can be validated server-side.
+type FormDefinition struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	FormKey        string `gorm:"uniqueIndex" json:"form_key"`
+	Label          string `json:"label"`
+	RequireConsent bool   `json:"require_consent"`
+	// AllowAnonymous opens the form to unauthenticated submissions,
+	// which are held until their email address is verified.
+	AllowAnonymous bool           `gorm:"default:false" json:"allow_anonymous"`
+	Fields         datatypes.JSON `json:"fields"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+func (FormDefinition) TableName() string { return "form_definitions" }
+
+// FormSubmissionDetail is a single answered field of a form submission.
+type FormSubmissionDetail struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	SubmissionID uint   `gorm:"index" json:"submission_id"`
+	FieldKey     string `json:"field_key"`
+	FieldLabel   string `json:"field_label"`
+	FieldType    string `json:"field_type"`
+	Value        string `json:"value"`
+}
+
+func (FormSubmissionDetail) TableName() string { return "form_submission_details" }
+
+// FormSubmissionUpload is a photo or document uploaded with a form
+// submission, stored under requests/<form>/<file>_<row>/ in GCS.
+type FormSubmissionUpload struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	SubmissionID uint      `gorm:"index" json:"submission_id"`
+	Kind         string    `json:"kind"`
+	Filename     string    `json:"filename"`
+	GCSPath      string    `json:"gcs_path"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (FormSubmissionUpload) TableName() string { return "form_submission_uploads" }
+
+// Notification is one entry in a user's in-app inbox.
+type Notification struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index" json:"user_id"`
+	EventType string    `gorm:"index" json:"event_type"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Read      bool      `gorm:"default:false" json:"read"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (Notification) TableName() string { return "notifications" }
+
+// NotificationPreference is a user's delivery choice for one event type.
+// Users without a row get the defaults: in-app on, email off.
+type NotificationPreference struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	UserID    uint   `gorm:"index" json:"user_id"`
+	EventType string `json:"event_type"`
+	InApp     bool   `gorm:"default:true" json:"in_app"`
+	Email     bool   `gorm:"default:false" json:"email"`
+}
+
+func (NotificationPreference) TableName() string { return "notification_preferences" }
+
+// RowClaim records a community member's claimed relationship to a row
+// ("this is my relative"), verified by an admin before it grants any
+// follow access.
+type RowClaim struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	FileID       uint      `gorm:"index" json:"file_id"`
+	RowID        uint      `gorm:"index" json:"row_id"`
+	UserID       uint      `gorm:"index" json:"user_id"`
+	Relationship string    `json:"relationship"`
+	Status       string    `gorm:"default:pending" json:"status"`
+	VerifiedBy   uint      `json:"verified_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (RowClaim) TableName() string { return "row_claims" }
+
+// ComputedColumn is an admin-defined derived column for a file, stored
+// as an expression and evaluated when rows are served or exported.
+type ComputedColumn struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	FileID     uint      `gorm:"index" json:"file_id"`
+	Name       string    `json:"name"`
+	Expression string    `json:"expression"`
+	CreatedBy  uint      `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (ComputedColumn) TableName() string { return "computed_columns" }
+
+// LinkRule defines how rows of two files are matched by the linkage
+// subsystem (e.g. name + birth year).
+type LinkRule struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Name      string         `json:"name"`
+	FileAID   uint           `gorm:"index" json:"file_a_id"`
+	FileBID   uint           `gorm:"index" json:"file_b_id"`
+	Fields    datatypes.JSON `json:"fields"`
+	CreatedBy uint           `json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+func (LinkRule) TableName() string { return "link_rules" }
+
+// RecordLink is one candidate match between a row in file A and a row in
+// file B, awaiting admin confirmation.
+type RecordLink struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	RuleID     uint      `gorm:"index" json:"rule_id"`
+	RowAID     uint      `gorm:"index" json:"row_a_id"`
+	RowBID     uint      `gorm:"index" json:"row_b_id"`
+	Score      float64   `json:"score"`
+	Status     string    `gorm:"default:pending" json:"status"`
+	ReviewedBy uint      `json:"reviewed_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (RecordLink) TableName() string { return "record_links" }
+
+// ChatUsage records the token cost of one chat call so Vertex spend can
+// be attributed and capped per user.
+type ChatUsage struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	UserID         uint      `gorm:"index" json:"user_id"`
+	File           string    `json:"file"`
+	PromptTokens   int32     `json:"prompt_tokens"`
+	ResponseTokens int32     `json:"response_tokens"`
+	CreatedAt      time.Time `gorm:"index" json:"created_at"`
+}
+
+func (ChatUsage) TableName() string { return "chat_usage" }
+
+// ChatTranscript keeps one chat Q&A pair for research-governance review.
+// Only the question and answer are stored — never the row context that
+// was in the prompt, which may contain registry data.
+type ChatTranscript struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index" json:"user_id"`
+	File      string    `json:"file"`
+	Question  string    `gorm:"type:text" json:"question"`
+	Answer    string    `gorm:"type:text" json:"answer"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (ChatTranscript) TableName() string { return "chat_transcripts" }
+
+// LoginEvent records one login or token-refresh attempt with the
+// client's IP, user agent and coarse country, for the user-facing
+// login history and for account-compromise alerting. Failed logins for
+// unknown emails are kept too (UserID 0) so password spraying shows up.
+type LoginEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index" json:"user_id"`
+	Email     string    `gorm:"index" json:"email"`
+	Kind      string    `json:"kind"` // "login" or "refresh"
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Country   string    `json:"country"`
+	Success   bool      `json:"success"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (LoginEvent) TableName() string { return "login_events" }
+
+// SystemLog is an append-only audit record of notable actions.
+type SystemLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index" json:"user_id"`
+	Action    string    `gorm:"index" json:"action"`
+	Detail    string    `json:"detail"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (SystemLog) TableName() string { return "logs" }
+
+// LogArchive records one batch of logs exported to GCS by the retention
+// job so archives can be listed and restored.
+type LogArchive struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ObjectPath string    `json:"object_path"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	EntryCount int64     `json:"entry_count"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (LogArchive) TableName() string { return "log_archives" }
+
+// OTP is a one-time password issued for password reset, valid for ten
+// minutes.
+type OTP struct {
+	ID    uint   `gorm:"primaryKey" json:"id"`
+	Email string `gorm:"index" json:"email"`
+	Code  string `json:"-"`
+	// Attempts counts failed verifications; the code stops working once
+	// it reaches the limit, so six digits cannot be brute-forced within
+	// the validity window.
+	Attempts  int       `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (OTP) TableName() string { return "otps" }
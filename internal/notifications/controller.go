@@ -0,0 +1,107 @@
+package notifications
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/middleware"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+// Service is the surface NotificationController depends on,
+// implemented by NotificationService. Kept as an interface so alternate
+// backends can be wired in by the server container.
+type Service interface {
+	List(userID uint, unreadOnly bool) ([]models.Notification, error)
+	MarkRead(notificationID, userID uint) error
+	MarkAllRead(userID uint) error
+	GetPreferences(userID uint) ([]models.NotificationPreference, error)
+	SetPreference(userID uint, eventType string, inApp, email bool) (*models.NotificationPreference, error)
+}
+
+var _ Service = (*NotificationService)(nil)
+
+// NotificationController exposes the /api/notifications endpoints.
+type NotificationController struct {
+	Service Service
+}
+
+func NewNotificationController(service Service) *NotificationController {
+	return &NotificationController{Service: service}
+}
+
+// RegisterRoutes mounts the notification endpoints. The group is
+// expected to be behind RequireAuth.
+func (ctl *NotificationController) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/notifications", ctl.List)
+	rg.POST("/notifications/:id/read", ctl.MarkRead)
+	rg.POST("/notifications/read-all", ctl.MarkAllRead)
+	rg.GET("/notifications/preferences", ctl.GetPreferences)
+	rg.PUT("/notifications/preferences", ctl.SetPreference)
+}
+
+func (ctl *NotificationController) List(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	unreadOnly := c.Query("unread") == "true"
+	notifications, err := ctl.Service.List(claims.UserID, unreadOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"notifications": notifications})
+}
+
+func (ctl *NotificationController) MarkRead(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err := ctl.Service.MarkRead(uint(id), claims.UserID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrNotificationNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "marked read"})
+}
+
+func (ctl *NotificationController) MarkAllRead(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if err := ctl.Service.MarkAllRead(claims.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to mark notifications read"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "all marked read"})
+}
+
+func (ctl *NotificationController) GetPreferences(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	prefs, err := ctl.Service.GetPreferences(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"preferences": prefs})
+}
+
+func (ctl *NotificationController) SetPreference(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req struct {
+		EventType string `json:"event_type" binding:"required"`
+		InApp     bool   `json:"in_app"`
+		Email     bool   `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	pref, err := ctl.Service.SetPreference(claims.UserID, req.EventType, req.InApp, req.Email)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"preference": pref})
+}
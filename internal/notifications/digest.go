@@ -0,0 +1,123 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+// digestCounts is one pass's summary of the review backlog.
+type digestCounts struct {
+	PendingRequests int64
+	OldestPending   *time.Time
+	PendingPhotos   int64
+	NewSubmissions  int64
+}
+
+// RunDigestLoop emails each admin a periodic summary of work waiting on
+// them. intervalHours is 24 for a daily digest, 168 for weekly.
+func (s *NotificationService) RunDigestLoop(ctx context.Context, intervalHours int) {
+	interval := time.Duration(intervalHours) * time.Hour
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	// The first window starts at boot; after that, "new since last
+	// digest" means since the previous pass.
+	since := time.Now().Add(-interval)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		now := time.Now()
+		if err := s.SendReviewerDigest(since); err != nil {
+			log.Printf("notifications: digest pass failed: %v", err)
+			continue
+		}
+		since = now
+	}
+}
+
+// SendReviewerDigest gathers the review backlog and mails it to every
+// admin who has not opted out. A digest with nothing to report is not
+// sent.
+func (s *NotificationService) SendReviewerDigest(since time.Time) error {
+	counts, err := s.digestCounts(since)
+	if err != nil {
+		return err
+	}
+	if counts.PendingRequests == 0 && counts.PendingPhotos == 0 && counts.NewSubmissions == 0 {
+		return nil
+	}
+	body := digestBody(counts, since)
+
+	var admins []models.Auth
+	if err := s.DB.Where("role = 'Admin'").Find(&admins).Error; err != nil {
+		return err
+	}
+	sent := 0
+	for _, admin := range admins {
+		if !s.wantsDigest(admin.ID) {
+			continue
+		}
+		if err := sendEmailHook(s.Config, admin.Email, "NordikDrive review digest", body); err != nil {
+			log.Printf("notifications: digest email to %s: %v", admin.Email, err)
+			continue
+		}
+		sent++
+	}
+	s.Logs.Record(0, "REVIEWER_DIGEST", fmt.Sprintf("sent=%d pending=%d photos=%d submissions=%d",
+		sent, counts.PendingRequests, counts.PendingPhotos, counts.NewSubmissions))
+	return nil
+}
+
+func (s *NotificationService) digestCounts(since time.Time) (*digestCounts, error) {
+	counts := &digestCounts{}
+	if err := s.DB.Model(&models.FileEditRequest{}).
+		Where("status = 'pending'").Count(&counts.PendingRequests).Error; err != nil {
+		return nil, err
+	}
+	if counts.PendingRequests > 0 {
+		var oldest models.FileEditRequest
+		if err := s.DB.Where("status = 'pending'").Order("created_at ASC").
+			First(&oldest).Error; err == nil {
+			counts.OldestPending = &oldest.CreatedAt
+		}
+	}
+	if err := s.DB.Model(&models.FileEditRequestPhoto{}).
+		Where("status = 'pending'").Count(&counts.PendingPhotos).Error; err != nil {
+		return nil, err
+	}
+	if err := s.DB.Model(&models.FormSubmission{}).
+		Where("created_at > ?", since).Count(&counts.NewSubmissions).Error; err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// wantsDigest applies the opt-out: the digest is on by default, and an
+// explicit preference row with email disabled turns it off — the
+// inverse of the opt-in default other events use.
+func (s *NotificationService) wantsDigest(userID uint) bool {
+	var pref models.NotificationPreference
+	err := s.DB.Where("user_id = ? AND event_type = ?", userID, EventReviewerDigest).
+		First(&pref).Error
+	if err != nil {
+		return true
+	}
+	return pref.Email
+}
+
+func digestBody(counts *digestCounts, since time.Time) string {
+	body := fmt.Sprintf("Pending edit requests: %d", counts.PendingRequests)
+	if counts.OldestPending != nil {
+		days := int(time.Since(*counts.OldestPending).Hours() / 24)
+		body += fmt.Sprintf(" (oldest has waited %d days)", days)
+	}
+	body += fmt.Sprintf("\nPhotos awaiting review: %d", counts.PendingPhotos)
+	body += fmt.Sprintf("\nNew form submissions since %s: %d", since.Format("2006-01-02"), counts.NewSubmissions)
+	return body
+}
@@ -0,0 +1,186 @@
+package notifications
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/config"
+	"github.com/datanorthnordik/nordikdriveapi/internal/logs"
+	"github.com/datanorthnordik/nordikdriveapi/internal/mail"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+// Event types users can tune delivery for.
+const (
+	EventEditRequestReviewed = "edit_request_reviewed"
+	EventPhotoReviewed       = "photo_reviewed"
+	EventFileReplaced        = "file_replaced"
+	EventRowChanged          = "row_changed"
+	EventEditRequestStale    = "edit_request_stale"
+	// EventReviewerDigest is the periodic backlog summary for admins;
+	// unlike the others it is email-only and on by default (see
+	// wantsDigest).
+	EventReviewerDigest = "reviewer_digest"
+)
+
+// eventTypes is the allow-list for preference updates.
+var eventTypes = map[string]bool{
+	EventEditRequestReviewed: true,
+	EventPhotoReviewed:       true,
+	EventFileReplaced:        true,
+	EventRowChanged:          true,
+	EventEditRequestStale:    true,
+	EventReviewerDigest:      true,
+}
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// sendEmailHook lets tests run the fan-out without a mail server.
+var sendEmailHook = sendEmail
+
+// NotificationService maintains each user's in-app inbox and fans events
+// out to email according to per-user preferences.
+type NotificationService struct {
+	DB     *gorm.DB
+	Config *config.Config
+	Logs   *logs.LogService
+}
+
+func NewNotificationService(db *gorm.DB, cfg *config.Config, logSvc *logs.LogService) *NotificationService {
+	return &NotificationService{DB: db, Config: cfg, Logs: logSvc}
+}
+
+// Notify delivers one event to each user through the channels their
+// preferences select. Delivery failures are logged, never returned:
+// notifications must not fail the action that triggered them.
+func (s *NotificationService) Notify(userIDs []uint, eventType, title, body string) {
+	for _, userID := range userIDs {
+		pref := s.preference(userID, eventType)
+		if pref.InApp {
+			n := models.Notification{
+				UserID:    userID,
+				EventType: eventType,
+				Title:     title,
+				Body:      body,
+			}
+			if err := s.DB.Create(&n).Error; err != nil {
+				log.Printf("notifications: store for user %d: %v", userID, err)
+			}
+		}
+		if pref.Email {
+			go s.emailUser(userID, title, body)
+		}
+	}
+}
+
+// emailUser looks up the user's address and sends the event by mail.
+func (s *NotificationService) emailUser(userID uint, subject, body string) {
+	var user models.Auth
+	if err := s.DB.First(&user, userID).Error; err != nil {
+		log.Printf("notifications: load user %d: %v", userID, err)
+		return
+	}
+	if err := sendEmailHook(s.Config, user.Email, subject, body); err != nil {
+		log.Printf("notifications: email user %d: %v", userID, err)
+	}
+}
+
+// preference returns the user's delivery choice for an event type, with
+// the in-app-only default for users who never set one.
+func (s *NotificationService) preference(userID uint, eventType string) models.NotificationPreference {
+	var pref models.NotificationPreference
+	err := s.DB.Where("user_id = ? AND event_type = ?", userID, eventType).First(&pref).Error
+	if err != nil {
+		return models.NotificationPreference{UserID: userID, EventType: eventType, InApp: true, Email: false}
+	}
+	return pref
+}
+
+// List returns the user's notifications, newest first. With unreadOnly
+// set, read entries are filtered out.
+func (s *NotificationService) List(userID uint, unreadOnly bool) ([]models.Notification, error) {
+	q := s.DB.Where("user_id = ?", userID).Order("created_at DESC").Limit(200)
+	if unreadOnly {
+		q = q.Where("read = false")
+	}
+	var out []models.Notification
+	if err := q.Find(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MarkRead marks one of the user's notifications as read.
+func (s *NotificationService) MarkRead(notificationID, userID uint) error {
+	result := s.DB.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", notificationID, userID).
+		Update("read", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead marks every unread notification of the user as read.
+func (s *NotificationService) MarkAllRead(userID uint) error {
+	return s.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read = false", userID).
+		Update("read", true).Error
+}
+
+// GetPreferences returns the user's stored preferences plus defaults for
+// event types they never touched.
+func (s *NotificationService) GetPreferences(userID uint) ([]models.NotificationPreference, error) {
+	var stored []models.NotificationPreference
+	if err := s.DB.Where("user_id = ?", userID).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+	byEvent := make(map[string]models.NotificationPreference, len(stored))
+	for _, pref := range stored {
+		byEvent[pref.EventType] = pref
+	}
+	out := make([]models.NotificationPreference, 0, len(eventTypes))
+	for _, event := range []string{EventEditRequestReviewed, EventPhotoReviewed, EventFileReplaced, EventRowChanged, EventEditRequestStale, EventReviewerDigest} {
+		if pref, ok := byEvent[event]; ok {
+			out = append(out, pref)
+			continue
+		}
+		if event == EventReviewerDigest {
+			// The digest is opt-out, so its untouched default is email-on.
+			out = append(out, models.NotificationPreference{UserID: userID, EventType: event, InApp: false, Email: true})
+			continue
+		}
+		out = append(out, models.NotificationPreference{UserID: userID, EventType: event, InApp: true, Email: false})
+	}
+	return out, nil
+}
+
+// SetPreference upserts the user's delivery choice for one event type.
+func (s *NotificationService) SetPreference(userID uint, eventType string, inApp, email bool) (*models.NotificationPreference, error) {
+	if !eventTypes[eventType] {
+		return nil, fmt.Errorf("unknown event type %q", eventType)
+	}
+	var pref models.NotificationPreference
+	err := s.DB.Where("user_id = ? AND event_type = ?", userID, eventType).First(&pref).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		pref = models.NotificationPreference{UserID: userID, EventType: eventType}
+	} else if err != nil {
+		return nil, err
+	}
+	pref.InApp = inApp
+	pref.Email = email
+	if err := s.DB.Save(&pref).Error; err != nil {
+		return nil, err
+	}
+	return &pref, nil
+}
+
+func sendEmail(cfg *config.Config, to, subject, body string) error {
+	return mail.Send(cfg, to, mail.Notification(subject, body))
+}
@@ -0,0 +1,77 @@
+// Package permissions resolves named permissions against role
+// definitions so controllers can ask for a capability instead of
+// hard-coding the "Admin" role string.
+package permissions
+
+import (
+	"encoding/json"
+	"sync"
+
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+// The named permissions a role can grant.
+const (
+	Upload          = "upload"
+	ApproveRequests = "approve_requests"
+	ManageAccess    = "manage_access"
+	ViewLogs        = "view_logs"
+	ExportMedia     = "export_media"
+)
+
+// Known lists every defined permission, for validating role edits.
+var Known = map[string]bool{
+	Upload:          true,
+	ApproveRequests: true,
+	ManageAccess:    true,
+	ViewLogs:        true,
+	ExportMedia:     true,
+}
+
+// rolePerms caches role name → granted permissions. Seeded from the
+// roles table at startup and reloaded whenever a role is edited,
+// following the same process-wide pattern as the maintenance switch.
+var (
+	mu        sync.RWMutex
+	rolePerms = map[string]map[string]bool{}
+)
+
+// Reload replaces the cache from the roles table.
+func Reload(db *gorm.DB) error {
+	var roles []models.Role
+	if err := db.Find(&roles).Error; err != nil {
+		return err
+	}
+	next := make(map[string]map[string]bool, len(roles))
+	for _, role := range roles {
+		granted := map[string]bool{}
+		if len(role.Permissions) > 0 {
+			var names []string
+			if err := json.Unmarshal(role.Permissions, &names); err != nil {
+				return err
+			}
+			for _, name := range names {
+				granted[name] = true
+			}
+		}
+		next[role.Name] = granted
+	}
+	mu.Lock()
+	rolePerms = next
+	mu.Unlock()
+	return nil
+}
+
+// Check reports whether the role grants the permission. Admin holds
+// every permission unconditionally, so existing Admin-only behaviour is
+// unchanged.
+func Check(role, perm string) bool {
+	if role == "Admin" {
+		return true
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	return rolePerms[role][perm]
+}
@@ -0,0 +1,59 @@
+package public
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Service is the surface PublicController depends on, implemented by
+// PublicService. Kept as an interface so alternate backends can be
+// wired in by the server container.
+type Service interface {
+	ListFiles() ([]PublicFile, error)
+	GetFileData(fileID uint, page int) (*PublicFile, []map[string]string, error)
+}
+
+var _ Service = (*PublicService)(nil)
+
+// PublicController exposes the unauthenticated /public/api endpoints.
+type PublicController struct {
+	Service Service
+}
+
+func NewPublicController(service Service) *PublicController {
+	return &PublicController{Service: service}
+}
+
+// RegisterRoutes mounts the public dataset endpoints. The group carries
+// no auth middleware; the service layer enforces publication status.
+func (ctl *PublicController) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/files", ctl.ListFiles)
+	rg.GET("/files/:id/data", ctl.GetFileData)
+}
+
+func (ctl *PublicController) ListFiles(c *gin.Context) {
+	files, err := ctl.Service.ListFiles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list files"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"files": files})
+}
+
+func (ctl *PublicController) GetFileData(c *gin.Context) {
+	fileID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	page, _ := strconv.Atoi(c.Query("page"))
+	file, rows, err := ctl.Service.GetFileData(uint(fileID), page)
+	if err != nil {
+		if errors.Is(err, ErrNotPublic) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load file"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"file": file, "rows": rows, "page_size": publicPageSize})
+}
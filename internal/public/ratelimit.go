@@ -0,0 +1,38 @@
+package public
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit is a fixed-window per-IP limiter for the public surface.
+// It is deliberately simple: the public API serves small embedded
+// dataset views, not bulk export, so a coarse window is enough.
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	var (
+		mu      sync.Mutex
+		counts  = map[string]int{}
+		resetAt = time.Now().Add(window)
+	)
+	return func(c *gin.Context) {
+		mu.Lock()
+		now := time.Now()
+		if now.After(resetAt) {
+			counts = map[string]int{}
+			resetAt = now.Add(window)
+		}
+		ip := c.ClientIP()
+		counts[ip]++
+		over := counts[ip] > limit
+		mu.Unlock()
+
+		if over {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
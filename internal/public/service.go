@@ -0,0 +1,131 @@
+package public
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+var ErrNotPublic = errors.New("file is not public")
+
+// publicPageSize caps how many rows one unauthenticated request can pull.
+const publicPageSize = 100
+
+// PublicFile is the metadata exposed for a published dataset. It omits
+// uploader identity and internal flags on purpose.
+type PublicFile struct {
+	ID        uint      `json:"id"`
+	Filename  string    `json:"filename"`
+	Columns   []string  `json:"columns"`
+	Rows      int       `json:"rows"`
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PublicService serves the unauthenticated read-only dataset API. Only
+// files explicitly published and past their embargo date are visible,
+// and rows are trimmed to the file's column allow-list.
+type PublicService struct {
+	DB *gorm.DB
+}
+
+func NewPublicService(db *gorm.DB) *PublicService {
+	return &PublicService{DB: db}
+}
+
+// ListFiles returns all published, non-embargoed files.
+func (s *PublicService) ListFiles() ([]PublicFile, error) {
+	var files []models.File
+	if err := s.DB.
+		Where("public = true AND is_delete = false AND private = false").
+		Where("embargo_until IS NULL OR embargo_until <= ?", time.Now()).
+		Order("filename").Find(&files).Error; err != nil {
+		return nil, err
+	}
+	out := make([]PublicFile, 0, len(files))
+	for _, f := range files {
+		out = append(out, toPublicFile(f))
+	}
+	return out, nil
+}
+
+// GetFileData returns one page of rows for a published file, with each
+// row trimmed to the public column allow-list.
+func (s *PublicService) GetFileData(fileID uint, page int) (*PublicFile, []map[string]string, error) {
+	file, err := s.publicFile(fileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	var rows []models.FileData
+	if err := s.DB.
+		Where("file_id = ? AND version = ?", file.ID, file.Version).
+		Order("id").
+		Limit(publicPageSize).
+		Offset((page - 1) * publicPageSize).
+		Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	allowed := allowedColumns(*file)
+	out := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		var data map[string]string
+		if err := json.Unmarshal(row.RowData, &data); err != nil {
+			continue
+		}
+		trimmed := make(map[string]string, len(allowed))
+		for column := range allowed {
+			if v, ok := data[column]; ok {
+				trimmed[column] = v
+			}
+		}
+		out = append(out, trimmed)
+	}
+	pub := toPublicFile(*file)
+	return &pub, out, nil
+}
+
+// publicFile loads a file and checks it is published and past embargo.
+func (s *PublicService) publicFile(fileID uint) (*models.File, error) {
+	var file models.File
+	if err := s.DB.First(&file, "id = ? AND is_delete = false", fileID).Error; err != nil {
+		return nil, ErrNotPublic
+	}
+	if !file.Public || file.Private {
+		return nil, ErrNotPublic
+	}
+	if file.EmbargoUntil != nil && file.EmbargoUntil.After(time.Now()) {
+		return nil, ErrNotPublic
+	}
+	return &file, nil
+}
+
+func allowedColumns(file models.File) map[string]bool {
+	var columns []string
+	_ = json.Unmarshal(file.PublicColumns, &columns)
+	allowed := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		allowed[c] = true
+	}
+	return allowed
+}
+
+func toPublicFile(file models.File) PublicFile {
+	var columns []string
+	_ = json.Unmarshal(file.PublicColumns, &columns)
+	return PublicFile{
+		ID:        file.ID,
+		Filename:  file.Filename,
+		Columns:   columns,
+		Rows:      file.Rows,
+		Version:   file.Version,
+		UpdatedAt: file.UpdatedAt,
+	}
+}
@@ -0,0 +1,104 @@
+package role
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/middleware"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+)
+
+// Service is the surface RoleController depends on, implemented by
+// RoleService. Kept as an interface so alternate backends can be wired
+// in by the server container.
+type Service interface {
+	GetAll() ([]models.Role, error)
+	CreateRole(name string, perms []string, adminID uint) (*models.Role, error)
+	UpdateRole(roleID uint, perms []string, adminID uint) (*models.Role, error)
+}
+
+var _ Service = (*RoleService)(nil)
+
+// RoleController exposes the role lookup endpoint.
+type RoleController struct {
+	Service Service
+}
+
+func NewRoleController(service Service) *RoleController {
+	return &RoleController{Service: service}
+}
+
+func (ctl *RoleController) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/roles", ctl.GetAll)
+}
+
+// RegisterAdminRoutes mounts role management under /api/admin.
+func (ctl *RoleController) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	rg.POST("/roles", ctl.CreateRole)
+	rg.PUT("/roles/:id", ctl.UpdateRole)
+}
+
+// rolePayload is the body of role create/edit calls.
+type rolePayload struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// CreateRole defines a custom role with named permissions.
+func (ctl *RoleController) CreateRole(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	var req rolePayload
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing role name"})
+		return
+	}
+	role, err := ctl.Service.CreateRole(req.Name, req.Permissions, claims.UserID)
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"role": role})
+}
+
+// UpdateRole replaces a role's permission set.
+func (ctl *RoleController) UpdateRole(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	roleID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var req rolePayload
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	role, err := ctl.Service.UpdateRole(uint(roleID), req.Permissions, claims.UserID)
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"role": role})
+}
+
+func respondRoleError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrRoleNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrRoleExists):
+		status = http.StatusConflict
+	case errors.Is(err, ErrUnknownPermission), errors.Is(err, ErrBuiltinRole):
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+func (ctl *RoleController) GetAll(c *gin.Context) {
+	roles, err := ctl.Service.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list roles"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
@@ -0,0 +1,106 @@
+package role
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/datanorthnordik/nordikdriveapi/internal/logs"
+	"github.com/datanorthnordik/nordikdriveapi/internal/models"
+	"github.com/datanorthnordik/nordikdriveapi/internal/permissions"
+)
+
+var (
+	ErrRoleExists        = errors.New("a role with this name already exists")
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrUnknownPermission = errors.New("unknown permission")
+	ErrBuiltinRole       = errors.New("the Admin role cannot be edited")
+)
+
+// RoleService manages role definitions and their permission sets.
+type RoleService struct {
+	DB   *gorm.DB
+	Logs *logs.LogService
+}
+
+func NewRoleService(db *gorm.DB, logSvc *logs.LogService) *RoleService {
+	return &RoleService{DB: db, Logs: logSvc}
+}
+
+// GetAll returns every defined role.
+func (s *RoleService) GetAll() ([]models.Role, error) {
+	var roles []models.Role
+	if err := s.DB.Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// validatePermissions rejects names the permissions package does not
+// define, so a typo in a role edit fails loudly instead of silently
+// granting nothing.
+func validatePermissions(perms []string) error {
+	for _, perm := range perms {
+		if !permissions.Known[perm] {
+			return fmt.Errorf("%w: %q", ErrUnknownPermission, perm)
+		}
+	}
+	return nil
+}
+
+// CreateRole defines a custom role with a permission set and reloads
+// the process-wide permission cache.
+func (s *RoleService) CreateRole(name string, perms []string, adminID uint) (*models.Role, error) {
+	name = strings.TrimSpace(name)
+	if err := validatePermissions(perms); err != nil {
+		return nil, err
+	}
+	var existing models.Role
+	if err := s.DB.Where("name = ?", name).First(&existing).Error; err == nil {
+		return nil, ErrRoleExists
+	}
+	encoded, err := json.Marshal(perms)
+	if err != nil {
+		return nil, err
+	}
+	role := models.Role{Name: name, Permissions: datatypes.JSON(encoded)}
+	if err := s.DB.Create(&role).Error; err != nil {
+		return nil, fmt.Errorf("create role: %w", err)
+	}
+	if err := permissions.Reload(s.DB); err != nil {
+		return nil, err
+	}
+	s.Logs.Record(adminID, "CREATE_ROLE", fmt.Sprintf("role=%s permissions=%s", name, strings.Join(perms, ",")))
+	return &role, nil
+}
+
+// UpdateRole replaces a role's permission set and reloads the cache.
+// Admin is implicit-everything and stays out of reach.
+func (s *RoleService) UpdateRole(roleID uint, perms []string, adminID uint) (*models.Role, error) {
+	if err := validatePermissions(perms); err != nil {
+		return nil, err
+	}
+	var role models.Role
+	if err := s.DB.First(&role, roleID).Error; err != nil {
+		return nil, ErrRoleNotFound
+	}
+	if role.Name == "Admin" {
+		return nil, ErrBuiltinRole
+	}
+	encoded, err := json.Marshal(perms)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.DB.Model(&role).Update("permissions", datatypes.JSON(encoded)).Error; err != nil {
+		return nil, err
+	}
+	if err := permissions.Reload(s.DB); err != nil {
+		return nil, err
+	}
+	s.Logs.Record(adminID, "UPDATE_ROLE", fmt.Sprintf("role=%s permissions=%s", role.Name, strings.Join(perms, ",")))
+	return &role, nil
+}
@@ -0,0 +1,134 @@
+// Package search provides a declarative field registry for endpoints
+// that accept user-supplied filter clauses. Each endpoint declares its
+// filterable fields once — API name, backing SQL column, value type and
+// allowed operators — and every clause is validated against that
+// declaration before any SQL is built, so column names never come from
+// user input.
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// Type is the value type of a field, which decides its default operator
+// set and how values are validated.
+type Type string
+
+const (
+	TypeString Type = "string"
+	TypeNumber Type = "number"
+)
+
+var (
+	ErrUnknownField = errors.New("unknown search field")
+	ErrUnknownOp    = errors.New("unknown search op")
+	ErrBadValue     = errors.New("invalid search value")
+)
+
+// Default operator sets per type; a Field may narrow them via Ops.
+var (
+	stringOps = []string{"eq", "neq", "contains", "starts_with"}
+	numberOps = []string{"eq", "neq", "gt", "gte", "lt", "lte"}
+)
+
+// Field declares one filterable field. Column is the SQL expression the
+// field maps onto (never exposed to clients). An empty Type means
+// TypeString; nil Ops allows every operator of the type.
+type Field struct {
+	Column string
+	Type   Type
+	Ops    []string
+}
+
+func (f Field) allows(op string) bool {
+	allowed := f.Ops
+	if allowed == nil {
+		if f.Type == TypeNumber {
+			allowed = numberOps
+		} else {
+			allowed = stringOps
+		}
+	}
+	for _, a := range allowed {
+		if a == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry is the immutable set of fields and sort expressions one
+// endpoint exposes. Build it once at package init.
+type Registry struct {
+	fields map[string]Field
+	sorts  map[string]string
+}
+
+// NewRegistry builds a registry from field declarations and an optional
+// sort-name → SQL-expression allow-list.
+func NewRegistry(fields map[string]Field, sorts map[string]string) *Registry {
+	return &Registry{fields: fields, sorts: sorts}
+}
+
+// Apply validates one clause against the registry and adds its WHERE
+// condition. Unknown fields, operators not allowed for the field, and
+// unparseable numeric values are rejected.
+func (r *Registry) Apply(q *gorm.DB, field, op, value string) (*gorm.DB, error) {
+	f, ok := r.fields[field]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownField, field)
+	}
+	if !f.allows(op) {
+		return nil, fmt.Errorf("%w %q for field %q", ErrUnknownOp, op, field)
+	}
+	if f.Type == TypeNumber {
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("%w: field %q needs a number, got %q", ErrBadValue, field, value)
+		}
+	}
+	switch op {
+	case "eq":
+		return q.Where(f.Column+" = ?", value), nil
+	case "neq":
+		return q.Where(f.Column+" <> ?", value), nil
+	case "contains":
+		return q.Where(f.Column+" ILIKE ?", "%"+value+"%"), nil
+	case "starts_with":
+		return q.Where(f.Column+" ILIKE ?", value+"%"), nil
+	case "gt":
+		return q.Where(f.Column+" > ?", value), nil
+	case "gte":
+		return q.Where(f.Column+" >= ?", value), nil
+	case "lt":
+		return q.Where(f.Column+" < ?", value), nil
+	case "lte":
+		return q.Where(f.Column+" <= ?", value), nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownOp, op)
+	}
+}
+
+// OrderClause validates a sort name against the allow-list and returns
+// the ORDER BY expression. An empty sort falls back to defaultSort; dir
+// is "asc" or "desc" (default desc).
+func (r *Registry) OrderClause(sort, dir, defaultSort string) (string, error) {
+	if sort == "" {
+		sort = defaultSort
+	}
+	column, ok := r.sorts[sort]
+	if !ok {
+		return "", fmt.Errorf("unknown sort field %q", sort)
+	}
+	switch dir {
+	case "", "desc":
+		return column + " DESC", nil
+	case "asc":
+		return column + " ASC", nil
+	default:
+		return "", fmt.Errorf("unknown sort direction %q", dir)
+	}
+}
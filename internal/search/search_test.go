@@ -0,0 +1,63 @@
+package search
+
+import (
+	"errors"
+	"testing"
+)
+
+var testRegistry = NewRegistry(
+	map[string]Field{
+		"username": {Column: "users.username"},
+		"status":   {Column: "status", Ops: []string{"eq"}},
+		"row_id":   {Column: "row_id", Type: TypeNumber},
+	},
+	map[string]string{
+		"date": "created_at",
+		"user": "users.username",
+	},
+)
+
+func TestApplyRejectsBadClauses(t *testing.T) {
+	if _, err := testRegistry.Apply(nil, "password", "eq", "x"); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("unknown field: got %v, want ErrUnknownField", err)
+	}
+	if _, err := testRegistry.Apply(nil, "username", "regex", "x"); !errors.Is(err, ErrUnknownOp) {
+		t.Errorf("unknown op: got %v, want ErrUnknownOp", err)
+	}
+	if _, err := testRegistry.Apply(nil, "status", "contains", "x"); !errors.Is(err, ErrUnknownOp) {
+		t.Errorf("op outside the field's allow-list: got %v, want ErrUnknownOp", err)
+	}
+	if _, err := testRegistry.Apply(nil, "row_id", "gt", "abc"); !errors.Is(err, ErrBadValue) {
+		t.Errorf("non-numeric value: got %v, want ErrBadValue", err)
+	}
+	if _, err := testRegistry.Apply(nil, "row_id", "contains", "1"); !errors.Is(err, ErrUnknownOp) {
+		t.Errorf("string op on number field: got %v, want ErrUnknownOp", err)
+	}
+}
+
+func TestOrderClause(t *testing.T) {
+	cases := []struct {
+		sort, dir, want string
+	}{
+		{"", "", "created_at DESC"}, // falls back to the default sort
+		{"user", "asc", "users.username ASC"},
+		{"date", "desc", "created_at DESC"},
+	}
+	for _, tc := range cases {
+		got, err := testRegistry.OrderClause(tc.sort, tc.dir, "date")
+		if err != nil {
+			t.Errorf("OrderClause(%q, %q): %v", tc.sort, tc.dir, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("OrderClause(%q, %q) = %q, want %q", tc.sort, tc.dir, got, tc.want)
+		}
+	}
+
+	if _, err := testRegistry.OrderClause("password", "asc", "date"); err == nil {
+		t.Error("unknown sort name accepted")
+	}
+	if _, err := testRegistry.OrderClause("date", "sideways", "date"); err == nil {
+		t.Error("unknown sort direction accepted")
+	}
+}
@@ -0,0 +1,32 @@
+package util
+
+import (
+	"path"
+	"regexp"
+)
+
+// FilenameSanitizer rewrites untrusted filenames so they are safe to use
+// in GCS object names and Content-Disposition headers. Unsafe matches
+// the character runs to rewrite; Replacement is what they become.
+type FilenameSanitizer struct {
+	Unsafe      *regexp.Regexp
+	Replacement string
+}
+
+// Sanitize strips any directory components and rewrites unsafe runs.
+func (s FilenameSanitizer) Sanitize(name string) string {
+	name = path.Base(name)
+	return s.Unsafe.ReplaceAllString(name, s.Replacement)
+}
+
+// defaultSanitizer keeps ASCII letters, digits, dots, underscores and
+// hyphens, the rules every package used before they were consolidated.
+var defaultSanitizer = FilenameSanitizer{
+	Unsafe:      regexp.MustCompile(`[^a-zA-Z0-9._-]+`),
+	Replacement: "_",
+}
+
+// SanitizeFilename applies the default rules.
+func SanitizeFilename(name string) string {
+	return defaultSanitizer.Sanitize(name)
+}
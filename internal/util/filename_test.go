@@ -0,0 +1,28 @@
+package util
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"report 2024.xlsx", "report_2024.xlsx"},
+		{"../../etc/passwd", "passwd"},
+		{"membres première nation.csv", "membres_premi_re_nation.csv"},
+		{"already-safe_1.pdf", "already-safe_1.pdf"},
+		{`a"b<c>d.txt`, "a_b_c_d.txt"},
+	}
+	for _, tc := range cases {
+		if got := SanitizeFilename(tc.in); got != tc.want {
+			t.Errorf("SanitizeFilename(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestFilenameSanitizerCustomRules(t *testing.T) {
+	s := FilenameSanitizer{Unsafe: regexp.MustCompile(`[0-9]+`), Replacement: "N"}
+	if got := s.Sanitize("dir/report2024.csv"); got != "reportN.csv" {
+		t.Errorf("Sanitize = %q, want reportN.csv", got)
+	}
+}
@@ -0,0 +1,22 @@
+// Package util holds small helpers shared across the service packages:
+// gs:// URL parsing and filename sanitization used to be duplicated
+// per-package, and the copies drifted.
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseGSURL splits a gs://bucket/object URL into bucket and object.
+func ParseGSURL(raw string) (bucket, object string, err error) {
+	if !strings.HasPrefix(raw, "gs://") {
+		return "", "", fmt.Errorf("not a gs:// url: %s", raw)
+	}
+	rest := strings.TrimPrefix(raw, "gs://")
+	parts := strings.SplitN(rest, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("malformed gs:// url: %s", raw)
+	}
+	return parts[0], parts[1], nil
+}
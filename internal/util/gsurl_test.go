@@ -0,0 +1,25 @@
+package util
+
+import "testing"
+
+func TestParseGSURL(t *testing.T) {
+	bucket, object, err := ParseGSURL("gs://my-bucket/media/photos/1.jpg")
+	if err != nil {
+		t.Fatalf("ParseGSURL: %v", err)
+	}
+	if bucket != "my-bucket" || object != "media/photos/1.jpg" {
+		t.Errorf("got (%q, %q), want (my-bucket, media/photos/1.jpg)", bucket, object)
+	}
+
+	for _, raw := range []string{
+		"https://example.com/a/b", // wrong scheme
+		"gs://bucket-only",
+		"gs:///no-bucket",
+		"gs://bucket/",
+		"",
+	} {
+		if _, _, err := ParseGSURL(raw); err == nil {
+			t.Errorf("ParseGSURL(%q) accepted a malformed url", raw)
+		}
+	}
+}